@@ -0,0 +1,171 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultAggregateSamples is the number of distinct messages kept per
+// aggregated fingerprint.
+const defaultAggregateSamples = 5
+
+// maxAggregateBuckets is the number of fingerprints aggregated per window.
+const maxAggregateBuckets = 1000
+
+// WithAggregation sends a single summary event per fingerprint and window
+// for entries of the given levels, instead of one event per entry. The
+// summary carries the number of occurrences, the first and last time they
+// were seen, up to maxSamples distinct log messages and, per extra key, up
+// to maxSamples distinct values, so e.g. the affected user ids of an error
+// storm are visible in the summary. Fingerprints seen only once in a window
+// are sent unchanged, as are the entries of further fingerprints once 1000
+// are aggregated in a window. The window must be positive.
+func WithAggregation(window time.Duration, maxSamples int, levels ...logrus.Level) Option {
+	return func(hook *SentryHook) {
+		if window <= 0 {
+			hook.optionErr = fmt.Errorf("sentryhook: aggregation window %v is not positive", window)
+			return
+		}
+		if maxSamples <= 0 {
+			maxSamples = defaultAggregateSamples
+		}
+		a := &aggregator{
+			window:     window,
+			maxSamples: maxSamples,
+			levels:     make(map[logrus.Level]bool, len(levels)),
+			buckets:    make(map[string]*aggregateBucket),
+		}
+		for _, level := range levels {
+			a.levels[level] = true
+		}
+		hook.aggregator = a
+	}
+}
+
+type aggregator struct {
+	window     time.Duration
+	maxSamples int
+	levels     map[logrus.Level]bool
+
+	mu      sync.Mutex
+	buckets map[string]*aggregateBucket
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type aggregateBucket struct {
+	event   *sentrygo.Event
+	count   int
+	first   time.Time
+	last    time.Time
+	samples []string
+	// distinct extra values per key, and their serializations to tell
+	// them apart
+	extraSamples map[string][]interface{}
+	extraSeen    map[string]bool
+}
+
+// start launches the goroutine emitting summaries at the end of every window.
+func (a *aggregator) start(hook *SentryHook) {
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.window)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.flush(hook)
+			case <-a.stop:
+				a.flush(hook)
+				return
+			}
+		}
+	}()
+}
+
+// close emits the pending summaries and stops the aggregator.
+func (a *aggregator) close() {
+	close(a.stop)
+	<-a.done
+}
+
+// add records the event if its level is aggregated and reports whether it
+// was consumed.
+func (a *aggregator) add(event *sentrygo.Event, entry *logrus.Entry) bool {
+	if !a.levels[entry.Level] {
+		return false
+	}
+	key := dedupKey(event)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	bucket, ok := a.buckets[key]
+	if !ok {
+		if len(a.buckets) >= maxAggregateBuckets {
+			return false
+		}
+		bucket = &aggregateBucket{
+			event:        event,
+			first:        event.Timestamp,
+			extraSamples: make(map[string][]interface{}),
+			extraSeen:    make(map[string]bool),
+		}
+		a.buckets[key] = bucket
+	}
+	bucket.count++
+	bucket.last = event.Timestamp
+	if len(bucket.samples) < a.maxSamples && !containsString(bucket.samples, entry.Message) {
+		bucket.samples = append(bucket.samples, entry.Message)
+	}
+	for k, v := range event.Extra {
+		if k == formattedExtraKey || len(bucket.extraSamples[k]) >= a.maxSamples {
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil || bucket.extraSeen[k+"\x00"+string(data)] {
+			continue
+		}
+		bucket.extraSeen[k+"\x00"+string(data)] = true
+		bucket.extraSamples[k] = append(bucket.extraSamples[k], v)
+	}
+	return true
+}
+
+// flush emits one event per fingerprint recorded since the last flush.
+func (a *aggregator) flush(hook *SentryHook) {
+	a.mu.Lock()
+	buckets := a.buckets
+	a.buckets = make(map[string]*aggregateBucket)
+	a.mu.Unlock()
+
+	for _, bucket := range buckets {
+		event := bucket.event
+		if bucket.count > 1 {
+			event.Timestamp = bucket.last
+			event.Extra["aggregate_count"] = bucket.count
+			event.Extra["aggregate_first_seen"] = bucket.first
+			event.Extra["aggregate_last_seen"] = bucket.last
+			event.Extra["aggregate_samples"] = bucket.samples
+			event.Extra["aggregate_extra_samples"] = bucket.extraSamples
+			event.Tags["aggregated"] = "true"
+			event.Tags["aggregate_count"] = strconv.Itoa(bucket.count)
+		}
+		_ = hook.dispatch(event, nil)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
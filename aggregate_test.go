@@ -0,0 +1,76 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAggregation(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(),
+		WithAggregation(time.Hour, 2, logrus.WarnLevel),
+		WithMessageBuilder(MessageBuilderFunc(func(entry *logrus.Entry) string {
+			return "cache miss"
+		})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.WithField("user", 1).Warn("cache miss for user 1")
+	log.WithField("user", 2).Warn("cache miss for user 2")
+	log.WithField("user", 2).Warn("cache miss for user 3")
+	log.Error("not aggregated")
+	if n := len(server.Events()); n != 1 {
+		t.Fatalf("expected only the error to be sent right away, got %d events", n)
+	}
+
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected a summary event on close, got %d events", len(events))
+	}
+	summary := events[1]
+	if summary.Tags["aggregate_count"] != "3" {
+		t.Fatalf("expected 3 occurrences, got %+v", summary.Tags)
+	}
+	samples, _ := summary.Extra["aggregate_samples"].([]interface{})
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %v", summary.Extra["aggregate_samples"])
+	}
+	extraSamples, _ := summary.Extra["aggregate_extra_samples"].(map[string]interface{})
+	if users, _ := extraSamples["user"].([]interface{}); len(users) != 2 || users[0] != 1.0 || users[1] != 2.0 {
+		t.Fatalf("expected the distinct users 1 and 2, got %v", summary.Extra["aggregate_extra_samples"])
+	}
+}
+
+func TestAggregationLimits(t *testing.T) {
+	if _, err := NewSentryHook("", WithAggregation(0, 3, logrus.ErrorLevel)); err == nil {
+		t.Fatal("expected an error for a zero window")
+	}
+
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithAggregation(time.Hour, 2, logrus.WarnLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	for i := 0; i <= maxAggregateBuckets; i++ {
+		log.Warnf("cache miss %d", i)
+	}
+	if n := len(server.Events()); n != 1 {
+		t.Fatalf("expected the fingerprint beyond the limit sent right away, got %d events", n)
+	}
+	if n := len(hook.aggregator.buckets); n != maxAggregateBuckets {
+		t.Fatalf("expected %d aggregated fingerprints, got %d", maxAggregateBuckets, n)
+	}
+}
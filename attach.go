@@ -0,0 +1,87 @@
+package sentryhook
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sourceLoggerTag is the tag holding the name of the logger an event was
+// logged to.
+const sourceLoggerTag = "source_logger"
+
+// loggerTag is the tag holding the name of the component which logged the
+// event, see WithLoggerField.
+const loggerTag = "logger"
+
+// WithLoggerField names the entry field holding the component or subsystem
+// which logged the entry, e.g. "component". Its value is sent as the
+// event's logger and as the tag "logger", so issues can be filtered by it
+// in Sentry. Entries without the field use the name their logger was
+// attached with, see WithSourceName. The field is still sent as extra data.
+func WithLoggerField(field string) Option {
+	return func(hook *SentryHook) {
+		hook.loggerField = field
+	}
+}
+
+// attachment holds the settings of a single AddToLogger call.
+type attachment struct {
+	sourceName string
+}
+
+// AttachOption configures how a hook is attached to a logger.
+type AttachOption func(a *attachment)
+
+// WithSourceName names the logger the hook is attached to. Events logged to
+// that logger are tagged with the name, which tells apart events from
+// several loggers sharing one hook.
+func WithSourceName(name string) AttachOption {
+	return func(a *attachment) {
+		a.sourceName = name
+	}
+}
+
+// AddToLogger adds the hook to the logger's hooks, applying the attach
+// options to entries logged through that logger.
+func (hook *SentryHook) AddToLogger(logger *logrus.Logger, opts ...AttachOption) {
+	a := attachment{}
+	for _, o := range opts {
+		o(&a)
+	}
+	// events of children are built by their parent
+	owner := hook
+	if hook.parent != nil {
+		owner = hook.parent
+	}
+	if a.sourceName != "" {
+		owner.sourcesMu.Lock()
+		if owner.sources == nil {
+			owner.sources = make(map[*logrus.Logger]string)
+		}
+		owner.sources[logger] = a.sourceName
+		owner.sourcesMu.Unlock()
+	}
+	logger.AddHook(hook)
+}
+
+// loggerName returns the name of the component which logged the entry:
+// the logger field, or the name the entry's logger was attached with.
+func (hook *SentryHook) loggerName(entry *logrus.Entry) string {
+	if hook.loggerField != "" {
+		if v, ok := entry.Data[hook.loggerField]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return hook.sourceName(entry)
+}
+
+// sourceName returns the name the entry's logger was attached with.
+func (hook *SentryHook) sourceName(entry *logrus.Entry) string {
+	if entry.Logger == nil {
+		return ""
+	}
+	hook.sourcesMu.RLock()
+	defer hook.sourcesMu.RUnlock()
+	return hook.sources[entry.Logger]
+}
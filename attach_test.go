@@ -0,0 +1,61 @@
+package sentryhook
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAddToLoggerTagsSource(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit := logrus.New()
+	app := logrus.New()
+	hook.AddToLogger(audit, WithSourceName("audit"))
+	hook.AddToLogger(app)
+
+	audit.Error("from audit")
+	app.Error("from app")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Tags[sourceLoggerTag] != "audit" {
+		t.Fatalf("expected source tag audit, got %q", events[0].Tags[sourceLoggerTag])
+	}
+	if _, ok := events[1].Tags[sourceLoggerTag]; ok {
+		t.Fatalf("expected no source tag for unnamed logger, got %q", events[1].Tags[sourceLoggerTag])
+	}
+}
+
+func TestLoggerField(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithLoggerField("component"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit := logrus.New()
+	hook.AddToLogger(audit, WithSourceName("audit"))
+
+	audit.WithField("component", "billing").Error("from billing")
+	audit.Error("from audit")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Logger != "billing" || events[0].Tags[loggerTag] != "billing" {
+		t.Fatalf("expected logger billing, got %q tagged %q", events[0].Logger, events[0].Tags[loggerTag])
+	}
+	if events[1].Logger != "audit" || events[1].Tags[loggerTag] != "audit" {
+		t.Fatalf("expected logger audit, got %q tagged %q", events[1].Logger, events[1].Tags[loggerTag])
+	}
+}
@@ -0,0 +1,89 @@
+package sentryhook
+
+import (
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// AttachmentsField is a reserved field carrying an Attachment or a slice of
+// them, which are uploaded with the event:
+//
+//	log.WithField(sentryhook.AttachmentsField, sentryhook.Attachment{
+//		Filename: "config.json",
+//		Payload:  dump,
+//	}).Error("invalid configuration")
+const AttachmentsField = "sentry_attachments"
+
+// Attachment is a file uploaded with an event, e.g. a configuration dump, a
+// request body or a goroutine dump captured when the error was logged.
+type Attachment struct {
+	Filename string
+	// defaults to application/octet-stream
+	ContentType string
+	Payload     []byte
+}
+
+// WithAttachmentExtractor sets a function returning further attachments
+// for an entry, in addition to those of the AttachmentsField.
+func WithAttachmentExtractor(extractor func(entry *logrus.Entry) []Attachment) Option {
+	return func(hook *SentryHook) {
+		hook.attachmentExtractor = extractor
+	}
+}
+
+// entryAttachments returns the attachments of the entry, nil for
+// aggregated events.
+func (hook *SentryHook) entryAttachments(entry *logrus.Entry) []Attachment {
+	if entry == nil {
+		return nil
+	}
+	var attachments []Attachment
+	switch v := entry.Data[AttachmentsField].(type) {
+	case Attachment:
+		attachments = append(attachments, v)
+	case *Attachment:
+		if v != nil {
+			attachments = append(attachments, *v)
+		}
+	case []Attachment:
+		attachments = append(attachments, v...)
+	}
+	if hook.attachmentExtractor != nil {
+		attachments = append(attachments, hook.attachmentExtractor(entry)...)
+	}
+	return attachments
+}
+
+// sendAttachments uploads the attachments of a delivered event to the
+// hook's own client; destinations don't receive attachments. Failures are
+// reported on Errors.
+func (hook *SentryHook) sendAttachments(event *sentrygo.Event, attachments []Attachment) {
+	if len(attachments) == 0 || event.EventID == "" {
+		return
+	}
+	if !hook.Supports(CapabilityAttachments) {
+		hook.debug(event, logrus.Fields{"attachments": len(attachments)}, "attachments not supported by the server")
+		return
+	}
+	items := make([]envelopeItem, 0, len(attachments))
+	for _, a := range attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		items = append(items, envelopeItem{
+			Type:    "attachment",
+			Payload: a.Payload,
+			Header: map[string]interface{}{
+				"filename":     a.Filename,
+				"content_type": contentType,
+			},
+		})
+	}
+	ctx, cancel := hook.flushContext()
+	defer cancel()
+	header := map[string]interface{}{"event_id": string(event.EventID)}
+	if err := hook.sendEnvelopeWith(ctx, hook.deliveryClient(), header, items...); err != nil && err != ErrUnsupported {
+		hook.reportError(nil, event, err)
+	}
+}
@@ -0,0 +1,45 @@
+package sentryhook
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAttachments(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		server := NewMockServer()
+		hook, err := NewSentryHook(server.DSN(), WithAsync(async), WithExitHandler(false),
+			WithAttachmentExtractor(func(entry *logrus.Entry) []Attachment {
+				return []Attachment{{Filename: "goroutines.txt", ContentType: "text/plain", Payload: []byte("goroutine 1")}}
+			}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		log.WithField(AttachmentsField, Attachment{Filename: "config.json", Payload: []byte(`{"workers":4}`)}).Error("invalid configuration")
+		hook.Flush()
+
+		events := server.Events()
+		if len(events) != 1 {
+			t.Fatalf("async %v: expected 1 event, got %d", async, len(events))
+		}
+		if _, ok := events[0].Extra[AttachmentsField]; ok {
+			t.Fatalf("async %v: expected the attachments field to be left out of extra", async)
+		}
+		items := server.Items()
+		if len(items) != 2 {
+			t.Fatalf("async %v: expected 2 attachments, got %+v", async, items)
+		}
+		config, dump := items[0], items[1]
+		if config.Type != "attachment" || config.Header["filename"] != "config.json" ||
+			config.Header["content_type"] != "application/octet-stream" || string(config.Payload) != `{"workers":4}` {
+			t.Fatalf("async %v: unexpected attachment %+v", async, config)
+		}
+		if dump.Header["filename"] != "goroutines.txt" || dump.Header["content_type"] != "text/plain" {
+			t.Fatalf("async %v: unexpected attachment %+v", async, dump)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,187 @@
+package sentryhook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultMaxBreadcrumbs is the number of breadcrumbs kept per trail.
+const defaultMaxBreadcrumbs = 30
+
+// breadcrumbSequenceKey is the data key of the sequence number of a
+// breadcrumb within its trail.
+const breadcrumbSequenceKey = "sequence"
+
+// breadcrumbTrail is a bounded list of breadcrumbs, oldest first.
+type breadcrumbTrail struct {
+	mu     sync.Mutex
+	crumbs []*sentrygo.Breadcrumb
+	// the maximum of the hook recording into the trail
+	max int
+	// the sequence number of the last crumb added
+	seq int64
+}
+
+// add appends a copy of the crumb, with its timestamp in UTC and the next
+// sequence number of the trail, keeping the last max crumbs. A max of zero
+// uses the maximum of the last hook which recorded into the trail.
+func (t *breadcrumbTrail) add(crumb *sentrygo.Breadcrumb, max int) {
+	c := *crumb
+	c.Timestamp = c.Timestamp.UTC()
+	c.Data = make(map[string]interface{}, len(crumb.Data)+1)
+	for k, v := range crumb.Data {
+		c.Data[k] = v
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if max > 0 {
+		t.max = max
+	} else if max = t.max; max <= 0 {
+		max = defaultMaxBreadcrumbs
+	}
+	t.seq++
+	c.Data[breadcrumbSequenceKey] = t.seq
+	t.crumbs = append(t.crumbs, &c)
+	if over := len(t.crumbs) - max; over > 0 {
+		t.crumbs = append([]*sentrygo.Breadcrumb(nil), t.crumbs[over:]...)
+	}
+}
+
+func (t *breadcrumbTrail) snapshot() []*sentrygo.Breadcrumb {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentrygo.Breadcrumb(nil), t.crumbs...)
+}
+
+type breadcrumbKey struct{}
+
+// BreadcrumbContext returns a context with its own breadcrumb trail, e.g.
+// for a request. Entries logged with the context, or a context derived from
+// it, via logrus' WithContext record their breadcrumbs in this trail, and
+// only events logged with it receive them, so concurrent requests don't mix
+// up each other's breadcrumbs.
+func BreadcrumbContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, breadcrumbKey{}, &breadcrumbTrail{})
+}
+
+// AddBreadcrumb records a breadcrumb for code paths which don't log with
+// logrus, like HTTP clients or database layers, in the trail of a
+// BreadcrumbContext, so it is attached to the events of entries logged with
+// the context. Without a trail in the context it is added to the scope of
+// the context's hub, or of the current hub. A zero timestamp is set to the
+// current time.
+func AddBreadcrumb(ctx context.Context, crumb *sentrygo.Breadcrumb) {
+	if crumb.Timestamp.IsZero() {
+		crumb.Timestamp = time.Now()
+	}
+	if ctx != nil {
+		if t, ok := ctx.Value(breadcrumbKey{}).(*breadcrumbTrail); ok {
+			t.add(crumb, 0)
+			return
+		}
+	}
+	hub := sentrygo.CurrentHub()
+	if ctx != nil && sentrygo.HasHubOnContext(ctx) {
+		hub = sentrygo.GetHubFromContext(ctx)
+	}
+	hub.AddBreadcrumb(crumb, nil)
+}
+
+// WithBreadcrumbs records entries of the level and all more severe levels
+// which are not sent as events as breadcrumbs, keeping the last max of
+// them, and attaches them to the next events. Entries logged with a
+// BreadcrumbContext use the trail of their context, all other entries share
+// one trail. A max of zero keeps 30 breadcrumbs.
+func WithBreadcrumbs(level logrus.Level, max int) Option {
+	return func(hook *SentryHook) {
+		if max <= 0 {
+			max = defaultMaxBreadcrumbs
+		}
+		hook.breadcrumbs = &breadcrumbTrail{}
+		hook.breadcrumbLevel = level
+		hook.maxBreadcrumbs = max
+	}
+}
+
+// trail returns the breadcrumb trail for entries logged with ctx.
+func (hook *SentryHook) trail(ctx context.Context) *breadcrumbTrail {
+	if ctx != nil {
+		if t, ok := ctx.Value(breadcrumbKey{}).(*breadcrumbTrail); ok {
+			return t
+		}
+	}
+	return hook.breadcrumbs
+}
+
+// recordBreadcrumb adds the entry to its breadcrumb trail if breadcrumbs are
+// enabled for its level.
+func (hook *SentryHook) recordBreadcrumb(entry *logrus.Entry) {
+	if hook.breadcrumbs == nil || entry.Level > hook.breadcrumbLevel {
+		return
+	}
+	crumb := &sentrygo.Breadcrumb{
+		Type:      "default",
+		Category:  "log",
+		Message:   entry.Message,
+		Level:     hook.severity(entry.Level),
+		Timestamp: entry.Time,
+	}
+	if name := hook.sourceName(entry); name != "" {
+		crumb.Category = name
+	}
+	for k, v := range entry.Data {
+		if !hook.extraAllowed(k) {
+			continue
+		}
+		if crumb.Data == nil {
+			crumb.Data = make(map[string]interface{}, len(entry.Data))
+		}
+		crumb.Data[k] = hook.extraLimits.sanitize(v)
+	}
+	hook.trail(entry.Context).add(crumb, hook.maxBreadcrumbs)
+}
+
+// attachBreadcrumbs adds the breadcrumbs of the entry's trail to the event.
+func (hook *SentryHook) attachBreadcrumbs(event *sentrygo.Event, entry *logrus.Entry) {
+	if hook.breadcrumbs == nil {
+		return
+	}
+	event.Breadcrumbs = append(event.Breadcrumbs, hook.trail(entry.Context).snapshot()...)
+}
+
+// orderBreadcrumbs puts the breadcrumbs of the event in UTC and in the
+// order they happened: by timestamp, then by their sequence number within
+// the trail, which breaks ties, as concurrent producers may add them out of
+// order.
+func orderBreadcrumbs(event *sentrygo.Event) {
+	crumbs := event.Breadcrumbs
+	if len(crumbs) == 0 {
+		return
+	}
+	for i, crumb := range crumbs {
+		if crumb.Timestamp.Location() != time.UTC {
+			c := *crumb
+			c.Timestamp = c.Timestamp.UTC()
+			crumbs[i] = &c
+		}
+	}
+	sort.SliceStable(crumbs, func(i, j int) bool {
+		a, b := crumbs[i], crumbs[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return breadcrumbSequence(a) < breadcrumbSequence(b)
+	})
+}
+
+// breadcrumbSequence returns the sequence number of a crumb recorded in a
+// trail, 0 for other crumbs.
+func breadcrumbSequence(crumb *sentrygo.Breadcrumb) int64 {
+	seq, _ := crumb.Data[breadcrumbSequenceKey].(int64)
+	return seq
+}
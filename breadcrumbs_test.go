@@ -0,0 +1,121 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestBreadcrumbContexts(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithLevel(logrus.ErrorLevel), WithBreadcrumbs(logrus.InfoLevel, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	first := BreadcrumbContext(context.Background())
+	second := BreadcrumbContext(context.Background())
+	log.Info("startup")
+	log.WithContext(first).Info("first: received")
+	log.WithContext(second).Info("second: received")
+	log.WithContext(first).Debug("first: too verbose")
+	log.WithContext(first).Warn("first: retrying")
+	log.WithContext(first).Warn("first: retrying again")
+	log.WithContext(context.WithValue(first, struct{}{}, 1)).Error("first failed")
+	log.Error("background failure")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	var messages []string
+	for _, crumb := range events[0].Breadcrumbs {
+		messages = append(messages, crumb.Message)
+	}
+	if len(messages) != 2 || messages[0] != "first: retrying" || messages[1] != "first: retrying again" {
+		t.Fatalf("expected the last 2 breadcrumbs of the first context, got %v", messages)
+	}
+	if crumbs := events[1].Breadcrumbs; len(crumbs) != 1 || crumbs[0].Message != "startup" {
+		t.Fatalf("expected the shared breadcrumbs, got %+v", crumbs)
+	}
+}
+
+func TestAddBreadcrumb(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithBreadcrumbs(logrus.InfoLevel, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	ctx := BreadcrumbContext(context.Background())
+	AddBreadcrumb(ctx, &sentrygo.Breadcrumb{Category: "http", Message: "GET /users/7"})
+	AddBreadcrumb(ctx, &sentrygo.Breadcrumb{Category: "sql", Message: "SELECT 1"})
+	log.WithContext(ctx).Error("lookup failed")
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	crumbs := events[0].Breadcrumbs
+	if len(crumbs) != 2 || crumbs[0].Category != "http" || crumbs[1].Message != "SELECT 1" || crumbs[0].Timestamp.IsZero() {
+		t.Fatalf("expected the added breadcrumbs, got %+v", crumbs)
+	}
+
+	hub := sentrygo.NewHub(nil, sentrygo.NewScope())
+	AddBreadcrumb(sentrygo.SetHubOnContext(context.Background(), hub), &sentrygo.Breadcrumb{Message: "no trail"})
+	if probe := hub.Scope().ApplyToEvent(sentrygo.NewEvent(), nil); len(probe.Breadcrumbs) != 1 {
+		t.Fatalf("expected the breadcrumb on the hub scope, got %+v", probe.Breadcrumbs)
+	}
+}
+
+func TestBreadcrumbOrdering(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithBreadcrumbs(logrus.InfoLevel, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	zone := time.FixedZone("CEST", 2*60*60)
+	at := time.Date(2024, 5, 1, 14, 0, 0, 0, zone)
+	ctx := BreadcrumbContext(context.Background())
+	// concurrent producers may add crumbs out of order
+	AddBreadcrumb(ctx, &sentrygo.Breadcrumb{Message: "second", Timestamp: at.Add(time.Second)})
+	AddBreadcrumb(ctx, &sentrygo.Breadcrumb{Message: "first", Timestamp: at})
+	AddBreadcrumb(ctx, &sentrygo.Breadcrumb{Message: "third", Timestamp: at.Add(time.Second)})
+	log.WithContext(ctx).Error("lookup failed")
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	crumbs := events[0].Breadcrumbs
+	var messages []string
+	for _, crumb := range crumbs {
+		messages = append(messages, crumb.Message)
+	}
+	if len(messages) != 3 || messages[0] != "first" || messages[1] != "second" || messages[2] != "third" {
+		t.Fatalf("expected the breadcrumbs in order, got %v", messages)
+	}
+	if seq := crumbs[2].Data[breadcrumbSequenceKey]; seq != 3.0 {
+		t.Fatalf("expected the sequence number of the trail, got %v", seq)
+	}
+
+	event := sentrygo.NewEvent()
+	event.Breadcrumbs = []*sentrygo.Breadcrumb{{Timestamp: at}}
+	orderBreadcrumbs(event)
+	if event.Breadcrumbs[0].Timestamp.Location() != time.UTC {
+		t.Fatalf("expected timestamps in UTC, got %v", event.Breadcrumbs[0].Timestamp)
+	}
+}
@@ -0,0 +1,146 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithEventBudget caps the number of events sent per period, so a storm of
+// errors cannot exhaust the Sentry quota. Events beyond the budget are
+// dropped and counted in Stats.OverBudget; at the end of a period in which
+// events were dropped, a single warning event reports how many. Periods
+// start when the hook is created; per must be positive.
+func WithEventBudget(n int, per time.Duration) Option {
+	return func(hook *SentryHook) {
+		if per <= 0 {
+			hook.optionErr = fmt.Errorf("sentryhook: event budget period %v is not positive", per)
+			return
+		}
+		b := hook.eventBudget()
+		b.events = n
+		b.period = per
+	}
+}
+
+// WithEventBudgetBytes additionally caps the serialized size of the events
+// sent per period of WithEventBudget; without it the cap applies to the
+// lifetime of the hook. Measuring it costs an extra serialization of every
+// event.
+func WithEventBudgetBytes(bytes int) Option {
+	return func(hook *SentryHook) {
+		hook.eventBudget().bytes = bytes
+	}
+}
+
+func (hook *SentryHook) eventBudget() *budget {
+	if hook.budget == nil {
+		hook.budget = &budget{}
+	}
+	return hook.budget
+}
+
+// budget counts the events and bytes sent in the current period.
+type budget struct {
+	events int
+	bytes  int
+	period time.Duration
+
+	mu         sync.Mutex
+	sent       int
+	sentBytes  int
+	suppressed int
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// start launches the goroutine starting a new period every period.
+func (b *budget) start(hook *SentryHook) {
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go func() {
+		defer close(b.done)
+		if b.period <= 0 {
+			<-b.stop
+			return
+		}
+		ticker := time.NewTicker(b.period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.reset(hook)
+			case <-b.stop:
+				b.reset(hook)
+				return
+			}
+		}
+	}()
+}
+
+// close reports events suppressed in the current period and stops the
+// budget.
+func (b *budget) close() {
+	close(b.stop)
+	<-b.done
+}
+
+// admit reports whether the event fits into the budget, counting it if it
+// does.
+func (b *budget) admit(event *sentrygo.Event) bool {
+	size := 0
+	if b.bytes > 0 {
+		data, _ := json.Marshal(event)
+		size = len(data)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if (b.events > 0 && b.sent >= b.events) || (b.bytes > 0 && b.sentBytes+size > b.bytes) {
+		b.suppressed++
+		return false
+	}
+	b.sent++
+	b.sentBytes += size
+	return true
+}
+
+// reset starts a new period, reporting the events suppressed in the last.
+func (b *budget) reset(hook *SentryHook) {
+	b.mu.Lock()
+	suppressed := b.suppressed
+	b.sent, b.sentBytes, b.suppressed = 0, 0, 0
+	b.mu.Unlock()
+	if suppressed == 0 {
+		return
+	}
+	event := sentrygo.NewEvent()
+	event.EventID = hook.deterministicEventID()
+	event.Timestamp = hook.now()
+	event.Level = sentrygo.LevelWarning
+	event.Platform = "Golang"
+	event.Message = fmt.Sprintf("sentry event budget exceeded, %d events suppressed", suppressed)
+	event.Fingerprint = []string{"sentryhook-budget-exceeded"}
+	event.Tags = copyTags(hook.staticTags(), 2)
+	event.Tags["budget_exceeded"] = "true"
+	event.Tags["budget_suppressed"] = strconv.Itoa(suppressed)
+	event.Extra = map[string]interface{}{
+		"budget_events":     b.events,
+		"budget_bytes":      b.bytes,
+		"budget_period":     b.period.String(),
+		"budget_suppressed": suppressed,
+	}
+	_ = hook.dispatch(event, nil)
+}
+
+// overBudget reports whether the event is dropped by the event budget.
+func (hook *SentryHook) overBudget(event *sentrygo.Event) bool {
+	if hook.budget == nil || hook.budget.admit(event) {
+		return false
+	}
+	hook.stats.update(func(stats *Stats) { stats.OverBudget++ })
+	return true
+}
@@ -0,0 +1,62 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestEventBudget(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithEventBudget(2, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	for i := 0; i < 5; i++ {
+		log.Error("disk full")
+	}
+	if n := len(server.Events()); n != 2 {
+		t.Fatalf("expected the budget of 2 events to be sent, got %d", n)
+	}
+	if s := hook.Stats(); s.OverBudget != 3 {
+		t.Fatalf("expected 3 events over budget, got %+v", s)
+	}
+
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected a budget report on close, got %d events", len(events))
+	}
+	report := events[2]
+	if report.Message != "sentry event budget exceeded, 3 events suppressed" || report.Tags["budget_exceeded"] != "true" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestEventBudgetPeriod(t *testing.T) {
+	if _, err := NewSentryHook("", WithEventBudget(10, 0)); err == nil {
+		t.Fatal("expected an error for a zero period")
+	}
+}
+
+func TestEventBudgetBytes(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithEventBudget(0, time.Hour), WithEventBudgetBytes(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("too large for the budget")
+	if n := len(server.Events()); n != 0 {
+		t.Fatalf("expected the event to exceed the byte budget, got %d events", n)
+	}
+}
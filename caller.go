@@ -0,0 +1,120 @@
+package sentryhook
+
+import (
+	"reflect"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// SkipFramesField is a reserved field holding the number of innermost
+// frames to leave out of the stacktrace of the logging call site, for call
+// sites wrapped by a helper which should not appear as the culprit:
+//
+//	func reportFailure(err error) {
+//		log.WithField(sentryhook.SkipFramesField, 1).WithError(err).Error("request failed")
+//	}
+//
+// Frames are counted from the logging call site if logrus reports callers,
+// see logrus' SetReportCaller. It also applies to errors without a stack of
+// their own, whose stacktrace then shows the call site and its callers.
+const SkipFramesField = "sentry_skip_frames"
+
+// TraceField is a reserved field which, when set to false, leaves the
+// stacktrace of the logging call site out of the event. Stacktraces carried
+// by errors are still sent.
+const TraceField = "sentry_trace"
+
+// stackSourceTag is the tag marking events whose exception stacktrace was
+// captured at the logging call site, see WithErrorStackFallbackCapture.
+const stackSourceTag = "stack_source"
+
+// WithErrorStackFallbackCapture captures the full stacktrace of the logging
+// call site for errors carrying no stacktrace at all, like those created
+// with errors.New, and attaches it to the innermost exception. Such events
+// are tagged "stack_source=log_site", as the stacktrace shows where the
+// error was logged rather than where it was created. Without it these
+// errors only show the logging call site if logrus reports callers.
+func WithErrorStackFallbackCapture(enable bool) Option {
+	return func(hook *SentryHook) {
+		hook.errorStackFallback = enable
+	}
+}
+
+// hasStacktrace reports whether any of the exceptions carries a stacktrace.
+func hasStacktrace(exceptions []sentrygo.Exception) bool {
+	for _, exception := range exceptions {
+		if exception.Stacktrace != nil && len(exception.Stacktrace.Frames) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// frameOverrides returns the stacktrace settings of the entry: those of
+// StacktraceConfiguration if enabled, overridden by the entry's reserved
+// fields.
+func (hook *SentryHook) frameOverrides(entry *logrus.Entry, hasCaller bool) (skip int, trace bool) {
+	trace = true
+	if config := hook.StacktraceConfiguration; config.Enable {
+		trace = entry.Level <= config.Level
+		if !hasCaller {
+			// the stack is recorded within the hook
+			skip = config.Skip
+		}
+	}
+	if t, ok := entry.Data[TraceField].(bool); ok {
+		trace = t
+	}
+	switch v := reflect.ValueOf(entry.Data[SkipFramesField]); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		skip = int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		skip = int(v.Uint())
+	}
+	return skip, trace
+}
+
+// logSiteStacktrace returns the stacktrace of the logging call site,
+// without the skip innermost frames, or nil if no frames are left.
+func logSiteStacktrace(caller sentrygo.Frame, hasCaller bool, skip int) *sentrygo.Stacktrace {
+	st := sentrygo.NewStacktrace()
+	if hasCaller {
+		st = withCallerFrame(st, caller)
+	}
+	if st == nil || skip <= 0 {
+		return st
+	}
+	if skip >= len(st.Frames) {
+		return nil
+	}
+	st.Frames = st.Frames[:len(st.Frames)-skip]
+	return st
+}
+
+// callerFrame converts the caller logrus recorded with ReportCaller into a
+// sentry frame.
+func callerFrame(entry *logrus.Entry) (sentrygo.Frame, bool) {
+	if entry.Caller == nil {
+		return sentrygo.Frame{}, false
+	}
+	return sentrygo.NewFrame(*entry.Caller), true
+}
+
+// withCallerFrame makes the stacktrace end at the logging call site. Frames
+// recorded after the caller, the internals of logrus and this hook, are cut
+// off; if the caller is not part of the stacktrace it is appended.
+func withCallerFrame(st *sentrygo.Stacktrace, caller sentrygo.Frame) *sentrygo.Stacktrace {
+	if st == nil {
+		return &sentrygo.Stacktrace{Frames: []sentrygo.Frame{caller}}
+	}
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		frame := st.Frames[i]
+		if frame.Function == caller.Function && frame.Module == caller.Module && frame.Lineno == caller.Lineno {
+			st.Frames = st.Frames[:i+1]
+			return st
+		}
+	}
+	st.Frames = append(st.Frames, caller)
+	return st
+}
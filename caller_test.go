@@ -0,0 +1,170 @@
+package sentryhook
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCallerFrame(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.SetReportCaller(true)
+	log.Hooks.Add(hook)
+
+	log.Error("with caller")
+
+	events := server.Events()
+	if len(events) != 1 || len(events[0].Exception) != 1 {
+		t.Fatalf("expected one event with one exception, got %+v", events)
+	}
+	exception := events[0].Exception[0]
+	if exception.Value != "" {
+		t.Fatalf("expected no file name in the exception value, got %q", exception.Value)
+	}
+	frames := exception.Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if last.Function != "TestCallerFrame" {
+		t.Fatalf("expected the stacktrace to end at the caller, got %+v", last)
+	}
+}
+
+// reportFailure is a centralized error helper, which should not show up as
+// the culprit.
+func reportFailure(log *logrus.Logger, err error) {
+	log.WithField(SkipFramesField, 1).WithError(err).Error("request failed")
+}
+
+func TestFrameOverrides(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.SetReportCaller(true)
+	log.Hooks.Add(hook)
+
+	reportFailure(log, errors.New("connection reset"))
+	log.WithField(TraceField, false).Error("without trace")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	frames := events[0].Exception[0].Stacktrace.Frames
+	if last := frames[len(frames)-1]; last.Function != "TestFrameOverrides" {
+		t.Fatalf("expected the stacktrace to end at the helper's caller, got %+v", last)
+	}
+	if _, ok := events[0].Extra[SkipFramesField]; ok {
+		t.Fatal("expected the reserved field to be left out of extra")
+	}
+	if len(events[1].Exception) != 0 {
+		t.Fatalf("expected no stacktrace, got %+v", events[1].Exception)
+	}
+}
+
+// logFailure logs the error from a frame below the test, so the captured
+// stacktrace has more than one frame.
+func logFailure(log *logrus.Logger, err error) {
+	log.WithError(err).Error("request failed")
+}
+
+func TestErrorStackFallbackCapture(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithErrorStackFallbackCapture(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.SetReportCaller(true)
+	log.Hooks.Add(hook)
+
+	logFailure(log, errors.New("connection reset"))
+
+	events := server.Events()
+	if len(events) != 1 || len(events[0].Exception) != 1 {
+		t.Fatalf("expected one event with one exception, got %+v", events)
+	}
+	if events[0].Tags[stackSourceTag] != "log_site" {
+		t.Fatalf("expected stack source log_site, got %q", events[0].Tags[stackSourceTag])
+	}
+	frames := events[0].Exception[0].Stacktrace.Frames
+	if len(frames) < 2 || frames[len(frames)-2].Function != "TestErrorStackFallbackCapture" {
+		t.Fatalf("expected the callers of the logging call site, got %+v", frames)
+	}
+	if last := frames[len(frames)-1]; last.Function != "logFailure" {
+		t.Fatalf("expected the stacktrace to end at the caller, got %+v", last)
+	}
+}
+
+func TestStackTraceConfiguration(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	config := DefaultStackTraceConfiguration()
+	config.Enable = true
+	config.Level = logrus.ErrorLevel
+	config.Skip = 2
+	config.Context = 1
+	config.InAppPrefixes = []string{"github.com/ainiaa/sentryhook"}
+	hook, err := NewSentryHook(server.DSN(), func(hook *SentryHook) {
+		hook.StacktraceConfiguration = config
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Warn("below the level")
+	log.Error("at the level")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if len(events[0].Exception) != 0 {
+		t.Fatalf("expected no stacktrace below the level, got %+v", events[0].Exception)
+	}
+	frames := events[1].Exception[0].Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if last.Function != "(*SentryHook).fire" {
+		t.Fatalf("expected the innermost frames skipped, got %+v", last)
+	}
+	if len(last.PreContext) != 1 || last.ContextLine == "" || len(last.PostContext) != 1 {
+		t.Fatalf("expected one line of context, got %q %q %q", last.PreContext, last.ContextLine, last.PostContext)
+	}
+}
+
+func TestContextLinesCacheBound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sentryhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := &contextLines{lines: 1, files: make(map[string][][]byte)}
+	for i := 0; i <= maxContextFiles; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%d.go", i))
+		if err := ioutil.WriteFile(path, []byte("package main\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if lines := c.source(path); len(lines) != 2 {
+			t.Fatalf("unexpected lines %q", lines)
+		}
+	}
+	if len(c.files) > maxContextFiles {
+		t.Fatalf("expected at most %d cached files, got %d", maxContextFiles, len(c.files))
+	}
+}
@@ -0,0 +1,159 @@
+package sentryhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Capability is an optional server feature the hook can make use of.
+type Capability string
+
+const (
+	CapabilityAttachments Capability = "attachments"
+	CapabilitySessions    Capability = "sessions"
+	CapabilityCheckIns    Capability = "check_ins"
+)
+
+// ErrUnsupported is returned when the server does not support what is sent,
+// e.g. check-ins to a self-hosted Sentry predating cron monitors.
+var ErrUnsupported = errors.New("sentryhook: not supported by the server")
+
+// capabilityMinVersions are the first self-hosted Sentry or Relay releases
+// supporting a capability.
+var capabilityMinVersions = map[Capability][3]int{
+	CapabilityAttachments: {20, 6, 0},
+	CapabilitySessions:    {20, 6, 0},
+	CapabilityCheckIns:    {23, 3, 0},
+}
+
+// WithCapability overrides the detected support of a capability, e.g. to
+// force-enable a feature on a server which does not advertise its version.
+func WithCapability(capability Capability, enabled bool) Option {
+	return func(hook *SentryHook) {
+		hook.capabilities.mu.Lock()
+		defer hook.capabilities.mu.Unlock()
+		if hook.capabilities.overrides == nil {
+			hook.capabilities.overrides = make(map[Capability]bool)
+		}
+		hook.capabilities.overrides[capability] = enabled
+	}
+}
+
+// capabilities tracks what the server behind the DSN supports. It is
+// filled in from the responses to the first requests.
+type capabilities struct {
+	mu          sync.Mutex
+	detected    bool
+	version     string
+	noEnvelopes bool
+	overrides   map[Capability]bool
+}
+
+// supportsEnvelopes reports whether the server has an envelope endpoint,
+// which is assumed until a request to it is answered with 404.
+func (hook *SentryHook) supportsEnvelopes() bool {
+	hook.capabilities.mu.Lock()
+	defer hook.capabilities.mu.Unlock()
+	return !hook.capabilities.noEnvelopes
+}
+
+// ServerVersion returns the Sentry or Relay version detected from the
+// responses of the server, or an empty string if it is not known (yet).
+func (hook *SentryHook) ServerVersion() string {
+	hook.capabilities.mu.Lock()
+	defer hook.capabilities.mu.Unlock()
+	return hook.capabilities.version
+}
+
+// Supports reports whether the capability can be used with the server.
+// Overrides win; servers which did not report a version are assumed to be
+// up to date. Attachments and session updates are left out and check-ins
+// fail with ErrUnsupported while their capability is not supported.
+func (hook *SentryHook) Supports(capability Capability) bool {
+	c := &hook.capabilities
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if enabled, ok := c.overrides[capability]; ok {
+		return enabled
+	}
+	if c.noEnvelopes {
+		return false
+	}
+	version, ok := parseVersion(c.version)
+	if !ok {
+		return true
+	}
+	return !versionLess(version, capabilityMinVersions[capability])
+}
+
+// observeResponse detects the server version from the Server header, which
+// Sentry and Relay answer in the form "<product>/<version>", and notes
+// servers predating the envelope endpoint.
+func (hook *SentryHook) observeResponse(req *http.Request, resp *http.Response) {
+	c := &hook.capabilities
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if strings.HasSuffix(req.URL.Path, "/envelope/") && resp.StatusCode == http.StatusNotFound {
+		c.noEnvelopes = true
+	}
+	if c.detected {
+		return
+	}
+	c.detected = true
+	for _, product := range strings.Fields(resp.Header.Get("Server")) {
+		parts := strings.SplitN(product, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.ToLower(parts[0])
+		if name == "sentry" || name == "relay" {
+			c.version = parts[1]
+			return
+		}
+	}
+}
+
+// capabilityTransport observes the responses of the wrapped transport.
+type capabilityTransport struct {
+	base http.RoundTripper
+	hook *SentryHook
+}
+
+func (t *capabilityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.hook.observeResponse(req, resp)
+	}
+	return resp, err
+}
+
+func parseVersion(s string) ([3]int, bool) {
+	var version [3]int
+	if s == "" {
+		return version, false
+	}
+	// drop pre-release and build suffixes like "-dev0" or "+abc"
+	if i := strings.IndexAny(s, "-+ "); i >= 0 {
+		s = s[:i]
+	}
+	for i, part := range strings.SplitN(s, ".", 3) {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+func versionLess(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package sentryhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCapabilities(t *testing.T) {
+	hook, err := NewSentryHook("", WithCapability(CapabilitySessions, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hook.Supports(CapabilityCheckIns) {
+		t.Fatal("expected capabilities to be assumed before detection")
+	}
+
+	req := &http.Request{URL: &url.URL{Path: "/api/1/store/"}}
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Server": {"nginx Sentry/21.4.1"}}}
+	hook.observeResponse(req, resp)
+
+	if hook.ServerVersion() != "21.4.1" {
+		t.Fatalf("unexpected server version %q", hook.ServerVersion())
+	}
+	if !hook.Supports(CapabilityAttachments) {
+		t.Error("expected attachments to be supported by 21.4.1")
+	}
+	if hook.Supports(CapabilityCheckIns) {
+		t.Error("expected check-ins to be unsupported by 21.4.1")
+	}
+	if !hook.Supports(CapabilitySessions) {
+		t.Error("expected the override to win")
+	}
+}
+
+func TestUnsupportedCapabilitiesSkipped(t *testing.T) {
+	server := &MockServer{}
+	server.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Sentry/20.1.0")
+		server.handle(w, r)
+	}))
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithSessionTracking(), WithExitHandler(false),
+		WithAttachmentExtractor(func(entry *logrus.Entry) []Attachment {
+			return []Attachment{{Filename: "request.txt", Payload: []byte("GET /")}}
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("charge failed")
+	if _, err := hook.CheckIn("nightly", CheckInOK); err != ErrUnsupported {
+		t.Fatalf("expected check-ins to be unsupported, got %v", err)
+	}
+	if err := hook.RunMonitored("nightly", func() error { return nil }); err != nil {
+		t.Fatalf("expected the job to run without check-ins, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := hook.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if hook.ServerVersion() != "20.1.0" || len(server.Events()) != 1 {
+		t.Fatalf("expected the event sent to Sentry 20.1.0, got %d events from %q", len(server.Events()), hook.ServerVersion())
+	}
+	for _, item := range server.Items() {
+		switch item.Type {
+		case "attachment", "check_in":
+			t.Fatalf("expected no %s sent to an old server", item.Type)
+		}
+	}
+	// only the session start precedes the detection of the version
+	if updates := sessionUpdates(t, server); len(updates) != 1 || !updates[0].Init {
+		t.Fatalf("expected only the session start, got %+v", updates)
+	}
+	if len(hook.Errors()) != 0 {
+		t.Fatalf("expected the skipped items not to be reported, got %+v", <-hook.Errors())
+	}
+}
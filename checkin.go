@@ -0,0 +1,132 @@
+package sentryhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CheckInStatus is the status of a cron monitor check-in.
+type CheckInStatus string
+
+// The statuses of check-ins.
+const (
+	CheckInInProgress CheckInStatus = "in_progress"
+	CheckInOK         CheckInStatus = "ok"
+	CheckInError      CheckInStatus = "error"
+)
+
+// checkIn is the payload of a check-in envelope item.
+type checkIn struct {
+	ID          string        `json:"check_in_id"`
+	MonitorSlug string        `json:"monitor_slug"`
+	Status      CheckInStatus `json:"status"`
+	Duration    float64       `json:"duration,omitempty"`
+	Release     string        `json:"release,omitempty"`
+	Environment string        `json:"environment,omitempty"`
+}
+
+// A CheckInOption configures a check-in.
+type CheckInOption func(*checkIn)
+
+// WithCheckInID continues the check-in with the id returned by an earlier
+// in-progress check-in, instead of starting a new one.
+func WithCheckInID(id string) CheckInOption {
+	return func(c *checkIn) {
+		c.ID = id
+	}
+}
+
+// WithCheckInDuration reports how long the job ran.
+func WithCheckInDuration(d time.Duration) CheckInOption {
+	return func(c *checkIn) {
+		c.Duration = d.Seconds()
+	}
+}
+
+// CheckIn reports the status of a run of the Sentry cron monitor with the
+// slug and returns the id of the check-in, to pass to WithCheckInID when
+// the run completes. It is sent through the hook's client, waiting at most
+// the flush timeout. Servers predating cron monitors fail it with
+// ErrUnsupported.
+func (hook *SentryHook) CheckIn(monitorSlug string, status CheckInStatus, opts ...CheckInOption) (string, error) {
+	if monitorSlug == "" {
+		return "", errors.New("sentryhook: check-in needs a monitor slug")
+	}
+	if !hook.Supports(CapabilityCheckIns) {
+		return "", ErrUnsupported
+	}
+	c := checkIn{
+		MonitorSlug: monitorSlug,
+		Status:      status,
+		Release:     hook.release,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.ID == "" {
+		c.ID = hook.newID()
+	}
+	if client := hook.currentClient(); client != nil {
+		c.Environment = client.Options().Environment
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := hook.flushContext()
+	defer cancel()
+	return c.ID, hook.sendEnvelope(ctx, nil, envelopeItem{Type: "check_in", Payload: payload})
+}
+
+// RunMonitored runs the job as a run of the cron monitor with the slug,
+// checking in before and after it, with the error status if the job fails
+// or panics. It returns the error of the job; failing check-ins don't keep
+// the job from running and are only returned if the job succeeds. Servers
+// without cron monitors only run the job.
+func (hook *SentryHook) RunMonitored(monitorSlug string, job func() error) (err error) {
+	id, checkInErr := hook.CheckIn(monitorSlug, CheckInInProgress)
+	start := time.Now()
+	defer func() {
+		status := CheckInOK
+		recovered := recover()
+		if recovered != nil || err != nil {
+			status = CheckInError
+		}
+		_, finishErr := hook.CheckIn(monitorSlug, status, WithCheckInID(id), WithCheckInDuration(time.Since(start)))
+		if recovered != nil {
+			panic(recovered)
+		}
+		if err == nil && checkInErr == nil {
+			checkInErr = finishErr
+		}
+		if err == nil && checkInErr != nil && checkInErr != ErrUnsupported {
+			err = fmt.Errorf("sentryhook: check-in failed: %v", checkInErr)
+		}
+	}()
+	return job()
+}
+
+// newID returns a random id for check-ins and sessions, drawn from the
+// hook's source in deterministic mode.
+func (hook *SentryHook) newID() string {
+	if id := hook.deterministicEventID(); id != "" {
+		return string(id)
+	}
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// flushContext returns a context bounded by the flush timeout, for requests
+// the hook sends itself.
+func (hook *SentryHook) flushContext() (context.Context, context.CancelFunc) {
+	if hook.flushTimeout > 0 {
+		return context.WithTimeout(context.Background(), hook.flushTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
@@ -0,0 +1,75 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestRunMonitored(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hook.RunMonitored("nightly-report", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	jobErr := errors.New("export failed")
+	if err := hook.RunMonitored("nightly-report", func() error { return jobErr }); err != jobErr {
+		t.Fatalf("expected the job error, got %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the panic to continue")
+			}
+		}()
+		_ = hook.RunMonitored("nightly-report", func() error { panic("boom") })
+	}()
+
+	items := server.Items()
+	if len(items) != 6 {
+		t.Fatalf("expected 6 check-ins, got %d", len(items))
+	}
+	var checkIns []checkIn
+	for _, item := range items {
+		if item.Type != "check_in" {
+			t.Fatalf("unexpected item type %q", item.Type)
+		}
+		var c checkIn
+		if err := json.Unmarshal(item.Payload, &c); err != nil {
+			t.Fatal(err)
+		}
+		checkIns = append(checkIns, c)
+	}
+	want := []CheckInStatus{CheckInInProgress, CheckInOK, CheckInInProgress, CheckInError, CheckInInProgress, CheckInError}
+	for i, c := range checkIns {
+		if c.Status != want[i] || c.MonitorSlug != "nightly-report" {
+			t.Fatalf("check-in %d: expected %s, got %+v", i, want[i], c)
+		}
+		if i%2 == 1 && (c.ID != checkIns[i-1].ID || c.Duration <= 0) {
+			t.Fatalf("check-in %d: expected the id of the run and a duration, got %+v", i, c)
+		}
+	}
+	if checkIns[0].ID == checkIns[2].ID || len(checkIns[0].ID) != 32 {
+		t.Fatalf("expected a new id per run, got %q and %q", checkIns[0].ID, checkIns[2].ID)
+	}
+}
+
+func TestCheckInWithoutDSN(t *testing.T) {
+	hook, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hook.CheckIn("nightly-report", CheckInOK); err != ErrNoDSN {
+		t.Fatalf("expected ErrNoDSN, got %v", err)
+	}
+	ran := false
+	err = hook.RunMonitored("nightly-report", func() error { ran = true; return nil })
+	if !ran || err == nil {
+		t.Fatalf("expected the job to run and the check-in failure, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package sentryhook
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithScope returns a child hook for one part of a program, e.g. the API,
+// the workers or the cron jobs, adding the tags to its events and firing
+// for the levels, or the hook's current levels if levels is nil. The child
+// shares the hook's client, queue, configuration, stats and Errors channel;
+// its tags are dynamic tags, see TagSourceDynamic, and its levels are
+// changed with SetLevel and SetLevels on the child. Children of a child
+// add their tags to those of the child. Only logging goes through the
+// child: Flush, Stats, Levels and Errors are those of the hook, Close of a
+// child does nothing, and the other methods are to be called on the hook.
+// The child replaces the hook on the loggers it is added to; a logger with
+// both reports its entries twice.
+func (hook *SentryHook) WithScope(tags map[string]string, levels []logrus.Level) *SentryHook {
+	root := hook
+	scoped := copyTags(tags, 0)
+	if hook.parent != nil {
+		root = hook.parent
+		scoped = copyTags(hook.staticTags(), len(tags))
+		for k, v := range tags {
+			scoped[k] = v
+		}
+	}
+	if levels == nil {
+		hook.configMu.RLock()
+		levels = hook.levels
+		hook.configMu.RUnlock()
+	}
+	return &SentryHook{
+		parent: root,
+		tags:   scoped,
+		levels: append([]logrus.Level(nil), levels...),
+		errors: root.errors,
+		now:    root.now,
+	}
+}
+
+// fireScoped passes the entry of a child hook on to its parent, with the
+// child's tags and level filter.
+func (hook *SentryHook) fireScoped(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// tags of the entry's context are more specific than those of the child
+	tags := copyTags(hook.staticTags(), 0)
+	for k, v := range contextTags(ctx) {
+		tags[k] = v
+	}
+	scoped := *entry
+	scoped.Context = ContextWithTags(ctx, tags)
+	return hook.parent.fire(&scoped, hook.enabled(entry.Level))
+}
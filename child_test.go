@@ -0,0 +1,56 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithScope(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithTags(map[string]string{"service": "shop", "module": "main"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := hook.WithScope(map[string]string{"module": "api"}, nil)
+	cron := hook.WithScope(map[string]string{"module": "cron"}, []logrus.Level{logrus.WarnLevel})
+	nightly := cron.WithScope(map[string]string{"job": "nightly"}, nil)
+
+	apiLog, cronLog, nightlyLog := logrus.New(), logrus.New(), logrus.New()
+	apiLog.AddHook(api)
+	cronLog.AddHook(cron)
+	nightlyLog.AddHook(nightly)
+
+	apiLog.Info("not reported by api")
+	apiLog.WithContext(ContextWithTags(context.Background(), map[string]string{"module": "api.auth"})).Error("from api")
+	cronLog.Error("not reported by cron")
+	cronLog.Warn("from cron")
+	nightlyLog.Warn("from nightly")
+
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for i, want := range []map[string]string{
+		{"service": "shop", "module": "api.auth"},
+		{"service": "shop", "module": "cron"},
+		{"service": "shop", "module": "cron", "job": "nightly"},
+	} {
+		for k, v := range want {
+			if events[i].Tags[k] != v {
+				t.Fatalf("event %d: expected tag %s=%s, got %v", i, k, v, events[i].Tags)
+			}
+		}
+	}
+	if sent := nightly.Stats().Sent; sent != 3 {
+		t.Fatalf("expected the children to share the hook's stats, got %d sent", sent)
+	}
+	if _, err := api.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatalf("expected closing a child to leave the hook open, got %v", err)
+	}
+}
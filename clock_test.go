@@ -0,0 +1,100 @@
+package sentryhook
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeClock is a time source for tests. Times derived from time.Now keep
+// its monotonic reading; set replaces it with a wall-clock-only time, like
+// a custom time source whose clock is stepped.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Now()}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+func TestWindowsIgnoreEntryTimeSteps(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	clock := newFakeClock()
+	hook, err := NewSentryHook(server.DSN(), WithTimeSource(clock.Now),
+		WithFirstThenSample(1, time.Minute, 0),
+		WithEscalation(EscalationRule{Name: "storm", Threshold: 3, Window: time.Minute}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	// the wall clock of the entries is stepped back and forth by hours,
+	// e.g. by NTP, while the monotonic clock moves on by seconds
+	wall := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, step := range []time.Duration{0, -3 * time.Hour, 5 * time.Hour} {
+		log.WithTime(wall.Add(step)).Error("connection refused")
+		clock.Advance(time.Second)
+	}
+	if n := len(server.Events()); n != 2 {
+		t.Fatalf("expected the first event and the escalation within one window, got %d events", n)
+	}
+	if n := escalated(server.Events()); n != 1 {
+		t.Fatalf("expected the steps to stay within the escalation window, got %d escalations", n)
+	}
+	clock.Advance(time.Minute)
+	log.WithTime(wall.Add(-time.Hour)).Error("connection refused")
+	if n := len(server.Events()); n != 3 {
+		t.Fatalf("expected a new sampling window, got %d events", n)
+	}
+}
+
+func TestWindowsRecoverFromClockStepBack(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	clock := newFakeClock()
+	wall := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock.Set(wall)
+	hook, err := NewSentryHook(server.DSN(), WithTimeSource(clock.Now),
+		WithFirstThenSample(1, time.Hour, 0),
+		WithEscalation(EscalationRule{Name: "storm", Threshold: 2, Window: time.Hour}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("disk full")
+	// a time source without monotonic readings is stepped back by a day;
+	// the windows start over instead of waiting for the clock to catch up
+	clock.Set(wall.Add(-24 * time.Hour))
+	log.Error("disk full")
+	if n := len(server.Events()); n != 2 {
+		t.Fatalf("expected a new sampling window after the step, got %d events", n)
+	}
+	if n := escalated(server.Events()); n != 0 {
+		t.Fatalf("expected the escalation window to start over, got %d escalations", n)
+	}
+}
@@ -0,0 +1,390 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Config is a declarative configuration of a SentryHook.
+type Config struct {
+	// the sentry DSN; an empty DSN disables sending
+	DSN string
+	// the environment and release events are reported for
+	Environment string
+	Release     string
+	// the levels the hook fires for
+	Levels []logrus.Level
+	// the timeout for sending a single event
+	Timeout time.Duration
+	// how long to wait for the client to flush its buffer
+	FlushTimeout time.Duration
+	// static tags added to every event
+	Tags map[string]string
+	// whether events are sent asynchronously
+	Async bool
+	// stacktrace settings
+	StackTrace StackTraceConfiguration
+	// rules adjusting the handling of events, see WithRules
+	Rules []Rule
+	// the share of events sent, between 0.0 and 1.0; zero sends all
+	SampleRate float64
+	// functions modifying events before they are sent, e.g. to remove
+	// personal data; a scrubber returning nil drops the event
+	Scrubbers []func(event *sentrygo.Event) *sentrygo.Event
+}
+
+// fileConfig is the on-disk representation of Config. Levels and durations
+// are kept as strings so they can be validated with helpful errors.
+type fileConfig struct {
+	DSN          string            `json:"dsn" yaml:"dsn"`
+	Environment  string            `json:"environment" yaml:"environment"`
+	Release      string            `json:"release" yaml:"release"`
+	Levels       []string          `json:"levels" yaml:"levels"`
+	Timeout      string            `json:"timeout" yaml:"timeout"`
+	FlushTimeout string            `json:"flush_timeout" yaml:"flush_timeout"`
+	Tags         map[string]string `json:"tags" yaml:"tags"`
+	Async        bool              `json:"async" yaml:"async"`
+	SampleRate   float64           `json:"sample_rate" yaml:"sample_rate"`
+	StackTrace   struct {
+		Enable        bool            `json:"enable" yaml:"enable"`
+		Level         string          `json:"level" yaml:"level"`
+		Skip          *int            `json:"skip" yaml:"skip"`
+		Context       int             `json:"context" yaml:"context"`
+		InAppPrefixes []string        `json:"in_app_prefixes" yaml:"in_app_prefixes"`
+		InAppRules    map[string]bool `json:"in_app_rules" yaml:"in_app_rules"`
+	} `json:"stacktrace" yaml:"stacktrace"`
+	Rules []fileRule `json:"rules" yaml:"rules"`
+}
+
+// DefaultConfig returns the configuration used by NewSentryHook when no
+// options are given.
+func DefaultConfig() Config {
+	return Config{
+		Levels:       DefaultLevels(),
+		Timeout:      100 * time.Millisecond,
+		FlushTimeout: 3 * time.Second,
+		StackTrace:   DefaultStackTraceConfiguration(),
+	}
+}
+
+// LoadConfig reads a YAML or JSON configuration file (chosen by the .json
+// extension), expands ${ENV_VAR} references in its string values, applies
+// defaults for missing values and validates the result. YAML support can be
+// left out with the sentryhook_noyaml build tag.
+//
+// Environment references may carry a fallback: ${SENTRY_ENV:-production}.
+// They are expanded after parsing, so values of environment variables are
+// taken literally; $${ is a literal ${ and other $ signs are kept as they
+// are.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var fc fileConfig
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &fc)
+	} else {
+		err = unmarshalYAML(data, &fc)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("sentryhook: parsing %s: %v", path, err)
+	}
+	expandConfigStrings(reflect.ValueOf(&fc).Elem())
+	return fc.toConfig()
+}
+
+// expandConfigStrings expands the environment references of the strings
+// held by v, see expandEnvRefs. Map keys are left alone.
+func expandConfigStrings(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(expandEnvRefs(v.String()))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			expandConfigStrings(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			expandConfigStrings(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandConfigStrings(v.Index(i))
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			value := expandEnvRefs(v.MapIndex(k).String())
+			v.SetMapIndex(k, reflect.ValueOf(value).Convert(v.Type().Elem()))
+		}
+	}
+}
+
+// expandEnvRefs replaces the ${NAME} and ${NAME:-fallback} references in s.
+func expandEnvRefs(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			// an escaped reference
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		b.WriteString(expandEnv(s[i+2 : i+2+end]))
+		s = s[i+2+end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// expandEnv resolves a single ${NAME} or ${NAME:-fallback} reference.
+func expandEnv(name string) string {
+	fallback := ""
+	if i := strings.Index(name, ":-"); i >= 0 {
+		name, fallback = name[:i], name[i+2:]
+	}
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (fc fileConfig) toConfig() (Config, error) {
+	config := DefaultConfig()
+
+	if fc.DSN != "" {
+		if _, err := sentrygo.NewDsn(fc.DSN); err != nil {
+			return Config{}, fmt.Errorf("sentryhook: invalid dsn: %v", err)
+		}
+		config.DSN = fc.DSN
+	}
+	config.Environment = fc.Environment
+	config.Release = fc.Release
+	if len(fc.Levels) > 0 {
+		levels, err := parseLevels(fc.Levels)
+		if err != nil {
+			return Config{}, err
+		}
+		config.Levels = levels
+	}
+	if fc.Timeout != "" {
+		timeout, err := parseDuration("timeout", fc.Timeout)
+		if err != nil {
+			return Config{}, err
+		}
+		config.Timeout = timeout
+	}
+	if fc.FlushTimeout != "" {
+		timeout, err := parseDuration("flush_timeout", fc.FlushTimeout)
+		if err != nil {
+			return Config{}, err
+		}
+		config.FlushTimeout = timeout
+	}
+	config.Tags = fc.Tags
+	config.Async = fc.Async
+	if fc.SampleRate < 0 || fc.SampleRate > 1 {
+		return Config{}, fmt.Errorf("sentryhook: invalid sample_rate %v, expected a value between 0 and 1", fc.SampleRate)
+	}
+	config.SampleRate = fc.SampleRate
+
+	config.StackTrace.Enable = fc.StackTrace.Enable
+	if fc.StackTrace.Level != "" {
+		level, err := logrus.ParseLevel(fc.StackTrace.Level)
+		if err != nil {
+			return Config{}, fmt.Errorf("sentryhook: invalid stacktrace level: %v", err)
+		}
+		config.StackTrace.Level = level
+	}
+	if fc.StackTrace.Skip != nil {
+		if *fc.StackTrace.Skip < 0 {
+			return Config{}, fmt.Errorf("sentryhook: invalid stacktrace skip %d", *fc.StackTrace.Skip)
+		}
+		config.StackTrace.Skip = *fc.StackTrace.Skip
+	}
+	if fc.StackTrace.Context < 0 {
+		return Config{}, fmt.Errorf("sentryhook: invalid stacktrace context %d", fc.StackTrace.Context)
+	}
+	config.StackTrace.Context = fc.StackTrace.Context
+	config.StackTrace.InAppPrefixes = fc.StackTrace.InAppPrefixes
+	config.StackTrace.InAppRules = fc.StackTrace.InAppRules
+	for _, fr := range fc.Rules {
+		rule, err := fr.toRule()
+		if err != nil {
+			return Config{}, err
+		}
+		config.Rules = append(config.Rules, rule)
+	}
+	return config, nil
+}
+
+// ConfigFromEnv reads the configuration from environment variables, so a
+// service can be configured entirely through its deployment:
+//
+//	SENTRY_DSN                     the DSN
+//	SENTRY_ENVIRONMENT             the environment
+//	SENTRY_RELEASE                 the release
+//	SENTRYHOOK_LEVELS              comma separated levels, e.g. "error,fatal,panic"
+//	SENTRYHOOK_TIMEOUT             the send timeout, e.g. "200ms"
+//	SENTRYHOOK_FLUSH_TIMEOUT       the flush timeout, e.g. "5s"
+//	SENTRYHOOK_TAGS                comma separated tags, e.g. "team=payments,region=eu"
+//	SENTRYHOOK_ASYNC               whether events are sent asynchronously
+//	SENTRYHOOK_STACKTRACE          whether stacktraces are attached
+//	SENTRYHOOK_STACKTRACE_LEVEL    the level from which stacktraces are attached
+//	SENTRYHOOK_STACKTRACE_SKIP     the number of frames skipped
+//	SENTRYHOOK_STACKTRACE_CONTEXT  the number of source lines around frames
+//	SENTRYHOOK_IN_APP_PREFIXES     comma separated in-app package prefixes
+//
+// Unset variables keep their defaults and the result is validated like a
+// configuration file.
+func ConfigFromEnv() (Config, error) {
+	var fc fileConfig
+	fc.DSN = os.Getenv("SENTRY_DSN")
+	fc.Environment = os.Getenv("SENTRY_ENVIRONMENT")
+	fc.Release = os.Getenv("SENTRY_RELEASE")
+	fc.Levels = splitList(os.Getenv("SENTRYHOOK_LEVELS"))
+	fc.Timeout = os.Getenv("SENTRYHOOK_TIMEOUT")
+	fc.FlushTimeout = os.Getenv("SENTRYHOOK_FLUSH_TIMEOUT")
+	if tags := splitList(os.Getenv("SENTRYHOOK_TAGS")); len(tags) > 0 {
+		fc.Tags = make(map[string]string, len(tags))
+		for _, tag := range tags {
+			i := strings.Index(tag, "=")
+			if i <= 0 {
+				return Config{}, fmt.Errorf("sentryhook: invalid SENTRYHOOK_TAGS entry %q, expected key=value", tag)
+			}
+			fc.Tags[strings.TrimSpace(tag[:i])] = strings.TrimSpace(tag[i+1:])
+		}
+	}
+	var err error
+	if fc.Async, err = envBool("SENTRYHOOK_ASYNC"); err != nil {
+		return Config{}, err
+	}
+	if fc.StackTrace.Enable, err = envBool("SENTRYHOOK_STACKTRACE"); err != nil {
+		return Config{}, err
+	}
+	fc.StackTrace.Level = os.Getenv("SENTRYHOOK_STACKTRACE_LEVEL")
+	if value := os.Getenv("SENTRYHOOK_STACKTRACE_SKIP"); value != "" {
+		skip, err := strconv.Atoi(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("sentryhook: invalid SENTRYHOOK_STACKTRACE_SKIP: %v", err)
+		}
+		fc.StackTrace.Skip = &skip
+	}
+	if value := os.Getenv("SENTRYHOOK_STACKTRACE_CONTEXT"); value != "" {
+		if fc.StackTrace.Context, err = strconv.Atoi(value); err != nil {
+			return Config{}, fmt.Errorf("sentryhook: invalid SENTRYHOOK_STACKTRACE_CONTEXT: %v", err)
+		}
+	}
+	fc.StackTrace.InAppPrefixes = splitList(os.Getenv("SENTRYHOOK_IN_APP_PREFIXES"))
+	return fc.toConfig()
+}
+
+// splitList splits a comma separated list, leaving out empty elements.
+func splitList(value string) []string {
+	var list []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			list = append(list, element)
+		}
+	}
+	return list
+}
+
+// envBool parses a boolean environment variable, false if it is unset.
+func envBool(name string) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("sentryhook: invalid %s: %v", name, err)
+	}
+	return b, nil
+}
+
+func parseLevels(names []string) ([]logrus.Level, error) {
+	levels := make([]logrus.Level, 0, len(names))
+	for _, name := range names {
+		level, err := logrus.ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("sentryhook: invalid level: %v", err)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+func parseDuration(field, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("sentryhook: invalid %s: %v", field, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("sentryhook: invalid %s: negative duration %s", field, value)
+	}
+	return d, nil
+}
+
+// NewFromConfig creates a hook from a Config. Additional options are applied
+// after the configuration.
+func NewFromConfig(config Config, opts ...Option) (*SentryHook, error) {
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("sentryhook: invalid sample rate %v, expected a value between 0 and 1", config.SampleRate)
+	}
+	options := []Option{
+		func(hook *SentryHook) {
+			if config.Levels != nil {
+				hook.levels = config.Levels
+			}
+			if config.Timeout > 0 {
+				hook.Timeout = config.Timeout
+			}
+			if config.FlushTimeout > 0 {
+				hook.flushTimeout = config.FlushTimeout
+			}
+			if config.Environment != "" {
+				hook.clientOptions.Environment = config.Environment
+			}
+			if config.Release != "" {
+				hook.release = config.Release
+			}
+			hook.tags = copyTags(config.Tags, 0)
+			hook.StacktraceConfiguration = config.StackTrace
+			hook.asynchronous = config.Async
+			hook.rules = config.Rules
+			hook.sampleRate = config.SampleRate
+			hook.scrubbers = config.Scrubbers
+		},
+	}
+	return NewSentryHook(config.DSN, append(options, opts...)...)
+}
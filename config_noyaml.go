@@ -0,0 +1,10 @@
+//go:build sentryhook_noyaml
+// +build sentryhook_noyaml
+
+package sentryhook
+
+import "errors"
+
+func unmarshalYAML(data []byte, v interface{}) error {
+	return errors.New("YAML support is left out by the sentryhook_noyaml build tag, use a .json file")
+}
@@ -0,0 +1,88 @@
+package sentryhook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "sentryhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	cases := map[string]string{
+		"dsn":     `{"dsn": "not a dsn"}`,
+		"level":   `{"levels": ["loud"]}`,
+		"timeout": `{"timeout": "soon"}`,
+	}
+	for name, content := range cases {
+		path := writeConfigFile(t, "sentry.json", content)
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+		os.RemoveAll(filepath.Dir(path))
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"SENTRY_DSN":                 "https://public@sentry.example.com/1",
+		"SENTRY_ENVIRONMENT":         "staging",
+		"SENTRY_RELEASE":             "v1.2.3",
+		"SENTRYHOOK_LEVELS":          "error, fatal",
+		"SENTRYHOOK_FLUSH_TIMEOUT":   "1s",
+		"SENTRYHOOK_TAGS":            "team=payments,region=eu",
+		"SENTRYHOOK_ASYNC":           "true",
+		"SENTRYHOOK_STACKTRACE":      "1",
+		"SENTRYHOOK_STACKTRACE_SKIP": "4",
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DSN != env["SENTRY_DSN"] || config.Environment != "staging" || config.Release != "v1.2.3" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	if len(config.Levels) != 2 || config.Levels[1] != logrus.FatalLevel {
+		t.Fatalf("unexpected levels %v", config.Levels)
+	}
+	if config.FlushTimeout != time.Second || config.Timeout != 100*time.Millisecond {
+		t.Fatalf("unexpected timeouts %v %v", config.FlushTimeout, config.Timeout)
+	}
+	if config.Tags["team"] != "payments" || config.Tags["region"] != "eu" {
+		t.Fatalf("unexpected tags %v", config.Tags)
+	}
+	if !config.Async || !config.StackTrace.Enable || config.StackTrace.Skip != 4 {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	hook, err := NewFromConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.client.Options().Environment != "staging" || hook.release != "v1.2.3" {
+		t.Fatalf("expected the environment and release on the hook")
+	}
+
+	os.Setenv("SENTRYHOOK_TAGS", "payments")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatal("expected an error for a malformed tag")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !sentryhook_noyaml
+// +build !sentryhook_noyaml
+
+package sentryhook
+
+import "gopkg.in/yaml.v2"
+
+// This file parses YAML configuration files. Build with the
+// sentryhook_noyaml tag to leave the dependency out.
+
+func unmarshalYAML(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
@@ -0,0 +1,130 @@
+//go:build !sentryhook_noyaml
+// +build !sentryhook_noyaml
+
+package sentryhook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoadConfigYAML(t *testing.T) {
+	os.Setenv("SENTRYHOOK_TEST_DSN", "https://public@sentry.example.com/1")
+	defer os.Unsetenv("SENTRYHOOK_TEST_DSN")
+
+	path := writeConfigFile(t, "sentry.yaml", `
+dsn: ${SENTRYHOOK_TEST_DSN}
+levels: [error, fatal]
+flush_timeout: 1s
+tags:
+  env: ${SENTRYHOOK_TEST_ENV:-staging}
+stacktrace:
+  enable: true
+  level: error
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DSN != "https://public@sentry.example.com/1" {
+		t.Fatalf("unexpected dsn %q", config.DSN)
+	}
+	if len(config.Levels) != 2 || config.Levels[0] != logrus.ErrorLevel {
+		t.Fatalf("unexpected levels %v", config.Levels)
+	}
+	if config.FlushTimeout != time.Second || config.Timeout != 100*time.Millisecond {
+		t.Fatalf("unexpected timeouts %v %v", config.FlushTimeout, config.Timeout)
+	}
+	if config.Tags["env"] != "staging" {
+		t.Fatalf("expected env fallback, got %q", config.Tags["env"])
+	}
+	if !config.StackTrace.Enable || config.StackTrace.Level != logrus.ErrorLevel || config.StackTrace.Skip != 6 {
+		t.Fatalf("unexpected stacktrace config %+v", config.StackTrace)
+	}
+	if _, err := NewFromConfig(config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigExpansion(t *testing.T) {
+	os.Setenv("SENTRYHOOK_TEST_TEAM", "pay\"ments\nlevels: [debug]")
+	defer os.Unsetenv("SENTRYHOOK_TEST_TEAM")
+
+	path := writeConfigFile(t, "sentry.yaml", `
+levels: [error]
+tags:
+  team: ${SENTRYHOOK_TEST_TEAM}
+  price: 'cost $5 or $1'
+  template: $${NOT_EXPANDED}
+rules:
+  - match:
+      message: '^charge \$\d+$'
+    action: drop
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Tags["team"] != "pay\"ments\nlevels: [debug]" || len(config.Levels) != 1 {
+		t.Fatalf("expected the variable taken literally, got %q and levels %v", config.Tags["team"], config.Levels)
+	}
+	if config.Tags["price"] != "cost $5 or $1" || config.Tags["template"] != "${NOT_EXPANDED}" {
+		t.Fatalf("expected bare and escaped dollars kept, got %q and %q", config.Tags["price"], config.Tags["template"])
+	}
+	if len(config.Rules) != 1 || !config.Rules[0].Message.MatchString("charge $42") {
+		t.Fatalf("expected the rule's pattern kept, got %+v", config.Rules)
+	}
+}
+
+func TestLoadConfigRules(t *testing.T) {
+	path := writeConfigFile(t, "sentry.yaml", `
+rules:
+  - name: noisy
+    match:
+      message: "^cache miss"
+      levels: [debug..warn]
+    action: drop
+  - name: db
+    match:
+      tags: {component: db}
+    action: escalate
+    level: error
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", config.Rules)
+	}
+	noisy, db := config.Rules[0], config.Rules[1]
+	if noisy.Action != RuleDrop || !noisy.Message.MatchString("cache miss for user 1") || len(noisy.Levels) != 3 {
+		t.Fatalf("unexpected rule %+v", noisy)
+	}
+	if db.Action != RuleEscalate || db.Level != sentrygo.LevelError || db.Tags["component"] != "db" {
+		t.Fatalf("unexpected rule %+v", db)
+	}
+
+	for _, invalid := range []string{
+		"rules: [{action: explode}]",
+		"rules: [{action: route}]",
+		"rules: [{action: drop, match: {message: '('}}]",
+		"rules: [{action: drop, match: {levels: [warn..loud]}}]",
+	} {
+		path := writeConfigFile(t, "sentry.yaml", invalid)
+		defer os.RemoveAll(filepath.Dir(path))
+		if _, err := LoadConfig(path); err == nil {
+			t.Fatalf("expected an error for %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sentryhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNetworkConstraint is returned by the transport of a hook when sending
+// a request would exceed its network constraints.
+var ErrNetworkConstraint = errors.New("sentryhook: network constraints exceeded")
+
+// NetworkConstraints limit the traffic a hook generates, e.g. for devices
+// reporting over metered links. Zero values mean no limit.
+type NetworkConstraints struct {
+	// the maximum number of request bytes sent per minute
+	MaxBytesPerMinute int
+	// the maximum number of requests sent per minute
+	MaxEventsPerMinute int
+	// whether request bodies are gzip-compressed before sending
+	PreferCompression bool
+}
+
+// WithNetworkConstraints enforces the constraints on every HTTP request the
+// client sends, so every delivery attempt counts against the same budget.
+// Requests over budget are dropped and counted as throttled in the stats.
+// It only applies to hooks creating their own client.
+func WithNetworkConstraints(constraints NetworkConstraints) Option {
+	return func(hook *SentryHook) {
+		hook.constraints = &constraints
+	}
+}
+
+// constrainedTransport wraps base, or http.DefaultTransport if base is nil,
+// with the hook's network constraints.
+func (hook *SentryHook) constrainedTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &constrainedTransport{base: base, constraints: *hook.constraints, hook: hook}
+}
+
+type constrainedTransport struct {
+	base        http.RoundTripper
+	constraints NetworkConstraints
+	hook        *SentryHook
+
+	mu          sync.Mutex
+	windowStart time.Time
+	events      int
+	bytes       int
+}
+
+func (t *constrainedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	req = req.Clone(req.Context())
+	if t.constraints.PreferCompression && req.Header.Get("Content-Encoding") == "" {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(body); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	if !t.admit(len(body)) {
+		t.hook.stats.update(func(stats *Stats) {
+			stats.Throttled++
+		})
+		return nil, ErrNetworkConstraint
+	}
+	return t.base.RoundTrip(req)
+}
+
+// admit charges a request of the given size against the budget of the
+// current minute and reports whether it fits.
+func (t *constrainedTransport) admit(size int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.hook.now()
+	// also start over if the clock was stepped back
+	if elapsed := now.Sub(t.windowStart); elapsed >= time.Minute || elapsed < 0 {
+		t.windowStart = now
+		t.events = 0
+		t.bytes = 0
+	}
+	if t.constraints.MaxEventsPerMinute > 0 && t.events+1 > t.constraints.MaxEventsPerMinute {
+		return false
+	}
+	if t.constraints.MaxBytesPerMinute > 0 && t.bytes+size > t.constraints.MaxBytesPerMinute {
+		return false
+	}
+	t.events++
+	t.bytes += size
+	return true
+}
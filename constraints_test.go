@@ -0,0 +1,31 @@
+package sentryhook
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNetworkConstraints(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithNetworkConstraints(NetworkConstraints{
+		MaxEventsPerMinute: 2,
+		PreferCompression:  true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	for i := 0; i < 3; i++ {
+		log.Error("constrained")
+	}
+
+	if n := len(server.Events()); n != 2 {
+		t.Fatalf("expected 2 compressed events to get through, got %d", n)
+	}
+	if throttled := hook.Stats().Throttled; throttled != 1 {
+		t.Fatalf("expected 1 throttled request, got %d", throttled)
+	}
+}
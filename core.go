@@ -0,0 +1,147 @@
+package sentryhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// defaultCoreWorkers is the number of delivery workers of a Core.
+const defaultCoreWorkers = 4
+
+// Core is a delivery queue, a pool of workers and a client shared by
+// several hooks, so that processes with many loggers don't run a worker and
+// a connection pool per hook. Hooks attached to a core with NewHookWithCore
+// keep their own configuration, stats and Errors channel.
+type Core struct {
+	client    *sentrygo.Client
+	queueSize int
+	workers   int
+	queue     chan *queuedEvent
+	running   sync.WaitGroup
+	closed    bool
+	mu        sync.RWMutex
+}
+
+// A CoreOption configures a Core.
+type CoreOption func(core *Core)
+
+// WithCoreQueueSize sets how many events of all attached hooks the core
+// buffers before it starts dropping them.
+func WithCoreQueueSize(size int) CoreOption {
+	return func(core *Core) {
+		core.queueSize = size
+	}
+}
+
+// WithCoreWorkers sets the number of workers delivering the events of the
+// attached hooks. Events are delivered in order only with a single worker.
+func WithCoreWorkers(workers int) CoreOption {
+	return func(core *Core) {
+		core.workers = workers
+	}
+}
+
+// NewCore creates a delivery core sending events through the client and
+// starts its workers.
+func NewCore(client *sentrygo.Client, opts ...CoreOption) (*Core, error) {
+	if client == nil {
+		return nil, ErrNilClient
+	}
+	core := &Core{
+		client:    client,
+		queueSize: defaultQueueSize,
+		workers:   defaultCoreWorkers,
+	}
+	for _, o := range opts {
+		o(core)
+	}
+	if core.queueSize <= 0 {
+		core.queueSize = defaultQueueSize
+	}
+	if core.workers <= 0 {
+		core.workers = 1
+	}
+	core.queue = make(chan *queuedEvent, core.queueSize)
+	core.running.Add(core.workers)
+	for i := 0; i < core.workers; i++ {
+		go core.worker()
+	}
+	return core, nil
+}
+
+// NewHookWithCore creates an asynchronous hook delivering through the
+// core's queue, workers and client. Options configuring the client or the
+// queue, like WithNetworkConstraints or WithQueueSize, have no effect.
+// Closing the hook waits for its own events only and leaves the core
+// running for the other hooks.
+func NewHookWithCore(core *Core, opts ...Option) (*SentryHook, error) {
+	hook := newSentryHook(opts...)
+	if core == nil {
+		return nil, hook.misuse(ErrNilClient)
+	}
+	if hook.optionErr != nil {
+		return nil, hook.optionErr
+	}
+	hook.core = core
+	hook.client = core.client
+	hook.asynchronous = true
+	return hook.init(), nil
+}
+
+// Close stops the core once the events of all attached hooks are delivered
+// or ctx is done, and flushes the client. Hooks still attached report
+// further events as failed with ErrClosed.
+func (core *Core) Close(ctx context.Context) error {
+	core.mu.Lock()
+	if core.closed {
+		core.mu.Unlock()
+		return ErrClosed
+	}
+	core.closed = true
+	close(core.queue)
+	core.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		core.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	timeout := 3 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if !core.client.Flush(timeout) {
+		return ErrFlushTimeout
+	}
+	return nil
+}
+
+// push adds the event to the queue without blocking.
+func (core *Core) push(item *queuedEvent) error {
+	core.mu.RLock()
+	defer core.mu.RUnlock()
+	if core.closed {
+		return ErrClosed
+	}
+	select {
+	case core.queue <- item:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (core *Core) worker() {
+	defer core.running.Done()
+	for item := range core.queue {
+		item.hook.process(item)
+	}
+}
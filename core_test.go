@@ -0,0 +1,80 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHooksShareCore(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Dsn: server.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, err := NewCore(client, WithCoreWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loggers []*logrus.Logger
+	var hooks []*SentryHook
+	for _, name := range []string{"billing", "search"} {
+		hook, err := NewHookWithCore(core, WithTags(map[string]string{"component": name}), WithTimeout(5*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		loggers = append(loggers, log)
+		hooks = append(hooks, hook)
+	}
+	if hooks[0].queue != nil || hooks[1].queue != nil {
+		t.Fatal("expected hooks attached to a core to have no queue of their own")
+	}
+	loggers[0].Error("charge failed")
+	loggers[1].Error("index missing")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := hooks[0].Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the other hook keeps delivering through the core
+	loggers[1].Error("index stale")
+	hooks[1].Flush()
+	if err := core.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	components := map[string]int{}
+	for _, event := range server.Events() {
+		components[event.Tags["component"]]++
+	}
+	if components["billing"] != 1 || components["search"] != 2 {
+		t.Fatalf("unexpected events per component %v", components)
+	}
+	if s := hooks[1].Stats(); s.Sent != 2 {
+		t.Fatalf("expected the hook to count its own deliveries, got %+v", s)
+	}
+
+	loggers[1].Error("too late")
+	select {
+	case e := <-hooks[1].Errors():
+		if e.Err != ErrClosed {
+			t.Fatalf("expected ErrClosed, got %v", e.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an error for an event logged after the core closed")
+	}
+}
+
+func TestNewCoreWithoutClient(t *testing.T) {
+	if _, err := NewCore(nil); err != ErrNilClient {
+		t.Fatalf("expected ErrNilClient, got %v", err)
+	}
+}
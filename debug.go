@@ -0,0 +1,39 @@
+package sentryhook
+
+import (
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// WithDebugLogger logs the hook's own operation to logger at debug level:
+// events built, sent and not delivered, events dropped with the reason,
+// and retries. It is meant for diagnosing the integration; without it the
+// hook logs nothing. The logger must not have the hook attached.
+func WithDebugLogger(logger logrus.FieldLogger) Option {
+	return func(hook *SentryHook) {
+		hook.debugLogger = logger
+	}
+}
+
+// debug logs a diagnostic message about the event.
+func (hook *SentryHook) debug(event *sentrygo.Event, fields logrus.Fields, msg string) {
+	if hook.debugLogger == nil {
+		return
+	}
+	if fields == nil {
+		fields = logrus.Fields{}
+	}
+	if event != nil {
+		fields["event_id"] = string(event.EventID)
+		fields["message"] = event.Message
+	}
+	hook.debugLogger.WithFields(fields).Debug("sentryhook: " + msg)
+}
+
+// dropped logs that the event was dropped and why.
+func (hook *SentryHook) dropped(event *sentrygo.Event, reason string) {
+	hook.debug(event, logrus.Fields{"reason": reason}, "event dropped")
+	if !deliberateDrops[reason] {
+		hook.selfReport("drop", reason, event, nil)
+	}
+}
@@ -0,0 +1,41 @@
+package sentryhook
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestDebugLogger(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	debugLogger, debugLog := test.NewNullLogger()
+	debugLogger.SetLevel(logrus.DebugLevel)
+	hook, err := NewSentryHook(server.DSN(), WithDebugLogger(debugLogger),
+		WithRules(Rule{Message: regexp.MustCompile("^noisy"), Action: RuleDrop}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("payment failed")
+	log.Error("noisy retry")
+
+	var messages []string
+	for _, e := range debugLog.AllEntries() {
+		if e.Level != logrus.DebugLevel {
+			t.Fatalf("expected debug entries, got %v", e.Level)
+		}
+		messages = append(messages, e.Message)
+	}
+	want := "sentryhook: event built,sentryhook: event sent,sentryhook: event built,sentryhook: event dropped"
+	if got := strings.Join(messages, ","); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if last := debugLog.LastEntry(); last.Data["reason"] != "rule" || last.Data["message"] != "noisy retry" {
+		t.Fatalf("unexpected drop entry %+v", last.Data)
+	}
+}
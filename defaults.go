@@ -0,0 +1,32 @@
+package sentryhook
+
+import (
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSeverityMap returns a copy of the mapping of logrus levels to
+// sentry levels used without WithLevelMapping.
+func DefaultSeverityMap() map[logrus.Level]sentrygo.Level {
+	m := make(map[logrus.Level]sentrygo.Level, len(severityMap))
+	for k, v := range severityMap {
+		m[k] = v
+	}
+	return m
+}
+
+// DefaultLevels returns the levels a new hook fires for.
+func DefaultLevels() []logrus.Level {
+	return []logrus.Level{logrus.WarnLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.PanicLevel}
+}
+
+// DefaultStackTraceConfiguration returns the stacktrace configuration of a
+// new hook.
+func DefaultStackTraceConfiguration() StackTraceConfiguration {
+	return StackTraceConfiguration{
+		Enable:            false,
+		Level:             logrus.WarnLevel,
+		Skip:              6,
+		SendExceptionType: true,
+	}
+}
@@ -0,0 +1,37 @@
+package sentryhook
+
+import (
+	"reflect"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDefaults(t *testing.T) {
+	m := DefaultSeverityMap()
+	if m[logrus.WarnLevel] != sentrygo.LevelWarning {
+		t.Fatalf("unexpected default severity map %v", m)
+	}
+	m[logrus.WarnLevel] = sentrygo.LevelError
+	if DefaultSeverityMap()[logrus.WarnLevel] != sentrygo.LevelWarning {
+		t.Fatal("expected a copy of the severity map")
+	}
+
+	levels := DefaultLevels()
+	levels[0] = logrus.DebugLevel
+	if DefaultLevels()[0] != logrus.WarnLevel {
+		t.Fatal("expected a copy of the default levels")
+	}
+
+	hook, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hook.StacktraceConfiguration, DefaultStackTraceConfiguration()) {
+		t.Fatalf("expected the default stacktrace configuration, got %+v", hook.StacktraceConfiguration)
+	}
+	if !reflect.DeepEqual(hook.levels, DefaultLevels()) {
+		t.Fatalf("expected the default levels, got %v", hook.levels)
+	}
+}
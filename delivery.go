@@ -0,0 +1,199 @@
+package sentryhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultErrorsBuffer is the capacity of the channel returned by Errors.
+const defaultErrorsBuffer = 100
+
+var (
+	// ErrEventDropped is reported when the client did not accept an event,
+	// e.g. because of sampling, a BeforeSend callback or rate limiting.
+	ErrEventDropped = errors.New("sentryhook: event was dropped by the client")
+	// ErrFlushTimeout is reported when the client could not flush its
+	// buffered events within the flush timeout.
+	ErrFlushTimeout = errors.New("sentryhook: timed out flushing events")
+	// ErrTimeout is reported when a delivery did not complete within the
+	// hook's Timeout. The transport may still deliver the event later.
+	ErrTimeout = errors.New("sentryhook: delivery timed out")
+)
+
+// DeliveryError describes an event which could not be delivered.
+type DeliveryError struct {
+	Event *sentrygo.Event
+	Err   error
+	// the name of the destination, empty for the hook's own client
+	Destination string
+}
+
+func (e DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// WithEventFinalizer calls fn after every attempt to deliver an event to
+// the hook's own client, with the event id assigned by the client, nil if
+// it did not accept the event, and the outcome, e.g. to audit deliveries,
+// count them or link event ids back into request logs. It is called from
+// the delivering goroutine, the queue worker in asynchronous mode, and must
+// not log to the hook's logger.
+func WithEventFinalizer(fn func(event *sentrygo.Event, id *sentrygo.EventID, err error)) Option {
+	return func(hook *SentryHook) {
+		hook.finalizer = fn
+	}
+}
+
+// WithErrorsBuffer sets the capacity of the channel returned by Errors.
+func WithErrorsBuffer(size int) Option {
+	return func(hook *SentryHook) {
+		hook.errors = make(chan DeliveryError, size)
+	}
+}
+
+// Errors returns a channel reporting failures of asynchronous deliveries,
+// so applications can count them or fail over. Events the server could
+// not be reached for or rejected are reported with a TransportError. The
+// channel is bounded and never blocks the hook: errors are discarded while
+// it is full. In synchronous mode failures are returned from Fire instead.
+func (hook *SentryHook) Errors() <-chan DeliveryError {
+	return hook.errors
+}
+
+// deliver sends the event to the destination, or the hook's own client if
+// dest is nil, bounded by the hook's Timeout, and records the outcome in the
+// hook's stats.
+func (hook *SentryHook) deliver(dest *destination, event *sentrygo.Event) error {
+	return hook.deliverWithin(dest, event, hook.Timeout)
+}
+
+// deliverWithin is deliver with a timeout other than the hook's Timeout.
+func (hook *SentryHook) deliverWithin(dest *destination, event *sentrygo.Event, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := hook.now()
+	id, err := hook.send(ctx, dest, event)
+	took := hook.now().Sub(start)
+	hook.stats.update(func(stats *Stats) {
+		stats.DeliveryLatency.observe(took)
+		if err == ErrTimeout {
+			stats.TimedOut++
+		}
+		if err != nil {
+			stats.Failed++
+		} else {
+			stats.Sent++
+		}
+	})
+	if hook.debugLogger != nil {
+		fields := logrus.Fields{"destination": "sentry", "took": took}
+		if dest != nil {
+			fields["destination"] = dest.name
+		}
+		if err != nil {
+			fields[logrus.ErrorKey] = err
+			hook.debug(event, fields, "delivery attempt failed")
+		} else {
+			hook.debug(event, fields, "event sent")
+		}
+	}
+	if dest == nil && hook.finalizer != nil {
+		hook.finalizer(event, id, err)
+	}
+	return err
+}
+
+// send captures the event and flushes the client, waiting at most the
+// flush timeout or until the context deadline, whichever comes first. It
+// returns the id of the captured event, nil for sinks, and a
+// TransportError if the request failed or the server rejected the event.
+func (hook *SentryHook) send(ctx context.Context, dest *destination, event *sentrygo.Event) (*sentrygo.EventID, error) {
+	if ctx.Err() != nil {
+		return nil, ErrTimeout
+	}
+	client := hook.deliveryClient()
+	outcomes := &hook.outcomes
+	if dest != nil && hook.dryRun != nil {
+		return nil, hook.dryRun.send(ctx, event)
+	} else if dest != nil && dest.sink != nil {
+		return nil, dest.sink.Send(ctx, event)
+	} else if dest != nil {
+		client = dest.client
+		outcomes = dest.outcomes
+	}
+	if dest == nil {
+		defer hook.traces.track(hook, event)()
+	}
+	outcome := func() error { return nil }
+	if outcomes != nil {
+		outcome = outcomes.track(hook, event)
+	}
+	eventID := client.CaptureEvent(event, nil, hook.eventScope(event))
+	if eventID == nil {
+		outcome()
+		return nil, ErrEventDropped
+	}
+	timeout := hook.flushTimeout
+	deadline, bounded := ctx.Deadline()
+	if bounded && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	} else {
+		bounded = false
+	}
+	var err error
+	if !client.Flush(timeout) {
+		// the transport may still deliver it
+		outcome()
+		err = ErrFlushTimeout
+		if bounded {
+			err = ErrTimeout
+		}
+	} else if err = outcome(); err != nil {
+		return eventID, err
+	}
+	if dest == nil {
+		hook.setLastEventID(eventID)
+		hook.recordRecent(*eventID, event)
+	}
+	return eventID, err
+}
+
+// deliveryFailed logs and self-reports that the event could not be
+// delivered to the destination.
+func (hook *SentryHook) deliveryFailed(dest *destination, event *sentrygo.Event, err error) {
+	if hook.debugLogger == nil && hook.selfReporter == nil {
+		return
+	}
+	destination := "sentry"
+	if dest != nil {
+		destination = dest.name
+	}
+	hook.debug(event, logrus.Fields{"destination": destination, logrus.ErrorKey: err}, "event not delivered")
+	hook.selfReport("delivery", err.Error(), event, map[string]interface{}{"destination": destination})
+}
+
+// reportError publishes a failed asynchronous delivery without blocking.
+func (hook *SentryHook) reportError(dest *destination, event *sentrygo.Event, err error) {
+	e := DeliveryError{Event: event, Err: err}
+	if dest != nil {
+		e.Destination = dest.name
+	}
+	hook.deliveryFailed(dest, event, err)
+	select {
+	case hook.errors <- e:
+	default:
+	}
+}
@@ -0,0 +1,168 @@
+package sentryhook
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAsyncDeliveryErrors(t *testing.T) {
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{
+		BeforeSend: func(event *sentrygo.Event, hint *sentrygo.EventHint) *sentrygo.Event {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := NewWithClientSentryHook(client, WithAsync(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("dropped by BeforeSend")
+	hook.Flush()
+
+	select {
+	case deliveryErr := <-hook.Errors():
+		if deliveryErr.Err != ErrEventDropped {
+			t.Fatalf("expected ErrEventDropped, got %v", deliveryErr.Err)
+		}
+		if deliveryErr.Event.Message != "dropped by BeforeSend" {
+			t.Fatalf("unexpected event message %q", deliveryErr.Event.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a delivery error")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	transport := &blockingTransport{release: make(chan struct{})}
+	defer close(transport.release)
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Transport: &slowFlushTransport{transport}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := NewWithClientSentryHook(client, WithTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.Fire(logrus.NewEntry(logrus.New())); err != ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if stats := hook.Stats(); stats.TimedOut != 1 || stats.Failed != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+// slowFlushTransport accepts events but only finishes flushing once the
+// wrapped transport is released.
+type slowFlushTransport struct {
+	*blockingTransport
+}
+
+func (t *slowFlushTransport) SendEvent(event *sentrygo.Event) {}
+
+func (t *slowFlushTransport) Flush(timeout time.Duration) bool {
+	select {
+	case <-t.release:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestEventFinalizer(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	type outcome struct {
+		message string
+		id      *sentrygo.EventID
+		err     error
+	}
+	var outcomes []outcome
+	hook, err := NewSentryHook(server.DSN(), WithEventFinalizer(func(event *sentrygo.Event, id *sentrygo.EventID, err error) {
+		outcomes = append(outcomes, outcome{event.Message, id, err})
+	}), func(hook *SentryHook) {
+		hook.clientOptions.BeforeSend = func(event *sentrygo.Event, hint *sentrygo.EventHint) *sentrygo.Event {
+			if event.Message == "dropped" {
+				return nil
+			}
+			return event
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("sent")
+	log.Error("dropped")
+
+	if len(outcomes) != 2 {
+		t.Fatalf("expected 2 outcomes, got %d", len(outcomes))
+	}
+	if outcomes[0].id == nil || outcomes[0].err != nil || string(*outcomes[0].id) != string(server.Events()[0].EventID) {
+		t.Fatalf("expected the sent event's id, got %+v", outcomes[0])
+	}
+	if outcomes[1].message != "dropped" || outcomes[1].id != nil || outcomes[1].err != ErrEventDropped {
+		t.Fatalf("expected the dropped event's outcome, got %+v", outcomes[1])
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	var finalized []error
+	hook, err := NewSentryHook(server.DSN(), WithTimeout(5*time.Second), WithEventFinalizer(func(event *sentrygo.Event, id *sentrygo.EventID, err error) {
+		finalized = append(finalized, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext(1, http.StatusInternalServerError)
+	err = hook.Fire(logrus.NewEntry(logrus.New()).WithField("n", 1))
+	var transportErr TransportError
+	if !errors.As(err, &transportErr) || transportErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected the rejection to be returned, got %v", err)
+	}
+	if hook.LastEventID() != "" {
+		t.Fatalf("expected the rejected event not to be recorded as sent, got %q", hook.LastEventID())
+	}
+	if err := hook.Fire(logrus.NewEntry(logrus.New()).WithField("n", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if len(finalized) != 2 || !errors.As(finalized[0], &transportErr) || finalized[1] != nil {
+		t.Fatalf("expected the finalizer to see the outcomes, got %v", finalized)
+	}
+	if stats := hook.Stats(); stats.Failed != 1 || stats.Sent != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// connection refused
+	dsn := server.DSN()
+	server.Close()
+	async, err := NewSentryHook(dsn, WithAsync(true), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := async.Fire(logrus.NewEntry(logrus.New())); err != nil {
+		t.Fatal(err)
+	}
+	async.Flush()
+	select {
+	case deliveryErr := <-async.Errors():
+		if !errors.As(deliveryErr, &transportErr) || transportErr.Err == nil {
+			t.Fatalf("expected a transport error, got %v", deliveryErr.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a delivery error")
+	}
+}
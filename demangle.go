@@ -0,0 +1,70 @@
+package sentryhook
+
+import (
+	"regexp"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// rawFunctionVar is the frame variable preserving the function name before
+// demangling.
+const rawFunctionVar = "raw_function"
+
+var (
+	// type parameters of generic functions and types, innermost first
+	typeParams = regexp.MustCompile(`\[[^\[\]]*\]`)
+	// numbered closures, go statement and defer wrappers, e.g. func1.2
+	closureSuffix = regexp.MustCompile(`\.(func|gowrap|deferwrap)\d+(\.\d+)*`)
+)
+
+// WithFrameDemangling normalizes the function names of stack frames, for
+// display and for grouping in Sentry: type parameters of generics are
+// dropped ("Map[...]" becomes "Map"), numbered closures lose their numbers
+// ("handle.func1.2" becomes "handle.func"), as do goroutine and defer
+// wrappers, and method values lose their "-fm" suffix. The raw name is kept
+// in the "raw_function" variable of the frame.
+func WithFrameDemangling() Option {
+	return func(hook *SentryHook) {
+		hook.demangle = true
+	}
+}
+
+// demangleFrames normalizes the function names of all stacktraces of the
+// event.
+func (hook *SentryHook) demangleFrames(event *sentrygo.Event) {
+	if !hook.demangle {
+		return
+	}
+	for i := range event.Exception {
+		st := event.Exception[i].Stacktrace
+		if st == nil {
+			continue
+		}
+		for j := range st.Frames {
+			frame := &st.Frames[j]
+			name := demangle(frame.Function)
+			if name == frame.Function {
+				continue
+			}
+			if frame.Vars == nil {
+				frame.Vars = make(map[string]interface{}, 1)
+			}
+			frame.Vars[rawFunctionVar] = frame.Function
+			frame.Function = name
+		}
+	}
+}
+
+// demangle returns the normalized function name.
+func demangle(name string) string {
+	for strings.Contains(name, "[") {
+		stripped := typeParams.ReplaceAllString(name, "")
+		if stripped == name {
+			break
+		}
+		name = stripped
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	return closureSuffix.ReplaceAllString(name, ".$1")
+}
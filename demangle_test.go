@@ -0,0 +1,53 @@
+package sentryhook
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDemangle(t *testing.T) {
+	for raw, want := range map[string]string{
+		"Map[...]":                               "Map",
+		"(*List[go.shape.int]).Push":             "(*List).Push",
+		"Map[...].func1":                         "Map.func",
+		"handle.func1.2":                         "handle.func",
+		"(*Server).serve.gowrap3":                "(*Server).serve.gowrap",
+		"run.deferwrap1":                         "run.deferwrap",
+		"(*Server).handle-fm":                    "(*Server).handle",
+		"Reduce[go.shape.[]int,go.shape.string]": "Reduce",
+		"main":                                   "main",
+	} {
+		if got := demangle(raw); got != want {
+			t.Errorf("demangle(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestFrameDemangling(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithFrameDemangling())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	func() {
+		log.Error("failed")
+	}()
+
+	events := server.Events()
+	if len(events) != 1 || len(events[0].Exception) == 0 || events[0].Exception[0].Stacktrace == nil {
+		t.Fatalf("expected an event with a stacktrace, got %+v", events)
+	}
+	var found bool
+	for _, frame := range events[0].Exception[0].Stacktrace.Frames {
+		if frame.Function == "TestFrameDemangling.func" {
+			found = frame.Vars[rawFunctionVar] == "TestFrameDemangling.func1"
+		}
+	}
+	if !found {
+		t.Fatalf("expected the closure frame demangled, got %+v", events[0].Exception[0].Stacktrace.Frames)
+	}
+}
@@ -0,0 +1,168 @@
+package sentryhook
+
+import (
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Profile controls how events are prepared for a destination, so that e.g.
+// an internal Sentry receives full events while an external service only
+// receives scrubbed ones.
+type Profile struct {
+	// replaces the hook's formatter for this destination; its output is
+	// used as the hook's formatter output would be, see WithMessageMode.
+	// Aggregated events are not reformatted.
+	Formatter logrus.Formatter
+	// removes all extra data
+	DropExtra bool
+	// edits the destination's copy of the event; returning nil drops it
+	Scrub func(event *sentrygo.Event) *sentrygo.Event
+}
+
+// destination is an additional client or sink events are delivered to.
+type destination struct {
+	name    string
+	client  *sentrygo.Client
+	sink    Sink
+	profile Profile
+	// whether only a share of the events, rate, is delivered
+	sampled bool
+	rate    float64
+	// the outcomes of the client's requests, nil if the hook did not
+	// create the client
+	outcomes *deliveryOutcomes
+}
+
+// WithDestination delivers every event to an additional client as well,
+// prepared according to the profile. Each destination receives its own copy
+// of the event, is delivered to independently and reports its failures on
+// Errors under the given name. As the client's transport is not the
+// hook's, events the server rejects are not reported as failures.
+func WithDestination(name string, client *sentrygo.Client, profile Profile) Option {
+	return func(hook *SentryHook) {
+		hook.destinations = append(hook.destinations, &destination{
+			name:    name,
+			client:  client,
+			profile: profile,
+		})
+	}
+}
+
+// destination returns the destination with the name, or nil.
+func (hook *SentryHook) destination(name string) *destination {
+	for _, dest := range hook.destinations {
+		if dest.name == name {
+			return dest
+		}
+	}
+	return nil
+}
+
+// prepare returns the destination's copy of the event, or nil if the
+// profile or sampling drops it. entry is nil for aggregated events.
+func (dest *destination) prepare(hook *SentryHook, event *sentrygo.Event, entry *logrus.Entry) *sentrygo.Event {
+	if dest.sampled && hook.random() >= dest.rate {
+		return nil
+	}
+	event = cloneEvent(event)
+	profile := dest.profile
+	if profile.Formatter != nil && entry != nil {
+		formatted := string(hook.createContent(profile.Formatter, entry))
+		if hook.messageBuilder == nil && hook.messageMode == MessageModeFormatted {
+			event.Message = formatted
+		} else {
+			event.Extra[formattedExtraKey] = formatted
+		}
+	}
+	if profile.DropExtra {
+		event.Extra = map[string]interface{}{}
+	}
+	if profile.Scrub != nil {
+		event = profile.Scrub(event)
+	}
+	return event
+}
+
+// cloneEvent copies the parts of an event built by the hook, so that a copy
+// can be modified, e.g. scrubbed for a destination, without affecting the
+// original. Maps and slices in extra data, contexts, breadcrumb data and
+// frame variables are copied as well; other values are shared.
+func cloneEvent(event *sentrygo.Event) *sentrygo.Event {
+	c := *event
+	c.Tags = copyTags(event.Tags, 0)
+	c.Extra = copyValues(event.Extra)
+	if event.Contexts != nil {
+		c.Contexts = copyValues(event.Contexts)
+	}
+	c.Fingerprint = append([]string(nil), event.Fingerprint...)
+	if event.Breadcrumbs != nil {
+		c.Breadcrumbs = make([]*sentrygo.Breadcrumb, len(event.Breadcrumbs))
+		for i, b := range event.Breadcrumbs {
+			if b != nil {
+				crumb := *b
+				if b.Data != nil {
+					crumb.Data = copyValues(b.Data)
+				}
+				b = &crumb
+			}
+			c.Breadcrumbs[i] = b
+		}
+	}
+	if event.Exception != nil {
+		c.Exception = make([]sentrygo.Exception, len(event.Exception))
+		for i, e := range event.Exception {
+			if e.Stacktrace != nil {
+				st := *e.Stacktrace
+				st.Frames = append([]sentrygo.Frame(nil), st.Frames...)
+				for j, frame := range st.Frames {
+					if frame.Vars != nil {
+						st.Frames[j].Vars = copyValues(frame.Vars)
+					}
+				}
+				e.Stacktrace = &st
+			}
+			c.Exception[i] = e
+		}
+	}
+	if event.Request != nil {
+		r := *event.Request
+		if r.Headers != nil {
+			r.Headers = copyTags(r.Headers, 0)
+		}
+		if r.Env != nil {
+			r.Env = copyTags(r.Env, 0)
+		}
+		c.Request = &r
+	}
+	return &c
+}
+
+// copyValues copies a map of arbitrary values, descending into the maps
+// and slices it holds.
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+// copyValue copies generic maps and slices, as produced by decoding JSON or
+// built by hand, and returns other values as they are.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return copyValues(v)
+	case map[string]string:
+		return copyTags(v, 0)
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = copyValue(e)
+		}
+		return c
+	case []string:
+		return append([]string(nil), v...)
+	}
+	return v
+}
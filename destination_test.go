@@ -0,0 +1,152 @@
+package sentryhook
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDestinationProfile(t *testing.T) {
+	internal := NewMockServer()
+	defer internal.Close()
+	external := NewMockServer()
+	defer external.Close()
+
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Dsn: external.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := NewSentryHook(internal.DSN(),
+		WithTags(map[string]string{"customer": "acme"}),
+		WithDestination("external", client, Profile{
+			Formatter: &logrus.TextFormatter{DisableTimestamp: true},
+			DropExtra: true,
+			Scrub: func(event *sentrygo.Event) *sentrygo.Event {
+				delete(event.Tags, "customer")
+				return event
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithField("card", "4111").Error("payment failed")
+
+	full := internal.Events()
+	if len(full) != 1 || full[0].Tags["customer"] != "acme" || full[0].Extra["card"] != "4111" {
+		t.Fatalf("expected the full event internally, got %+v", full)
+	}
+	scrubbed := external.Events()
+	if len(scrubbed) != 1 {
+		t.Fatalf("expected 1 external event, got %d", len(scrubbed))
+	}
+	if _, ok := scrubbed[0].Tags["customer"]; ok {
+		t.Fatalf("expected the tag to be scrubbed, got %v", scrubbed[0].Tags)
+	}
+	if len(scrubbed[0].Extra) != 0 {
+		t.Fatalf("expected no extra data, got %v", scrubbed[0].Extra)
+	}
+}
+
+func TestDestinationFailureIsIndependent(t *testing.T) {
+	primary := NewMockServer()
+	defer primary.Close()
+	secondary := NewMockServer()
+	defer secondary.Close()
+	secondary.FailNext(1, 500)
+
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Dsn: secondary.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := NewSentryHook(primary.DSN(), WithDestination("secondary", client, Profile{
+		Scrub: func(event *sentrygo.Event) *sentrygo.Event {
+			if event.Message == "private" {
+				return nil
+			}
+			return event
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("first")
+	log.Error("private")
+	log.Error("second")
+
+	if n := len(primary.Events()); n != 3 {
+		t.Fatalf("expected 3 primary events, got %d", n)
+	}
+	events := secondary.Events()
+	if len(events) != 1 || events[0].Message != "second" {
+		t.Fatalf("expected only the second event at the secondary, got %+v", events)
+	}
+}
+
+func TestDestinationScrubLeavesPrimaryEvent(t *testing.T) {
+	internal := NewMockServer()
+	defer internal.Close()
+	external := NewMockServer()
+	defer external.Close()
+
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Dsn: external.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := NewSentryHook(internal.DSN(),
+		WithBreadcrumbs(logrus.InfoLevel, 10),
+		WithDestination("external", client, Profile{
+			Scrub: func(event *sentrygo.Event) *sentrygo.Event {
+				event.Request.Headers["X-Customer"] = "[redacted]"
+				event.Breadcrumbs[0].Message = "[redacted]"
+				event.Breadcrumbs[0].Data["user"] = "[redacted]"
+				event.Contexts["cloud"].(map[string]interface{})["account"] = "[redacted]"
+				return event
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.addMetadata("cloud", "cloud.", map[string]string{"account": "1234"})
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	req := httptest.NewRequest(http.MethodGet, "/charge", nil)
+	req.Header.Set("X-Customer", "acme")
+	log.WithField("user", "alice").Info("logged in")
+	log.WithField(RequestField, req).Error("payment failed")
+
+	for name, server := range map[string]*MockServer{"internal": internal, "external": external} {
+		events := server.Events()
+		if len(events) != 1 || events[0].Request == nil || len(events[0].Breadcrumbs) == 0 {
+			t.Fatalf("%s: expected one event with request and breadcrumbs, got %+v", name, events)
+		}
+		redacted := name == "external"
+		event := events[0]
+		cloud, _ := event.Contexts["cloud"].(map[string]interface{})
+		got := []string{
+			event.Request.Headers["X-Customer"],
+			event.Breadcrumbs[0].Message,
+			fmt.Sprint(event.Breadcrumbs[0].Data["user"]),
+			fmt.Sprint(cloud["account"]),
+		}
+		for i, value := range got {
+			if (value == "[redacted]") != redacted {
+				t.Fatalf("%s: unexpected value %d %q of %v", name, i, value, got)
+			}
+		}
+	}
+
+	// the hook's own metadata is left alone for later events
+	if account := hook.metadataContexts["cloud"].(map[string]interface{})["account"]; account != "1234" {
+		t.Fatalf("expected the metadata to be unchanged, got %v", account)
+	}
+}
@@ -0,0 +1,65 @@
+package sentryhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithDryRun builds events as usual, including the processing of the
+// client like BeforeSend, but writes them to w as lines of JSON instead of
+// sending them, e.g. to review scrubbing and fingerprinting rules locally.
+// The copies of destinations and sinks are written too, after applying
+// their profiles, and so are the envelope items the hook sends itself, like
+// check-ins and sessions, as objects with the item type, header and
+// payload. Like other options configuring the client, it has no effect on
+// the client of NewWithClientSentryHook, whose events are sent.
+func WithDryRun(w io.Writer) Option {
+	return func(hook *SentryHook) {
+		hook.dryRun = &writerSink{w: w}
+		hook.clientOptions.Transport = &dryRunTransport{sink: hook.dryRun}
+	}
+}
+
+// dryRunTransport is a client transport writing events to a writer sink.
+type dryRunTransport struct {
+	sink *writerSink
+}
+
+func (t *dryRunTransport) Configure(options sentrygo.ClientOptions) {}
+
+func (t *dryRunTransport) SendEvent(event *sentrygo.Event) {
+	_ = t.sink.send(context.Background(), event)
+}
+
+func (t *dryRunTransport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+// dryRunItem is the representation of an envelope item in dry-run mode.
+type dryRunItem struct {
+	Type    string                 `json:"envelope_item"`
+	Header  map[string]interface{} `json:"header,omitempty"`
+	Payload interface{}            `json:"payload"`
+}
+
+// writeDryRunItems writes envelope items to the dry-run writer.
+func (hook *SentryHook) writeDryRunItems(items []envelopeItem) error {
+	for _, item := range items {
+		var payload interface{} = string(item.Payload)
+		if json.Valid(item.Payload) {
+			payload = json.RawMessage(item.Payload)
+		}
+		data, err := json.Marshal(dryRunItem{Type: item.Type, Header: item.Header, Payload: payload})
+		if err != nil {
+			return err
+		}
+		if err := hook.dryRun.write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
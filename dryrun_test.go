@@ -0,0 +1,67 @@
+package sentryhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDryRun(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	var out bytes.Buffer
+	hook, err := NewSentryHook(server.DSN(),
+		WithDryRun(&out),
+		WithSink("archive", SinkFunc(nil), Profile{DropExtra: true}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithField("order", 7).Error("payment failed")
+	if _, err := hook.CheckIn("nightly", CheckInOK); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []sentrygo.Event
+	var items []string
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var line map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("expected JSON lines, got %q", out.String())
+		}
+		if item, ok := line["envelope_item"]; ok {
+			items = append(items, string(item))
+			continue
+		}
+		var event sentrygo.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected the event and the sink's copy, got %d lines: %s", len(events), out.String())
+	}
+	for _, event := range events {
+		if event.Message != "payment failed" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	}
+	if len(events[0].Extra) != 0 || events[1].Extra["order"] == nil {
+		t.Fatalf("expected the sink's profile applied to its copy only, got %+v", events)
+	}
+	if len(items) != 1 || items[0] != `"check_in"` {
+		t.Fatalf("expected the check-in item, got %v", items)
+	}
+	if n := server.Requests(); n != 0 {
+		t.Fatalf("expected nothing sent in dry-run mode, got %d requests", n)
+	}
+}
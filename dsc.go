@@ -0,0 +1,179 @@
+package sentryhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// DynamicSamplingContext is the dynamic sampling context of a trace: the
+// sentry- entries of W3C baggage, without the prefix, e.g. "trace_id",
+// "public_key", "sample_rate" or "release".
+type DynamicSamplingContext map[string]string
+
+// ParseBaggage extracts the dynamic sampling context from the value of a
+// baggage header. It returns nil if the header has no sentry- entries.
+func ParseBaggage(baggage string) DynamicSamplingContext {
+	var dsc DynamicSamplingContext
+	for _, member := range strings.Split(baggage, ",") {
+		// properties of a member follow a semicolon
+		member = strings.TrimSpace(strings.SplitN(member, ";", 2)[0])
+		kv := strings.SplitN(member, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], "sentry-") {
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			continue
+		}
+		if dsc == nil {
+			dsc = make(DynamicSamplingContext)
+		}
+		dsc[strings.TrimPrefix(strings.TrimSpace(kv[0]), "sentry-")] = value
+	}
+	return dsc
+}
+
+type dscKey struct{}
+
+// ContextWithDynamicSamplingContext returns a context carrying the dynamic
+// sampling context. Events logged with it are sent with the context in
+// their envelope, so Sentry samples them consistently with the trace they
+// belong to. Middleware and RequestContext do this for the baggage header
+// of incoming requests.
+func ContextWithDynamicSamplingContext(ctx context.Context, dsc DynamicSamplingContext) context.Context {
+	return context.WithValue(ctx, dscKey{}, dsc)
+}
+
+// DynamicSamplingContextFromContext returns the dynamic sampling context
+// carried by the context, or nil.
+func DynamicSamplingContextFromContext(ctx context.Context) DynamicSamplingContext {
+	if ctx == nil {
+		return nil
+	}
+	dsc, _ := ctx.Value(dscKey{}).(DynamicSamplingContext)
+	return dsc
+}
+
+// traceContext is the trace context of an event logged with a dynamic
+// sampling context. Only the trace id is part of the event; the whole
+// dynamic sampling context goes into the envelope header.
+type traceContext struct {
+	dsc DynamicSamplingContext
+}
+
+func (t traceContext) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"trace_id": t.dsc["trace_id"]})
+}
+
+// addTraceContext adds the trace context of the entry's dynamic sampling
+// context to the event.
+func (hook *SentryHook) addTraceContext(event *sentrygo.Event, entry *logrus.Entry) {
+	dsc := DynamicSamplingContextFromContext(entry.Context)
+	if dsc == nil {
+		return
+	}
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]interface{})
+	}
+	event.Contexts["trace"] = traceContext{dsc: dsc}
+}
+
+// pendingTraces holds the dynamic sampling contexts of the events being
+// sent by the hook's own client, by event id, for the transport to put
+// into their envelopes.
+type pendingTraces struct {
+	mu     sync.Mutex
+	traces map[sentrygo.EventID]DynamicSamplingContext
+}
+
+// track registers the dynamic sampling context of the event, if it has
+// one, until the returned function is called. It assigns the event id the
+// client would otherwise assign.
+func (p *pendingTraces) track(hook *SentryHook, event *sentrygo.Event) func() {
+	t, ok := event.Contexts["trace"].(traceContext)
+	if !ok {
+		return func() {}
+	}
+	if event.EventID == "" {
+		event.EventID = sentrygo.EventID(hook.newID())
+	}
+	id := event.EventID
+	p.mu.Lock()
+	if p.traces == nil {
+		p.traces = make(map[sentrygo.EventID]DynamicSamplingContext)
+	}
+	p.traces[id] = t.dsc
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		delete(p.traces, id)
+		p.mu.Unlock()
+	}
+}
+
+func (p *pendingTraces) get(id sentrygo.EventID) DynamicSamplingContext {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.traces[id]
+}
+
+// dscTransport sends events with a dynamic sampling context as envelopes
+// carrying it in the trace header, instead of to the store endpoint the
+// client uses, which has no place for it.
+type dscTransport struct {
+	base http.RoundTripper
+	hook *SentryHook
+}
+
+func (t *dscTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || !strings.HasSuffix(req.URL.Path, "/store/") {
+		return t.base.RoundTrip(req)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var event struct {
+		EventID sentrygo.EventID `json:"event_id"`
+	}
+	_ = json.Unmarshal(body, &event)
+	dsc := t.hook.traces.get(event.EventID)
+	if dsc == nil {
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return t.base.RoundTrip(req)
+	}
+
+	var envelope bytes.Buffer
+	_ = json.NewEncoder(&envelope).Encode(map[string]interface{}{
+		"event_id": event.EventID,
+		"sent_at":  t.hook.now().UTC().Format(time.RFC3339Nano),
+		"trace":    dsc,
+	})
+	_ = json.NewEncoder(&envelope).Encode(map[string]interface{}{
+		"type":   "event",
+		"length": len(body),
+	})
+	envelope.Write(body)
+	envelope.WriteByte('\n')
+
+	r := req.Clone(req.Context())
+	u := *req.URL
+	u.Path = strings.TrimSuffix(u.Path, "/store/") + "/envelope/"
+	r.URL = &u
+	r.Host = u.Host
+	r.Body = ioutil.NopCloser(&envelope)
+	r.ContentLength = int64(envelope.Len())
+	r.Header.Set("Content-Type", "application/x-sentry-envelope")
+	return t.base.RoundTrip(r)
+}
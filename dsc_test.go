@@ -0,0 +1,58 @@
+package sentryhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseBaggage(t *testing.T) {
+	dsc := ParseBaggage("other=1, sentry-trace_id=771a43a4192642f0b136d5159a501700;prop, sentry-release=api%402.0.0,sentry-sample_rate=0.5")
+	if len(dsc) != 3 || dsc["trace_id"] != "771a43a4192642f0b136d5159a501700" || dsc["release"] != "api@2.0.0" || dsc["sample_rate"] != "0.5" {
+		t.Fatalf("unexpected dynamic sampling context %v", dsc)
+	}
+	if dsc := ParseBaggage("other=1"); dsc != nil {
+		t.Fatalf("expected nil without sentry entries, got %v", dsc)
+	}
+}
+
+func TestDynamicSamplingContextPropagation(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.WithContext(r.Context()).Error("payment failed")
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/pay", nil)
+	r.Header.Set("baggage", "sentry-trace_id=771a43a4192642f0b136d5159a501700,sentry-public_key=public,sentry-sample_rate=0.5")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	log.Error("outside of a trace")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	trace, _ := events[0].Contexts["trace"].(map[string]interface{})
+	if trace["trace_id"] != "771a43a4192642f0b136d5159a501700" {
+		t.Fatalf("expected the trace context, got %+v", events[0].Contexts)
+	}
+	headers := server.EnvelopeHeaders()
+	if len(headers) != 1 {
+		t.Fatalf("expected only the traced event in an envelope, got %+v", headers)
+	}
+	dsc, _ := headers[0]["trace"].(map[string]interface{})
+	if headers[0]["event_id"] != string(events[0].EventID) || dsc["sample_rate"] != "0.5" || dsc["public_key"] != "public" {
+		t.Fatalf("unexpected envelope header %+v", headers[0])
+	}
+	if len(hook.traces.traces) != 0 {
+		t.Fatalf("expected delivered events to be forgotten, got %v", hook.traces.traces)
+	}
+}
@@ -0,0 +1,128 @@
+package sentryhook
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoDSNProvider is returned by RefreshDSN for hooks created without
+// WithDSNProvider.
+var ErrNoDSNProvider = errors.New("sentryhook: hook has no DSN provider")
+
+// defaultDSNRefresh is how often the DSN provider is asked for the DSN.
+const defaultDSNRefresh = 5 * time.Minute
+
+// WithDSNProvider fetches the DSN from the provider, e.g. a secret manager,
+// instead of taking the DSN passed to NewSentryHook, and asks it again
+// every five minutes, see WithDSNRefreshInterval, or on RefreshDSN. When
+// it returns a new DSN the hook switches to a new client: events already
+// handed to the old client are still flushed by it, while events still
+// queued are sent with the new one. Failures of the provider after
+// creation are reported on Errors and the current client is kept;
+// NewSentryHook fails if the provider does. The option has no effect on
+// hooks created with a client.
+func WithDSNProvider(provider func() (string, error)) Option {
+	return func(hook *SentryHook) {
+		hook.dsnRotation = &dsnRotation{provider: provider, interval: defaultDSNRefresh}
+	}
+}
+
+// WithDSNRefreshInterval sets how often the provider of WithDSNProvider is
+// asked for the DSN. Zero only refreshes it on RefreshDSN.
+func WithDSNRefreshInterval(interval time.Duration) Option {
+	return func(hook *SentryHook) {
+		if hook.dsnRotation != nil {
+			hook.dsnRotation.interval = interval
+		}
+	}
+}
+
+// RefreshDSN asks the provider of WithDSNProvider for the DSN right away,
+// e.g. when the secret manager signals a rotation, and switches to a new
+// client if it changed.
+func (hook *SentryHook) RefreshDSN() error {
+	if hook.dsnRotation == nil {
+		return ErrNoDSNProvider
+	}
+	return hook.dsnRotation.refresh(hook)
+}
+
+// dsnRotation polls a DSN provider.
+type dsnRotation struct {
+	provider func() (string, error)
+	interval time.Duration
+
+	mu      sync.Mutex
+	current string
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// start launches the goroutine polling the provider every interval.
+func (r *dsnRotation) start(hook *SentryHook) {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		if r.interval <= 0 {
+			<-r.stop
+			return
+		}
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.refresh(hook); err != nil {
+					hook.reportError(nil, nil, err)
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops polling the provider.
+func (r *dsnRotation) close() {
+	close(r.stop)
+	<-r.done
+}
+
+// refresh switches the hook to a new client if the provider returns a new
+// DSN.
+func (r *dsnRotation) refresh(hook *SentryHook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dsn, err := r.provider()
+	if err != nil {
+		return fmt.Errorf("sentryhook: fetching the DSN: %v", err)
+	}
+	if dsn == r.current {
+		return nil
+	}
+	client, err := hook.newClient(dsn)
+	if err != nil {
+		return err
+	}
+	r.current = dsn
+
+	// wait for synchronous deliveries to the old client, which hold mu
+	hook.mu.Lock()
+	old := hook.client
+	if old == nil {
+		// still pending, e.g. validating the DSN in the background
+		hook.mu.Unlock()
+		return hook.SetClient(client)
+	}
+	hook.clientMu.Lock()
+	hook.client = client
+	hook.clientMu.Unlock()
+	hook.mu.Unlock()
+	if !old.Flush(hook.flushTimeout) {
+		return ErrFlushTimeout
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package sentryhook
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDSNProvider(t *testing.T) {
+	first, second := NewMockServer(), NewMockServer()
+	defer first.Close()
+	defer second.Close()
+	var mu sync.Mutex
+	dsn, fail := first.DSN(), false
+	provider := func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			return "", errors.New("vault sealed")
+		}
+		return dsn, nil
+	}
+	hook, err := NewSentryHook("", WithDSNProvider(provider), WithDSNRefreshInterval(0), WithAsync(true), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("before rotation")
+	mu.Lock()
+	dsn = second.DSN()
+	mu.Unlock()
+	if err := hook.RefreshDSN(); err != nil {
+		t.Fatal(err)
+	}
+	log.Error("after rotation")
+	hook.Flush()
+	if n, m := len(first.Events()), len(second.Events()); n+m != 2 || m == 0 {
+		t.Fatalf("expected the events split across the rotation, got %d and %d", n, m)
+	}
+
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	if err := hook.RefreshDSN(); err == nil {
+		t.Fatal("expected the provider's error")
+	}
+	log.Error("still sent")
+	hook.Flush()
+	if len(first.Events())+len(second.Events()) != 3 {
+		t.Fatal("expected the current client to be kept when the provider fails")
+	}
+
+	if _, err := NewSentryHook("", WithDSNProvider(provider)); err == nil {
+		t.Fatal("expected NewSentryHook to fail with the provider")
+	}
+	plain, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.RefreshDSN(); err != ErrNoDSNProvider {
+		t.Fatalf("expected ErrNoDSNProvider, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package sentryhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// ErrNoDSN is returned by operations which talk to Sentry directly when the
+// hook's client has no DSN.
+var ErrNoDSN = errors.New("sentryhook: no DSN configured")
+
+// envelopeItem is a single item of an envelope sent by the hook itself, for
+// payloads the client has no API for.
+type envelopeItem struct {
+	Type    string
+	Payload []byte
+	// further fields of the item header
+	Header map[string]interface{}
+}
+
+// sendEnvelope posts an envelope to the envelope endpoint of the client's
+// DSN through the client's HTTP transport, so that proxies, network
+// constraints and capability detection apply as for events.
+func (hook *SentryHook) sendEnvelope(ctx context.Context, header map[string]interface{}, items ...envelopeItem) error {
+	return hook.sendEnvelopeWith(ctx, hook.currentClient(), header, items...)
+}
+
+// sendEnvelopeWith is sendEnvelope for a known client, for callers which
+// must not take hook.mu, like the queue worker while Flush waits for it.
+func (hook *SentryHook) sendEnvelopeWith(ctx context.Context, client *sentrygo.Client, header map[string]interface{}, items ...envelopeItem) error {
+	if hook.dryRun != nil {
+		return hook.writeDryRunItems(items)
+	}
+	if client == nil {
+		return ErrNoDSN
+	}
+	if !hook.supportsEnvelopes() {
+		return ErrUnsupported
+	}
+	options := client.Options()
+	if options.Dsn == "" {
+		return ErrNoDSN
+	}
+	dsn, err := sentrygo.NewDsn(options.Dsn)
+	if err != nil {
+		return err
+	}
+
+	if header == nil {
+		header = make(map[string]interface{})
+	}
+	header["dsn"] = dsn.String()
+	header["sent_at"] = hook.now().UTC().Format(time.RFC3339Nano)
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(header); err != nil {
+		return err
+	}
+	for _, item := range items {
+		fields := map[string]interface{}{
+			"type":   item.Type,
+			"length": len(item.Payload),
+		}
+		for k, v := range item.Header {
+			fields[k] = v
+		}
+		itemHeader, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		body.Write(itemHeader)
+		body.WriteByte('\n')
+		body.Write(item.Payload)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, dsn.EnvelopeAPIURL().String(), &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range dsn.RequestHeaders() {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		transport := options.HTTPTransport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		httpClient = &http.Client{Transport: transport}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentryhook: envelope rejected with status %s", resp.Status)
+	}
+	return nil
+}
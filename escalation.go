@@ -0,0 +1,112 @@
+package sentryhook
+
+import (
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// EscalationRule sends an additional, escalated event once the same
+// fingerprint fired Threshold times within a sliding Window, e.g. a single
+// Fatal event after 50 occurrences in 5 minutes. The escalated event is a
+// copy of the event crossing the threshold, tagged "escalated" and
+// "escalation_rule"; the events themselves are sent as usual.
+type EscalationRule struct {
+	// identifies the rule in the escalation_rule tag
+	Name      string
+	Threshold int
+	Window    time.Duration
+	// the level of the escalated event, LevelFatal if empty
+	Level sentrygo.Level
+	// restricts the rule to matching events, all events if nil
+	Match func(event *sentrygo.Event) bool
+}
+
+// WithEscalation evaluates the rules for every event. Occurrences are
+// counted before sampling and load shedding, so dropped events count as
+// well. Windows are measured on the monotonic clock, see WithTimeSource,
+// so stepping the wall clock or logging entries with other times does not
+// stretch or shrink them. After escalating, a fingerprint has to reach the
+// threshold again before the rule escalates it again.
+func WithEscalation(rules ...EscalationRule) Option {
+	return func(hook *SentryHook) {
+		for _, rule := range rules {
+			hook.escalations = append(hook.escalations, &escalation{
+				rule:  rule,
+				times: make(map[string][]time.Time),
+			})
+		}
+	}
+}
+
+// escalation holds the sliding windows of a rule, per fingerprint.
+type escalation struct {
+	rule EscalationRule
+
+	mu    sync.Mutex
+	times map[string][]time.Time
+	swept time.Time
+}
+
+// record counts the event and reports whether it crosses the threshold.
+func (e *escalation) record(event *sentrygo.Event, now time.Time) (int, bool) {
+	if e.rule.Threshold <= 0 || (e.rule.Match != nil && !e.rule.Match(event)) {
+		return 0, false
+	}
+	key := dedupKey(event)
+	since := now.Add(-e.rule.Window)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// forget fingerprints which did not fire within the window; a clock
+	// stepped back, possible only with a time source without monotonic
+	// readings, starts the windows over
+	if elapsed := now.Sub(e.swept); elapsed > e.rule.Window || elapsed < 0 {
+		for k, times := range e.times {
+			if !times[len(times)-1].After(since) {
+				delete(e.times, k)
+			}
+		}
+		e.swept = now
+	}
+	times := e.times[key]
+	if len(times) > 0 && times[len(times)-1].After(now) {
+		times = nil
+	}
+	for len(times) > 0 && !times[0].After(since) {
+		times = times[1:]
+	}
+	times = append(times, now)
+	if len(times) < e.rule.Threshold {
+		e.times[key] = times
+		return 0, false
+	}
+	delete(e.times, key)
+	return len(times), true
+}
+
+// escalate sends an escalated copy of the event for every rule whose
+// threshold it crosses.
+func (hook *SentryHook) escalate(event *sentrygo.Event) {
+	for _, e := range hook.escalations {
+		count, crossed := e.record(event, hook.now())
+		if !crossed {
+			continue
+		}
+		escalated := cloneEvent(event)
+		escalated.EventID = hook.deterministicEventID()
+		escalated.Level = e.rule.Level
+		if escalated.Level == "" {
+			escalated.Level = sentrygo.LevelFatal
+		}
+		escalated.Tags["escalated"] = "true"
+		escalated.Tags["escalation_rule"] = e.rule.Name
+		escalated.Extra["escalation_count"] = count
+		escalated.Extra["escalation_window"] = e.rule.Window.String()
+		if len(escalated.Fingerprint) == 0 {
+			// keep escalations apart from the issue of the events
+			escalated.Fingerprint = []string{defaultFingerprint, "escalated", e.rule.Name}
+		}
+		_ = hook.dispatch(escalated, nil)
+	}
+}
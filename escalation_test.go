@@ -0,0 +1,69 @@
+package sentryhook
+
+import (
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEscalation(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	clock := newFakeClock()
+	start := clock.Now()
+	hook, err := NewSentryHook(server.DSN(), WithTimeSource(clock.Now), WithEscalation(EscalationRule{
+		Name:      "storm",
+		Threshold: 3,
+		Window:    5 * time.Minute,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	at := func(offset time.Duration) *logrus.Entry {
+		clock.Set(start.Add(offset))
+		return logrus.NewEntry(log)
+	}
+	at(0).Error("connection refused")
+	at(4 * time.Minute).Error("connection refused")
+	// the first occurrence has left the window
+	at(6 * time.Minute).Error("connection refused")
+	if escalated(server.Events()) != 0 {
+		t.Fatal("expected no escalation outside the window")
+	}
+	at(7 * time.Minute).Error("connection refused")
+	if n := escalated(server.Events()); n != 1 {
+		t.Fatalf("expected 1 escalation, got %d", n)
+	}
+	// counting starts over after escalating
+	at(8 * time.Minute).Error("connection refused")
+	if n := escalated(server.Events()); n != 1 {
+		t.Fatalf("expected no further escalation, got %d", n)
+	}
+
+	for _, event := range server.Events() {
+		if event.Tags["escalated"] != "true" {
+			continue
+		}
+		if event.Level != sentrygo.LevelFatal || event.Tags["escalation_rule"] != "storm" || event.Extra["escalation_count"] != 3.0 {
+			t.Fatalf("unexpected escalated event %+v", event)
+		}
+	}
+	if n := len(server.Events()); n != 6 {
+		t.Fatalf("expected every error to be sent as well, got %d events", n)
+	}
+}
+
+func escalated(events []*sentrygo.Event) int {
+	n := 0
+	for _, event := range events {
+		if event.Tags["escalated"] == "true" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,259 @@
+package sentryhook
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// maxErrorDepth limits how many errors of a chain are turned into exceptions.
+const maxErrorDepth = 32
+
+// maxSummarizedErrors limits how many messages a summarized aggregate lists.
+const maxSummarizedErrors = 10
+
+// AggregateStrategy controls how errors aggregating several errors, like
+// the results of errors.Join, hashicorp/go-multierror or uber-go/multierr,
+// are turned into exceptions.
+type AggregateStrategy int
+
+const (
+	// AggregateAll emits one exception per aggregated error.
+	AggregateAll AggregateStrategy = iota
+	// AggregateFirst only follows the first aggregated error.
+	AggregateFirst
+	// AggregateSummarized emits a single exception whose value lists the
+	// number of aggregated errors and their messages.
+	AggregateSummarized
+)
+
+// WithAggregateStrategy sets how aggregated errors are reported, see
+// AggregateStrategy.
+func WithAggregateStrategy(strategy AggregateStrategy) Option {
+	return func(hook *SentryHook) {
+		hook.aggregateStrategy = strategy
+	}
+}
+
+type wrapper interface {
+	Unwrap() error
+}
+
+// multiWrapper is implemented by errors.Join and uber-go/multierr.
+type multiWrapper interface {
+	Unwrap() []error
+}
+
+// wrappedErrorser is implemented by hashicorp/go-multierror.
+type wrappedErrorser interface {
+	WrappedErrors() []error
+}
+
+// errorser is implemented by older versions of uber-go/multierr.
+type errorser interface {
+	Errors() []error
+}
+
+// aggregatedErrors returns the errors aggregated by err, or nil if err is
+// not an aggregate.
+func aggregatedErrors(err error) []error {
+	switch e := err.(type) {
+	case multiWrapper:
+		return e.Unwrap()
+	case wrappedErrorser:
+		return e.WrappedErrors()
+	case errorser:
+		return e.Errors()
+	}
+	return nil
+}
+
+// entryError returns the error logged with WithError, if any. Nil and
+// typed-nil errors are not returned, see nilError.
+func entryError(entry *logrus.Entry) error {
+	err, _ := entry.Data[logrus.ErrorKey].(error)
+	if isNil(err) {
+		return nil
+	}
+	return err
+}
+
+// NilErrorMode sets how entries logged with a nil error are handled, e.g.
+// WithError(err) where err is nil or a typed nil like (*MyError)(nil).
+type NilErrorMode int
+
+const (
+	// NilErrorSkip builds the event as if no error was logged.
+	NilErrorSkip NilErrorMode = iota
+	// NilErrorTag builds the event as if no error was logged and tags it
+	// "nil_error".
+	NilErrorTag
+	// NilErrorDrop drops the event.
+	NilErrorDrop
+)
+
+// nilErrorTag marks events logged with a nil error.
+const nilErrorTag = "nil_error"
+
+// WithNilErrorMode sets how entries logged with a nil or typed-nil error
+// are handled. It defaults to NilErrorSkip, so they never produce
+// exceptions with a "nil" value.
+func WithNilErrorMode(mode NilErrorMode) Option {
+	return func(hook *SentryHook) {
+		hook.nilErrorMode = mode
+	}
+}
+
+// nilError reports whether the entry was logged with a nil or typed-nil
+// error.
+func nilError(entry *logrus.Entry) bool {
+	v, ok := entry.Data[logrus.ErrorKey]
+	if !ok {
+		return false
+	}
+	if v == nil {
+		return true
+	}
+	err, ok := v.(error)
+	return ok && isNil(err)
+}
+
+// isNil reports whether err is nil or a typed nil.
+func isNil(err error) bool {
+	if err == nil {
+		return true
+	}
+	v := reflect.ValueOf(err)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// errorBreadcrumb renders the full error chain with %+v, which includes the
+// stacks recorded by pkg/errors, so it survives even if Sentry collapses
+// the exceptions. It is added for StackTraceConfiguration's
+// IncludeErrorBreadcrumb.
+func (hook *SentryHook) errorBreadcrumb(err error, entry *logrus.Entry) *sentrygo.Breadcrumb {
+	return &sentrygo.Breadcrumb{
+		Type:      "error",
+		Category:  "error",
+		Message:   fmt.Sprintf("%+v", err),
+		Level:     hook.severity(entry.Level),
+		Timestamp: entry.Time,
+	}
+}
+
+// exceptions converts an error and everything it wraps into sentry
+// exceptions, ordered cause first as sentry expects. Each exception carries
+// the stacktrace of its own error if it has one.
+func (hook *SentryHook) exceptions(err error) []sentrygo.Exception {
+	chain := hook.appendExceptions(nil, err)
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// appendExceptions appends err and the errors it wraps, outermost first.
+// Typed-nil errors end the chain and members of aggregates which are nil
+// are left out.
+func (hook *SentryHook) appendExceptions(chain []sentrygo.Exception, err error) []sentrygo.Exception {
+	var previous string
+	for !isNil(err) && len(chain) < maxErrorDepth {
+		message := err.Error()
+		errs := aggregatedErrors(err)
+		if len(errs) == 0 {
+			// an empty aggregate is reported as a plain error
+			errs = nil
+		}
+		exception := hook.exception(err)
+		if errs != nil && hook.aggregateStrategy == AggregateSummarized {
+			exception.Value = summarizeErrors(errs)
+			return append(chain, exception)
+		}
+		// Wrappers which only add a stack, like pkg/errors' withStack, repeat
+		// the message of the error they wrap; merge them into one exception.
+		// The messages are compared as the exception's fields may be
+		// switched, see SwitchExceptionTypeAndMessage.
+		if n := len(chain); n > 0 && previous != "" && previous == message {
+			if chain[n-1].Stacktrace == nil {
+				chain[n-1].Stacktrace = exception.Stacktrace
+			}
+		} else {
+			chain = append(chain, exception)
+		}
+		previous = message
+
+		if errs != nil {
+			if hook.aggregateStrategy == AggregateFirst && len(errs) > 1 {
+				errs = errs[:1]
+			}
+			for _, e := range errs {
+				chain = hook.appendExceptions(chain, e)
+			}
+			return chain
+		}
+		err = unwrap(err)
+	}
+	return chain
+}
+
+// summarizeErrors describes aggregated errors in a single line.
+func summarizeErrors(errs []error) string {
+	messages := make([]string, 0, len(errs))
+	for i, err := range errs {
+		if i == maxSummarizedErrors {
+			messages = append(messages, fmt.Sprintf("and %d more", len(errs)-i))
+			break
+		}
+		if !isNil(err) {
+			messages = append(messages, err.Error())
+		}
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(errs), strings.Join(messages, "; "))
+}
+
+func (hook *SentryHook) exception(err error) sentrygo.Exception {
+	exception := sentrygo.Exception{
+		Value:      err.Error(),
+		Stacktrace: hook.errorStacktrace(err),
+	}
+	if hook.StacktraceConfiguration.SendExceptionType {
+		exception.Type = reflect.TypeOf(err).String()
+	}
+	if hook.StacktraceConfiguration.SwitchExceptionTypeAndMessage {
+		exception.Type, exception.Value = exception.Value, exception.Type
+	}
+	return exception
+}
+
+// errorStacktrace returns the stacktrace carried by err itself, without
+// looking at the errors it wraps.
+func (hook *SentryHook) errorStacktrace(err error) *sentrygo.Stacktrace {
+	if tracer, ok := err.(Stacktracer); ok {
+		return tracer.GetStacktrace()
+	}
+	for _, extract := range stackExtractors {
+		if pcs := extract(err); pcs != nil {
+			return hook.convertStackTrace(pcs)
+		}
+	}
+	return nil
+}
+
+// unwrap returns the error wrapped by err, supporting both the standard
+// library Unwrap method and pkg/errors' Cause.
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case wrapper:
+		return e.Unwrap()
+	case causer:
+		return e.Cause()
+	}
+	return nil
+}
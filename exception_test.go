@@ -0,0 +1,141 @@
+package sentryhook
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type testMultiError []error
+
+func (m testMultiError) Error() string {
+	return fmt.Sprintf("%d errors", len(m))
+}
+
+func (m testMultiError) WrappedErrors() []error {
+	return m
+}
+
+func TestAggregateStrategies(t *testing.T) {
+	multi := testMultiError{errors.New("disk full"), errors.New("quota exceeded")}
+
+	cases := []struct {
+		strategy AggregateStrategy
+		values   []string
+	}{
+		{AggregateAll, []string{"quota exceeded", "disk full", "2 errors"}},
+		{AggregateFirst, []string{"disk full", "2 errors"}},
+		{AggregateSummarized, []string{"2 errors occurred: disk full; quota exceeded"}},
+	}
+	for _, c := range cases {
+		hook, err := NewSentryHook("", WithAggregateStrategy(c.strategy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain := hook.exceptions(multi)
+		if len(chain) != len(c.values) {
+			t.Fatalf("strategy %d: expected %d exceptions, got %+v", c.strategy, len(c.values), chain)
+		}
+		for i, value := range c.values {
+			if chain[i].Value != value {
+				t.Errorf("strategy %d: exception %d: expected %q, got %q", c.strategy, i, value, chain[i].Value)
+			}
+		}
+
+		// an empty aggregate is a plain error
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		log.WithError(testMultiError{}).Error("nothing failed")
+		if chain := hook.exceptions(testMultiError{}); len(chain) != 1 || chain[0].Value != "0 errors" {
+			t.Errorf("strategy %d: expected the empty aggregate as a single exception, got %+v", c.strategy, chain)
+		}
+	}
+}
+
+type nilableError struct{}
+
+func (e *nilableError) Error() string {
+	return "nilable"
+}
+
+// fieldError dereferences its receiver, so a typed nil panics in Error.
+type fieldError struct {
+	msg string
+}
+
+func (e *fieldError) Error() string {
+	return e.msg
+}
+
+func TestWrappedTypedNilErrors(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	var typedNil *fieldError
+	log.WithError(fmt.Errorf("loading config: %w", typedNil)).Error("wrapped")
+	log.WithError(testMultiError{typedNil, nil, errors.New("disk full")}).Error("aggregated")
+	if err := hook.CapturePanic(typedNil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if chain := events[0].Exception; len(chain) != 1 || chain[0].Value != "loading config: <nil>" {
+		t.Fatalf("expected the typed nil left out of the chain, got %+v", chain)
+	}
+	if chain := events[1].Exception; len(chain) != 2 || chain[0].Value != "disk full" {
+		t.Fatalf("expected the nil members of the aggregate left out, got %+v", chain)
+	}
+	if chain := events[2].Exception; len(chain) != 1 || chain[0].Stacktrace == nil {
+		t.Fatalf("expected the panic reported with its stack, got %+v", chain)
+	}
+}
+
+func TestNilErrorMode(t *testing.T) {
+	var typedNil *nilableError
+	for _, tc := range []struct {
+		mode   NilErrorMode
+		events int
+		tagged bool
+	}{
+		{NilErrorSkip, 2, false},
+		{NilErrorTag, 2, true},
+		{NilErrorDrop, 0, false},
+	} {
+		server := NewMockServer()
+		hook, err := NewSentryHook(server.DSN(), WithNilErrorMode(tc.mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		log.WithError(nil).Error("untyped")
+		log.WithError(typedNil).Error("typed")
+
+		events := server.Events()
+		server.Close()
+		if len(events) != tc.events {
+			t.Fatalf("mode %d: expected %d events, got %d", tc.mode, tc.events, len(events))
+		}
+		for _, event := range events {
+			for _, exception := range event.Exception {
+				if exception.Value != "" {
+					t.Fatalf("mode %d: expected no error exception, got %+v", tc.mode, exception)
+				}
+			}
+			if tagged := event.Tags[nilErrorTag] == "true"; tagged != tc.tagged {
+				t.Fatalf("mode %d: unexpected tags %v", tc.mode, event.Tags)
+			}
+		}
+	}
+}
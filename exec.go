@@ -0,0 +1,113 @@
+package sentryhook
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxStderrTail is the number of bytes of the standard error of a failed
+// command attached by CaptureExec.
+const maxStderrTail = 16 << 10
+
+// CaptureExec reports a failed command run by a tool shelling out, e.g.
+//
+//	if err := cmd.Run(); err != nil {
+//		hook.CaptureExec(cmd, err)
+//	}
+//
+// The event carries the command line in the "process" context, with the
+// values of sensitive flags, assignments and URL passwords filtered, the
+// exit code, and the tail of the standard error as the attachment
+// stderr.txt. The standard error is taken from the *exec.ExitError of
+// cmd.Output, or from cmd.Stderr if it is a *bytes.Buffer. Events are
+// grouped by program and exit code. Like logged entries, they go through
+// the rules, scrubbers, sampling and volume controls of the hook. A nil err
+// reports nothing.
+func (hook *SentryHook) CaptureExec(cmd *exec.Cmd, err error) error {
+	if err == nil || cmd == nil {
+		return nil
+	}
+	name := filepath.Base(cmd.Path)
+	if len(cmd.Args) > 0 {
+		name = filepath.Base(cmd.Args[0])
+	}
+	exitCode := -1
+	var stderr []byte
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+		stderr = exitErr.Stderr
+	} else if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+	if buf, ok := cmd.Stderr.(*bytes.Buffer); ok && len(stderr) == 0 {
+		stderr = buf.Bytes()
+	}
+
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Time = hook.now()
+	entry.Level = logrus.ErrorLevel
+	entry.Message = fmt.Sprintf("command %s failed: %v", name, err)
+	entry.Data = logrus.Fields{logrus.ErrorKey: err}
+	if len(stderr) > 0 {
+		if len(stderr) > maxStderrTail {
+			stderr = stderr[len(stderr)-maxStderrTail:]
+		}
+		entry.Data[AttachmentsField] = Attachment{Filename: "stderr.txt", ContentType: "text/plain", Payload: stderr}
+	}
+	event := hook.buildEvent(entry)
+	if event == nil {
+		return nil
+	}
+	args := hook.scrubArgs(cmd.Args)
+	process := map[string]interface{}{
+		"command":   strings.Join(args, " "),
+		"args":      args,
+		"exit_code": exitCode,
+	}
+	if cmd.Dir != "" {
+		process["dir"] = cmd.Dir
+	}
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]interface{})
+	}
+	event.Contexts["process"] = process
+	event.Tags["process.name"] = name
+	event.Tags["process.exit_code"] = strconv.Itoa(exitCode)
+	event.Fingerprint = []string{"exec", name, strconv.Itoa(exitCode)}
+	return hook.handle(event, entry)
+}
+
+// scrubArgs returns a copy of command line arguments with the values of
+// sensitive flags, e.g. "--password=x" or "--token x", assignments like
+// "API_KEY=x" and the passwords of URLs filtered.
+func (hook *SentryHook) scrubArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if eq := strings.Index(arg, "="); eq > 0 && hook.sensitive(arg[:eq]) {
+			scrubbed[i] = arg[:eq+1] + filtered
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && hook.sensitive(arg) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			scrubbed[i] = arg
+			i++
+			scrubbed[i] = filtered
+			continue
+		}
+		if u, err := url.Parse(arg); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), filtered)
+				arg = u.String()
+			}
+		}
+		scrubbed[i] = arg
+	}
+	return scrubbed
+}
@@ -0,0 +1,101 @@
+package sentryhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithExitHandler controls whether the hook registers a logrus exit
+// handler, which is the default. logrus runs exit handlers when Fatal is
+// logged, right before the process exits; the handler delivers queued
+// events and flushes the clients, waiting at most the flush timeout, so
+// the fatal event and everything logged before it is not lost in
+// asynchronous mode. A single handler is registered per process; it drains
+// the hooks that have not been closed yet, so closed hooks are released.
+func WithExitHandler(register bool) Option {
+	return func(hook *SentryHook) {
+		hook.noExitHandler = !register
+	}
+}
+
+// exitHooks are the hooks drained by the process' logrus exit handler.
+var exitHooks struct {
+	once  sync.Once
+	mu    sync.Mutex
+	hooks map[*SentryHook]struct{}
+}
+
+// drainAllOnExit is the logrus exit handler. The hooks are drained
+// concurrently, so the wait is bounded by the longest flush timeout.
+func drainAllOnExit() {
+	exitHooks.mu.Lock()
+	hooks := make([]*SentryHook, 0, len(exitHooks.hooks))
+	for hook := range exitHooks.hooks {
+		hooks = append(hooks, hook)
+	}
+	exitHooks.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, hook := range hooks {
+		wg.Add(1)
+		go func(hook *SentryHook) {
+			defer wg.Done()
+			hook.drainOnExit()
+		}(hook)
+	}
+	wg.Wait()
+}
+
+// drainOnExit delivers the queued events of the hook and flushes its
+// clients.
+func (hook *SentryHook) drainOnExit() {
+	deadline := time.Now().Add(hook.flushTimeout)
+	if hook.asynchronous {
+		_ = waitTimeout(context.Background(), &hook.wg, deadline)
+	}
+	hook.flushClients(time.Until(deadline))
+}
+
+// flushClients flushes the client and the clients of all destinations
+// within the timeout, reporting whether all of them were flushed.
+func (hook *SentryHook) flushClients(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	flushed := true
+	if client := hook.currentClient(); client != nil {
+		flushed = client.Flush(timeout)
+	}
+	for _, dest := range hook.destinations {
+		if dest.client != nil && !dest.client.Flush(time.Until(deadline)) {
+			flushed = false
+		}
+	}
+	// the hook's own failures don't count as events left unsent
+	if hook.selfReporter != nil {
+		hook.selfReporter.client.Flush(time.Until(deadline))
+	}
+	return flushed
+}
+
+func (hook *SentryHook) registerExitHandler() {
+	if hook.noExitHandler {
+		return
+	}
+	exitHooks.once.Do(func() {
+		logrus.RegisterExitHandler(drainAllOnExit)
+	})
+	exitHooks.mu.Lock()
+	if exitHooks.hooks == nil {
+		exitHooks.hooks = make(map[*SentryHook]struct{})
+	}
+	exitHooks.hooks[hook] = struct{}{}
+	exitHooks.mu.Unlock()
+}
+
+func (hook *SentryHook) unregisterExitHandler() {
+	exitHooks.mu.Lock()
+	delete(exitHooks.hooks, hook)
+	exitHooks.mu.Unlock()
+}
@@ -0,0 +1,53 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestExitHandlerDrainsQueue(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewAsyncSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	exited := -1
+	log.ExitFunc = func(code int) {
+		exited = code
+		if n := len(server.Events()); n != 2 {
+			t.Errorf("expected 2 events delivered before exit, got %d", n)
+		}
+	}
+	log.Error("before")
+	log.Fatal("fatal")
+	if exited != 1 {
+		t.Fatalf("expected exit code 1, got %d", exited)
+	}
+}
+
+func TestExitHandlerReleasesClosedHooks(t *testing.T) {
+	closed, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	open, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := closed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	exitHooks.mu.Lock()
+	_, hasClosed := exitHooks.hooks[closed]
+	_, hasOpen := exitHooks.hooks[open]
+	exitHooks.mu.Unlock()
+	if hasClosed || !hasOpen {
+		t.Fatalf("expected only the open hook drained on exit, closed %v open %v", hasClosed, hasOpen)
+	}
+	open.Close(context.Background())
+}
@@ -0,0 +1,281 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithExtraKeys restricts the entry fields sent as extra data to the given
+// keys. Other fields are left out of events.
+func WithExtraKeys(keys ...string) Option {
+	return func(hook *SentryHook) {
+		hook.extraAllow = keySet(keys)
+	}
+}
+
+// WithoutExtraKeys leaves the entry fields with the given keys out of the
+// extra data, e.g. internal payloads which must not leave the process.
+func WithoutExtraKeys(keys ...string) Option {
+	return func(hook *SentryHook) {
+		hook.extraDeny = keySet(keys)
+	}
+}
+
+// WithDisableExtra disables sending extra data, including the formatter
+// output. Fields promoted to tags are still sent as tags.
+func WithDisableExtra(disable bool) Option {
+	return func(hook *SentryHook) {
+		hook.disableExtra = disable
+	}
+}
+
+// extraAllowed reports whether the entry field with the key is sent as
+// extra data.
+func (hook *SentryHook) extraAllowed(key string) bool {
+	if hook.disableExtra || reservedFields[key] || hook.extraDeny[key] || key == hook.requestFields.Request {
+		return false
+	}
+	return hook.extraAllow == nil || hook.extraAllow[key]
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// ExtraLimits bounds the extra data of events. A zero field disables the
+// corresponding limit.
+type ExtraLimits struct {
+	// the maximum serialized size of a single value; larger values are
+	// replaced by their truncated JSON text
+	MaxValueBytes int
+	// the maximum serialized size of an event; extra values are dropped,
+	// largest first, until the event fits
+	MaxEventBytes int
+	// the maximum nesting of maps, slices and structs within a value
+	MaxDepth int
+}
+
+// DefaultExtraLimits returns the limits used when none are configured.
+func DefaultExtraLimits() ExtraLimits {
+	return ExtraLimits{
+		MaxValueBytes: 16 << 10,
+		MaxEventBytes: 256 << 10,
+		MaxDepth:      10,
+	}
+}
+
+// WithExtraLimits sets the limits for extra data. Regardless of the limits,
+// entry fields are converted into JSON-safe values before they are added to
+// events: cycles, channels, functions and values whose serialization fails
+// or panics are replaced by descriptive strings, errors by their message.
+func WithExtraLimits(limits ExtraLimits) Option {
+	return func(hook *SentryHook) {
+		hook.extraLimits = limits
+	}
+}
+
+const (
+	maxDepthMarker    = "<max depth exceeded>"
+	cycleMarker       = "<cycle>"
+	eventLimitMarker  = "<dropped: event size limit>"
+	truncationMarkerf = "...<truncated %d bytes>"
+)
+
+// sanitize converts a value into one which serializes to JSON within the
+// limits.
+func (limits ExtraLimits) sanitize(v interface{}) (safe interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			safe = fmt.Sprintf("<unserializable %T: panic: %v>", v, r)
+		}
+	}()
+	s := sanitizer{maxDepth: limits.MaxDepth, visited: make(map[uintptr]bool)}
+	safe = s.value(reflect.ValueOf(v), 0)
+	if limits.MaxValueBytes <= 0 {
+		return safe
+	}
+	if str, ok := safe.(string); ok {
+		return truncate(str, limits.MaxValueBytes)
+	}
+	data, err := json.Marshal(safe)
+	if err != nil {
+		return fmt.Sprintf("<unserializable %T: %v>", v, err)
+	}
+	if len(data) > limits.MaxValueBytes {
+		return truncate(string(data), limits.MaxValueBytes)
+	}
+	return safe
+}
+
+// limitEventSize drops extra values, largest first, until the serialized
+// event fits into MaxEventBytes.
+func (limits ExtraLimits) limitEventSize(event *sentrygo.Event) {
+	if limits.MaxEventBytes <= 0 || len(event.Extra) == 0 {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil || len(data) <= limits.MaxEventBytes {
+		return
+	}
+	size := len(data)
+	type sized struct {
+		key  string
+		size int
+	}
+	values := make([]sized, 0, len(event.Extra))
+	for k, v := range event.Extra {
+		data, _ := json.Marshal(v)
+		values = append(values, sized{k, len(data)})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].size != values[j].size {
+			return values[i].size > values[j].size
+		}
+		return values[i].key < values[j].key
+	})
+	for _, v := range values {
+		if size <= limits.MaxEventBytes {
+			return
+		}
+		event.Extra[v.key] = eventLimitMarker
+		size -= v.size - len(eventLimitMarker) - 2
+	}
+}
+
+// truncate shortens s to at most max bytes plus a truncation marker,
+// without splitting a UTF-8 sequence.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf(truncationMarkerf, len(s)-cut)
+}
+
+// sanitizer walks a value, converting it into maps, slices and primitives.
+type sanitizer struct {
+	maxDepth int
+	// the pointers, maps and slices on the current path, to detect cycles
+	visited map[uintptr]bool
+}
+
+func (s sanitizer) value(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case json.Marshaler:
+			data, err := x.MarshalJSON()
+			if err != nil || !json.Valid(data) {
+				return fmt.Sprintf("<unserializable %s: %v>", v.Type(), err)
+			}
+			return json.RawMessage(data)
+		case error:
+			return x.Error()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return f
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex())
+	case reflect.String:
+		return v.String()
+	case reflect.Interface:
+		return s.value(v.Elem(), depth)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		p := v.Pointer()
+		if s.visited[p] {
+			return cycleMarker
+		}
+		s.visited[p] = true
+		defer delete(s.visited, p)
+	}
+	if v.Kind() == reflect.Ptr {
+		return s.value(v.Elem(), depth)
+	}
+
+	if s.maxDepth > 0 && depth >= s.maxDepth {
+		switch v.Kind() {
+		case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct:
+			return maxDepthMarker
+		}
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			if utf8.Valid(b) {
+				return string(b)
+			}
+			return b
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = s.value(v.Index(i), depth+1)
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key())] = s.value(iter.Value(), depth+1)
+		}
+		return m
+	case reflect.Struct:
+		t := v.Type()
+		m := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := field.Name
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			m[name] = s.value(v.Field(i), depth+1)
+		}
+		return m
+	}
+	return fmt.Sprintf("<unserializable %s>", v.Type())
+}
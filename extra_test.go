@@ -0,0 +1,138 @@
+package sentryhook
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestExtraSelection(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{"all", nil, []string{"user", "payload", "trace", formattedExtraKey}},
+		{"allowlist", []Option{WithExtraKeys("user", "trace")}, []string{"user", "trace", formattedExtraKey}},
+		{"denylist", []Option{WithoutExtraKeys("payload")}, []string{"user", "trace", formattedExtraKey}},
+		{"disabled", []Option{WithDisableExtra(true)}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := NewMockServer()
+			defer server.Close()
+			opts := append([]Option{WithFormatter(&logrus.TextFormatter{})}, c.opts...)
+			hook, err := NewSentryHook(server.DSN(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			log := logrus.New()
+			log.Hooks.Add(hook)
+			log.WithFields(logrus.Fields{"user": "u1", "payload": "secret", "trace": "t1"}).Error("boom")
+
+			events := server.Events()
+			if len(events) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(events))
+			}
+			extra := events[0].Extra
+			if len(extra) != len(c.want) {
+				t.Fatalf("expected keys %v, got %v", c.want, extra)
+			}
+			for _, k := range c.want {
+				if _, ok := extra[k]; !ok {
+					t.Fatalf("expected keys %v, got %v", c.want, extra)
+				}
+			}
+		})
+	}
+}
+
+type cyclic struct {
+	Name string
+	Next *cyclic
+}
+
+type panicky struct{}
+
+func (panicky) MarshalJSON() ([]byte, error) {
+	panic("boom")
+}
+
+func TestExtraSanitization(t *testing.T) {
+	loop := &cyclic{Name: "a"}
+	loop.Next = loop
+	deep := map[string]interface{}{}
+	for i, m := 0, deep; i < 5; i++ {
+		next := map[string]interface{}{}
+		m["child"] = next
+		m = next
+	}
+
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithExtraLimits(ExtraLimits{MaxValueBytes: 64, MaxDepth: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithFields(logrus.Fields{
+		"loop":    loop,
+		"deep":    deep,
+		"big":     strings.Repeat("x", 1000),
+		"channel": make(chan int),
+		"panicky": panicky{},
+		"number":  42,
+	}).Error("boom")
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	extra := events[0].Extra
+	if next := extra["loop"].(map[string]interface{})["Next"]; next != cycleMarker {
+		t.Errorf("expected the cycle to be cut, got %v", next)
+	}
+	if !strings.Contains(fmt.Sprint(extra["deep"]), maxDepthMarker) {
+		t.Errorf("expected the nesting to be cut, got %v", extra["deep"])
+	}
+	if big := extra["big"].(string); len(big) > 100 || !strings.Contains(big, "truncated 936 bytes") {
+		t.Errorf("expected the value to be truncated, got %q", big)
+	}
+	if extra["channel"] != "<unserializable chan int>" {
+		t.Errorf("unexpected channel value %v", extra["channel"])
+	}
+	if !strings.Contains(fmt.Sprint(extra["panicky"]), "panic: boom") {
+		t.Errorf("unexpected panicking value %v", extra["panicky"])
+	}
+	if extra["number"] != float64(42) {
+		t.Errorf("unexpected number %v", extra["number"])
+	}
+}
+
+func TestEventSizeLimit(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithDisableStacktrace(true), WithExtraLimits(ExtraLimits{MaxEventBytes: 2048}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithFields(logrus.Fields{
+		"large":  strings.Repeat("a", 3000),
+		"larger": strings.Repeat("b", 4000),
+		"small":  "kept",
+	}).Error("boom")
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	extra := events[0].Extra
+	if extra["larger"] != eventLimitMarker || extra["large"] != eventLimitMarker || extra["small"] != "kept" {
+		t.Fatalf("expected the large values to be dropped, got %v", extra)
+	}
+}
@@ -0,0 +1,40 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"errors"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// userReport is the payload of a user feedback envelope item.
+type userReport struct {
+	EventID  sentrygo.EventID `json:"event_id"`
+	Name     string           `json:"name"`
+	Email    string           `json:"email"`
+	Comments string           `json:"comments"`
+}
+
+// SubmitUserFeedback attaches feedback of an end user to the event they
+// experienced, typically LastEventID() of the request that failed. It is
+// sent through the hook's client, waiting at most the flush timeout.
+func (hook *SentryHook) SubmitUserFeedback(eventID sentrygo.EventID, name, email, comments string) error {
+	if eventID == "" {
+		return errors.New("sentryhook: user feedback needs an event id")
+	}
+	payload, err := json.Marshal(userReport{
+		EventID:  eventID,
+		Name:     name,
+		Email:    email,
+		Comments: comments,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := hook.flushContext()
+	defer cancel()
+	return hook.sendEnvelope(ctx, map[string]interface{}{"event_id": eventID}, envelopeItem{
+		Type:    "user_report",
+		Payload: payload,
+	})
+}
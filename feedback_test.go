@@ -0,0 +1,47 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSubmitUserFeedback(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("checkout failed")
+
+	eventID := hook.LastEventID()
+	if err := hook.SubmitUserFeedback(eventID, "Jane", "jane@example.com", "The button did nothing"); err != nil {
+		t.Fatal(err)
+	}
+	items := server.Items()
+	if len(items) != 1 || items[0].Type != "user_report" {
+		t.Fatalf("expected a user report, got %+v", items)
+	}
+	var report userReport
+	if err := json.Unmarshal(items[0].Payload, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.EventID != eventID || report.Email != "jane@example.com" || report.Comments != "The button did nothing" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if err := hook.SubmitUserFeedback("", "Jane", "", ""); err == nil {
+		t.Fatal("expected an error without event id")
+	}
+	noDSN, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := noDSN.SubmitUserFeedback(eventID, "Jane", "", ""); err != ErrNoDSN {
+		t.Fatalf("expected ErrNoDSN, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package sentryhook
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// defaultFingerprint stands for sentry's default grouping in an event
+// fingerprint.
+const defaultFingerprint = "{{ default }}"
+
+// fingerprint returns a key identifying the events sentry groups together,
+// the base of dedupKey and of the hash identifying issues to callbacks. An
+// explicit event fingerprint wins; otherwise level, message and exception
+// types are combined. They also stand for {{ default }} in an explicit
+// fingerprint.
+func fingerprint(event *sentrygo.Event) string {
+	if len(event.Fingerprint) > 0 {
+		parts := make([]string, len(event.Fingerprint))
+		for i, part := range event.Fingerprint {
+			if part == defaultFingerprint {
+				part = defaultKey(event)
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, "\x00")
+	}
+	return defaultKey(event)
+}
+
+// defaultKey combines the level, message and exception types of the event.
+func defaultKey(event *sentrygo.Event) string {
+	var b strings.Builder
+	b.WriteString(string(event.Level))
+	b.WriteByte(0)
+	b.WriteString(event.Message)
+	for _, exception := range event.Exception {
+		b.WriteByte(0)
+		b.WriteString(exception.Type)
+	}
+	return b.String()
+}
+
+// dedupKey returns the key under which aggregation, sampling and
+// escalation count the event: its fingerprint and, for events tagged with
+// the logging goroutine, see WithGoroutineIDTag, the goroutine.
+func dedupKey(event *sentrygo.Event) string {
+	key := fingerprint(event)
+	if id, ok := event.Tags[goroutineTag]; ok {
+		key += "\x00goroutine:" + id
+	}
+	return key
+}
+
+// fingerprintHash returns a short, printable hash of the event fingerprint.
+func fingerprintHash(event *sentrygo.Event) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(fingerprint(event)))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -3,8 +3,8 @@ module github.com/ainiaa/sentryhook
 go 1.13
 
 require (
-	github.com/ainiaa/bytesconv v0.1.0
 	github.com/getsentry/sentry-go v0.8.0
 	github.com/pkg/errors v0.8.1
 	github.com/sirupsen/logrus v1.7.0
+	gopkg.in/yaml.v2 v2.4.0
 )
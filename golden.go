@@ -0,0 +1,136 @@
+package sentryhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// redacted replaces volatile values in canonical events.
+const redacted = "<redacted>"
+
+// volatileFields are the dotted paths of event fields which differ between
+// runs or machines. A "*" matches every element of an array or object.
+var volatileFields = []string{
+	"event_id",
+	"timestamp",
+	"server_name",
+	"sdk",
+	"modules",
+	"threads",
+	"contexts.runtime",
+	"contexts.device",
+	"contexts.os",
+	"contexts.perf",
+	"contexts.resources",
+	"tags.goroutine_id",
+	"extra.aggregate_first_seen",
+	"extra.aggregate_last_seen",
+	"exception.*.stacktrace.frames.*.abs_path",
+}
+
+// CanonicalJSON serializes an event, e.g. one received by a MockServer, into
+// indented JSON with sorted keys and volatile fields like the event id and
+// timestamp replaced by "<redacted>", so it can be compared against a golden
+// file. redact lists additional dotted paths to replace, e.g. "tags.host"
+// or "exception.*.value".
+func CanonicalJSON(event *sentrygo.Event, redact ...string) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	for _, path := range append(volatileFields, redact...) {
+		redactPath(v, strings.Split(path, "."))
+	}
+	// maps are encoded with sorted keys
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// redactPath replaces the values at the path below v.
+func redactPath(v interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	key, rest := path[0], path[1:]
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if key != "*" && key != k {
+				continue
+			}
+			if len(rest) == 0 {
+				v[k] = redacted
+			} else {
+				redactPath(child, rest)
+			}
+		}
+	case []interface{}:
+		if key != "*" {
+			return
+		}
+		for i, child := range v {
+			if len(rest) == 0 {
+				v[i] = redacted
+			} else {
+				redactPath(child, rest)
+			}
+		}
+	}
+}
+
+// CompareGolden compares canonical output with the golden file at path. If
+// update is true the golden file is written instead, which is meant to be
+// driven by a test flag:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//
+//	got, _ := sentryhook.CanonicalJSON(server.Events()[0])
+//	if err := sentryhook.CompareGolden("testdata/event.golden", got, *update); err != nil {
+//		t.Fatal(err)
+//	}
+func CompareGolden(path string, got []byte, update bool) error {
+	if update {
+		return ioutil.WriteFile(path, got, 0644)
+	}
+	want, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("sentryhook: golden file %s does not exist, run with update to create it", path)
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(got, want) {
+		return nil
+	}
+	gotLines := strings.Split(string(got), "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			return fmt.Errorf("sentryhook: event differs from golden file %s at line %d:\n got: %s\nwant: %s", path, i+1, g, w)
+		}
+	}
+	return fmt.Errorf("sentryhook: event differs from golden file %s", path)
+}
@@ -0,0 +1,62 @@
+package sentryhook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sentryhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	golden := filepath.Join(dir, "event.golden")
+
+	emit := func(message string) []byte {
+		server := NewMockServer()
+		defer server.Close()
+		hook, err := NewSentryHook(server.DSN(), WithDisableStacktrace(true), WithTags(map[string]string{"host": "a", "team": "x"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		log.WithTime(time.Now()).WithField("order", 7).Error(message)
+		events := server.Events()
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(events))
+		}
+		got, err := CanonicalJSON(events[0], "tags.host")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := emit("boom")
+	for _, want := range []string{`"event_id": "<redacted>"`, `"timestamp": "<redacted>"`, `"host": "<redacted>"`, `"team": "x"`} {
+		if !strings.Contains(string(got), want) {
+			t.Fatalf("expected %s in\n%s", want, got)
+		}
+	}
+	if strings.Index(string(got), `"extra"`) > strings.Index(string(got), `"level"`) {
+		t.Fatalf("expected sorted keys in\n%s", got)
+	}
+
+	if err := CompareGolden(golden, got, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := CompareGolden(golden, emit("boom"), false); err != nil {
+		t.Fatalf("expected a second run to match: %v", err)
+	}
+	if err := CompareGolden(golden, emit("bang"), false); err == nil || !strings.Contains(err.Error(), "bang") {
+		t.Fatalf("expected a mismatch naming the changed line, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package sentryhook
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineTag is the tag holding the id of the logging goroutine.
+const goroutineTag = "goroutine_id"
+
+// WithGoroutineIDTag tags every event with the id of the goroutine that
+// logged the entry. This helps to tell apart identical errors coming from
+// different goroutines when debugging concurrency bugs: they are not
+// aggregated, sampled or escalated together, while sentry still groups them
+// into one issue.
+func WithGoroutineIDTag() Option {
+	return func(hook *SentryHook) {
+		hook.goroutineIDTag = true
+	}
+}
+
+// goroutineID extracts the id of the calling goroutine from the header of
+// runtime.Stack ("goroutine 18 [running]:"). It returns 0 if the header
+// can not be parsed.
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	b := bytes.TrimPrefix(buf[:n], goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
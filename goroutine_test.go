@@ -0,0 +1,59 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestGoroutineID(t *testing.T) {
+	main := goroutineID()
+	if main == 0 {
+		t.Fatal("expected a goroutine id")
+	}
+	ch := make(chan uint64)
+	go func() { ch <- goroutineID() }()
+	if other := <-ch; other == 0 || other == main {
+		t.Fatalf("expected a distinct goroutine id, got %d and %d", main, other)
+	}
+}
+
+func TestGoroutineIDFingerprint(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithGoroutineIDTag(), WithAggregation(time.Hour, 2, logrus.ErrorLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("deadlock detected")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Error("deadlock detected")
+	}()
+	<-done
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected an event per goroutine, got %d events", len(events))
+	}
+	for _, event := range events {
+		if _, ok := event.Tags["aggregate_count"]; ok {
+			t.Fatalf("expected the events not to be aggregated, got %+v", event.Tags)
+		}
+		if len(event.Fingerprint) != 0 {
+			t.Fatalf("expected sentry's grouping left alone, got %v", event.Fingerprint)
+		}
+	}
+	if events[0].Tags[goroutineTag] == events[1].Tags[goroutineTag] {
+		t.Fatalf("expected distinct goroutines, got %v", events[0].Tags[goroutineTag])
+	}
+}
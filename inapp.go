@@ -0,0 +1,72 @@
+package sentryhook
+
+import (
+	"runtime/debug"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithAutoInAppDetection marks frames of the main module, as reported by
+// debug.ReadBuildInfo, as in-app in addition to the configured
+// InAppPrefixes. All other frames, including the standard library and
+// dependencies, are marked as not in-app so sentry collapses them.
+func WithAutoInAppDetection() Option {
+	return func(hook *SentryHook) {
+		hook.mainModule = mainModulePath()
+		hook.autoInApp = true
+	}
+}
+
+// mainModulePath returns the path of the main module of the binary.
+func mainModulePath() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return info.Main.Path
+}
+
+// markInApp applies the in-app configuration to all stacktraces of the
+// event. Without auto detection, InAppPrefixes or InAppRules sentry's own
+// heuristic is kept.
+func (hook *SentryHook) markInApp(event *sentrygo.Event) {
+	config := hook.StacktraceConfiguration
+	if !hook.autoInApp && len(config.InAppPrefixes) == 0 && len(config.InAppRules) == 0 {
+		return
+	}
+	for i := range event.Exception {
+		if st := event.Exception[i].Stacktrace; st != nil {
+			for j := range st.Frames {
+				st.Frames[j].InApp = hook.isInApp(st.Frames[j].Module)
+			}
+		}
+	}
+}
+
+// isInApp decides whether frames of the module are in-app by the longest
+// matching prefix of the configuration.
+func (hook *SentryHook) isInApp(module string) bool {
+	longest, inApp := -1, false
+	match := func(prefix string, value bool) {
+		if strings.HasPrefix(module, prefix) && len(prefix) >= longest {
+			longest, inApp = len(prefix), value
+		}
+	}
+	if hook.autoInApp {
+		if module == "main" {
+			match(module, true)
+		} else if hook.mainModule != "" &&
+			(module == hook.mainModule || strings.HasPrefix(module, hook.mainModule+"/")) {
+			match(hook.mainModule, true)
+		}
+	}
+	for _, prefix := range hook.StacktraceConfiguration.InAppPrefixes {
+		match(prefix, true)
+	}
+	// explicit rules win ties
+	for prefix, value := range hook.StacktraceConfiguration.InAppRules {
+		match(prefix, value)
+	}
+	return inApp
+}
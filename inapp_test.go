@@ -0,0 +1,59 @@
+package sentryhook
+
+import "testing"
+
+func TestIsInApp(t *testing.T) {
+	hook, err := NewSentryHook("", WithAutoInAppDetection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.mainModule = "github.com/acme/app"
+	hook.StacktraceConfiguration.InAppPrefixes = []string{"github.com/acme/lib"}
+
+	cases := map[string]bool{
+		"main":                         true,
+		"github.com/acme/app":          true,
+		"github.com/acme/app/internal": true,
+		"github.com/acme/lib/db":       true,
+		"github.com/acme/application":  false,
+		"net/http":                     false,
+		"github.com/sirupsen/logrus":   false,
+	}
+	for module, want := range cases {
+		if got := hook.isInApp(module); got != want {
+			t.Errorf("%s: expected in-app %v, got %v", module, want, got)
+		}
+	}
+}
+
+func TestInAppRules(t *testing.T) {
+	hook, err := NewSentryHook("", WithAutoInAppDetection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.mainModule = "github.com/acme/monorepo"
+	hook.StacktraceConfiguration.InAppPrefixes = []string{"github.com/acme/shared"}
+	hook.StacktraceConfiguration.InAppRules = map[string]bool{
+		"github.com/acme/monorepo/gen":               false,
+		"github.com/acme/monorepo/gen/handwritten":   true,
+		"github.com/acme/shared/third_party":         false,
+		"github.com/acme/":                           false,
+		"github.com/acme/monorepo/vendor/github.com": false,
+	}
+
+	cases := map[string]bool{
+		"github.com/acme/monorepo/api":                       true,
+		"github.com/acme/monorepo/gen/proto":                 false,
+		"github.com/acme/monorepo/gen/handwritten/codec":     true,
+		"github.com/acme/shared/log":                         true,
+		"github.com/acme/shared/third_party/yaml":            false,
+		"github.com/acme/tools":                              false,
+		"github.com/acme/monorepo/vendor/github.com/pkg/foo": false,
+		"net/http": false,
+	}
+	for module, want := range cases {
+		if got := hook.isInApp(module); got != want {
+			t.Errorf("%s: expected in-app %v, got %v", module, want, got)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// ErrInFlightLimit is reported for events dropped because the limits of
+// WithMaxEventsInFlight or WithMaxBytesInFlight are reached.
+var ErrInFlightLimit = errors.New("sentryhook: too many events in flight")
+
+// degradedTag marks events stripped to fit the in-flight byte budget.
+const degradedTag = "degraded"
+
+// WithMaxEventsInFlight bounds the number of events queued or being
+// delivered, including the copies for destinations. Events beyond it are
+// dropped, reported with ErrInFlightLimit and counted in
+// Stats.DroppedInFlight. Reports of CapturePanic are always admitted.
+func WithMaxEventsInFlight(n int) Option {
+	return func(hook *SentryHook) {
+		hook.inFlightLimits().events = n
+	}
+}
+
+// WithMaxBytesInFlight bounds the total serialized size of the events
+// queued or being delivered, so memory stays bounded when events are large.
+// An event which does not fit is degraded first: its extra data,
+// breadcrumbs, request and source context are removed and it is tagged
+// "degraded", counted in Stats.Degraded. If it still does not fit it is
+// dropped like with WithMaxEventsInFlight. Measuring the size costs an
+// extra serialization of every event.
+func WithMaxBytesInFlight(bytes int) Option {
+	return func(hook *SentryHook) {
+		hook.inFlightLimits().bytes = bytes
+	}
+}
+
+func (hook *SentryHook) inFlightLimits() *inFlight {
+	if hook.inFlight == nil {
+		hook.inFlight = &inFlight{}
+	}
+	return hook.inFlight
+}
+
+// inFlight counts the events and bytes queued or being delivered.
+type inFlight struct {
+	events int
+	bytes  int
+
+	mu    sync.Mutex
+	count int
+	size  int
+}
+
+// reserve takes room for an event of the size, reporting whether it did
+// and, if not, whether only the byte budget was exceeded.
+func (f *inFlight) reserve(size int) (ok, tooLarge bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.events > 0 && f.count >= f.events {
+		return false, false
+	}
+	if f.bytes > 0 && f.size+size > f.bytes {
+		return false, true
+	}
+	f.count++
+	f.size += size
+	return true, false
+}
+
+// admit reserves room for the event, degrading it if it is too large, and
+// returns the reserved size to release once it is delivered.
+func (hook *SentryHook) admit(event *sentrygo.Event) (int, error) {
+	f := hook.inFlight
+	if f == nil {
+		return 0, nil
+	}
+	size := f.measure(event)
+	ok, tooLarge := f.reserve(size)
+	if !ok && tooLarge && degrade(event) {
+		size = f.measure(event)
+		if ok, _ = f.reserve(size); ok {
+			hook.stats.update(func(stats *Stats) { stats.Degraded++ })
+		}
+	}
+	if !ok {
+		hook.stats.update(func(stats *Stats) { stats.DroppedInFlight++ })
+		return 0, ErrInFlightLimit
+	}
+	return size, nil
+}
+
+// releaseInFlight frees the room reserved for a delivered or dropped event.
+func (hook *SentryHook) releaseInFlight(size int) {
+	f := hook.inFlight
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.count--
+	f.size -= size
+	f.mu.Unlock()
+}
+
+// measure returns the serialized size of the event, 0 without a byte
+// budget.
+func (f *inFlight) measure(event *sentrygo.Event) int {
+	if f.bytes <= 0 {
+		return 0
+	}
+	data, _ := json.Marshal(event)
+	return len(data)
+}
+
+// degrade strips the bulky parts of the event, reporting whether there was
+// anything to strip.
+func degrade(event *sentrygo.Event) bool {
+	if event.Tags[degradedTag] != "" {
+		return false
+	}
+	event.Extra = nil
+	event.Breadcrumbs = nil
+	event.Request = nil
+	for i := range event.Exception {
+		if st := event.Exception[i].Stacktrace; st != nil {
+			for j := range st.Frames {
+				st.Frames[j].PreContext = nil
+				st.Frames[j].ContextLine = ""
+				st.Frames[j].PostContext = nil
+				st.Frames[j].Vars = nil
+			}
+		}
+	}
+	if event.Tags == nil {
+		event.Tags = make(map[string]string)
+	}
+	event.Tags[degradedTag] = "true"
+	return true
+}
+
+// deliverAdmitted is deliver within the in-flight limits.
+func (hook *SentryHook) deliverAdmitted(dest *destination, event *sentrygo.Event) error {
+	size, err := hook.admit(event)
+	if err != nil {
+		return err
+	}
+	defer hook.releaseInFlight(size)
+	return hook.deliver(dest, event)
+}
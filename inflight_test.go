@@ -0,0 +1,65 @@
+package sentryhook
+
+import (
+	"strings"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMaxEventsInFlight(t *testing.T) {
+	transport := &blockingTransport{release: make(chan struct{})}
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := NewWithClientSentryHook(client, WithAsync(true), WithMaxEventsInFlight(2), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	for i := 0; i < 3; i++ {
+		log.Error("burst")
+	}
+	select {
+	case e := <-hook.Errors():
+		if e.Err != ErrInFlightLimit {
+			t.Fatalf("expected ErrInFlightLimit, got %v", e.Err)
+		}
+	default:
+		t.Fatal("expected the third event to be rejected")
+	}
+	close(transport.release)
+	hook.Flush()
+	log.Error("admitted again")
+	hook.Flush()
+	if stats := hook.Stats(); stats.DroppedInFlight != 1 || stats.Sent != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMaxBytesInFlight(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithMaxBytesInFlight(4<<10), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithField("dump", strings.Repeat("x", 8<<10)).Error("large extra")
+	log.Error(strings.Repeat("y", 8<<10))
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Tags["degraded"] != "true" || len(events[0].Extra) != 0 {
+		t.Fatalf("expected only the degraded event, got %+v", events)
+	}
+	if stats := hook.Stats(); stats.Degraded != 1 || stats.DroppedInFlight != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if f := hook.inFlight; f.count != 0 || f.size != 0 {
+		t.Fatalf("expected all room released, got %d events and %d bytes", f.count, f.size)
+	}
+}
@@ -0,0 +1,67 @@
+package sentryhook
+
+import (
+	"sync"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// interner deduplicates strings through a bounded table. Once the table is
+// full new strings are returned unchanged.
+type interner struct {
+	mu    sync.RWMutex
+	table map[string]string
+	max   int
+}
+
+// WithInterning shares the memory of repeated tag keys and values and extra
+// data keys between events, keeping up to max distinct strings. This keeps
+// the footprint of a large asynchronous queue down when many similar events
+// are buffered, e.g. during an outage.
+func WithInterning(max int) Option {
+	return func(hook *SentryHook) {
+		if max <= 0 {
+			hook.interner = nil
+			return
+		}
+		hook.interner = &interner{table: make(map[string]string), max: max}
+	}
+}
+
+func (in *interner) intern(s string) string {
+	in.mu.RLock()
+	interned, ok := in.table[s]
+	in.mu.RUnlock()
+	if ok {
+		return interned
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if interned, ok := in.table[s]; ok {
+		return interned
+	}
+	if len(in.table) >= in.max {
+		return s
+	}
+	in.table[s] = s
+	return s
+}
+
+// internEvent replaces the tags and extra keys of the event by their
+// interned copies.
+func (hook *SentryHook) internEvent(event *sentrygo.Event) {
+	in := hook.interner
+	if in == nil {
+		return
+	}
+	tags := make(map[string]string, len(event.Tags))
+	for k, v := range event.Tags {
+		tags[in.intern(k)] = in.intern(v)
+	}
+	event.Tags = tags
+	extra := make(map[string]interface{}, len(event.Extra))
+	for k, v := range event.Extra {
+		extra[in.intern(k)] = v
+	}
+	event.Extra = extra
+}
@@ -0,0 +1,48 @@
+package sentryhook
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"unsafe"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestInterning(t *testing.T) {
+	hook, err := NewSentryHook("", WithInterning(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// events built from entries carry further tags, like the mechanism,
+	// which would compete for the table in map order
+	build := func(region string) map[string]string {
+		// build the value at runtime so every event has its own copy
+		event := sentrygo.NewEvent()
+		event.Tags = map[string]string{"region": region + strconv.Itoa(1)}
+		event.Extra = map[string]interface{}{"n": 1}
+		hook.internEvent(event)
+		return event.Tags
+	}
+
+	first, second := build("eu"), build("eu")
+	if stringData(first["region"]) != stringData(second["region"]) {
+		t.Fatal("expected repeated tag values to share memory")
+	}
+	if first["region"] != "eu1" {
+		t.Fatalf("unexpected tag value %q", first["region"])
+	}
+
+	// the table holds "region" and "eu1" plus "n"; new values are not kept
+	third, fourth := build("us"), build("us")
+	if third["region"] != "us1" || stringData(third["region"]) == stringData(fourth["region"]) {
+		t.Fatal("expected the full table to leave new strings alone")
+	}
+	if n := len(hook.interner.table); n != 3 {
+		t.Fatalf("expected the table to be bounded at 3, got %d", n)
+	}
+}
@@ -0,0 +1,54 @@
+package sentryhook
+
+import (
+	"net/url"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+const defaultWebURL = "https://sentry.io"
+
+// WithIssueLinks configures the Sentry web UI used by IssueURL. baseURL is
+// the address of the Sentry UI and defaults to https://sentry.io when empty;
+// org and project are the slugs of the organization and project the DSN
+// belongs to.
+func WithIssueLinks(baseURL, org, project string) Option {
+	return func(hook *SentryHook) {
+		if baseURL == "" {
+			baseURL = defaultWebURL
+		}
+		hook.webURL = strings.TrimRight(baseURL, "/")
+		hook.org = org
+		hook.project = project
+	}
+}
+
+// IssueURL returns a link to the given event in the Sentry UI, which can be
+// embedded in chat notifications or error pages. It returns an empty string
+// if the event id is empty or WithIssueLinks was not configured.
+func (hook *SentryHook) IssueURL(eventID sentrygo.EventID) string {
+	if eventID == "" || hook.org == "" || hook.project == "" {
+		return ""
+	}
+	return hook.webURL + "/" + url.PathEscape(hook.org) + "/" + url.PathEscape(hook.project) +
+		"/events/" + url.PathEscape(string(eventID)) + "/"
+}
+
+// LastEventID returns the id of the last event captured by the hook. With
+// concurrent logging this is not necessarily the event of the caller's own
+// log entry.
+func (hook *SentryHook) LastEventID() sentrygo.EventID {
+	hook.lastEventMu.Lock()
+	defer hook.lastEventMu.Unlock()
+	return hook.lastEventID
+}
+
+func (hook *SentryHook) setLastEventID(eventID *sentrygo.EventID) {
+	if eventID == nil {
+		return
+	}
+	hook.lastEventMu.Lock()
+	hook.lastEventID = *eventID
+	hook.lastEventMu.Unlock()
+}
@@ -0,0 +1,22 @@
+package sentryhook
+
+import "testing"
+
+func TestIssueURL(t *testing.T) {
+	hook, err := NewSentryHook("", WithIssueLinks("", "acme", "backend"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://sentry.io/acme/backend/events/abc123/"
+	if got := hook.IssueURL("abc123"); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := hook.IssueURL(""); got != "" {
+		t.Fatalf("expected empty url for empty event id, got %q", got)
+	}
+
+	hook, _ = NewSentryHook("")
+	if got := hook.IssueURL("abc123"); got != "" {
+		t.Fatalf("expected empty url without issue links, got %q", got)
+	}
+}
@@ -0,0 +1,44 @@
+package sentryhook
+
+import "fmt"
+
+// WithLazyTags adds tags whose values are computed by calling the functions
+// whenever an event is built, e.g. the number of in-flight requests or the
+// state of a feature flag. They are dynamic tags, see TagSourceDynamic.
+func WithLazyTags(tags map[string]func() string) Option {
+	return func(hook *SentryHook) {
+		hook.lazyTags = tags
+	}
+}
+
+// WithLazyExtras adds extra data whose values are computed by calling the
+// functions whenever an event is built. Entry fields with the same key take
+// precedence.
+func WithLazyExtras(extras map[string]func() interface{}) Option {
+	return func(hook *SentryHook) {
+		hook.lazyExtras = extras
+	}
+}
+
+// resolveTag calls a lazy tag function, turning a panic into the tag value
+// so that a faulty resolver never breaks logging.
+func (hook *SentryHook) resolveTag(key string, fn func() string) (value string) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("<panic: %v>", r)
+			hook.selfReport("panic", "lazy tag function panicked", nil, map[string]interface{}{"key": key, "panic": value})
+		}
+	}()
+	return fn()
+}
+
+// resolveExtra calls a lazy extra function, turning a panic into the value.
+func (hook *SentryHook) resolveExtra(key string, fn func() interface{}) (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("<panic: %v>", r)
+			hook.selfReport("panic", "lazy extra function panicked", nil, map[string]interface{}{"key": key, "panic": value})
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,47 @@
+package sentryhook
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLazyTagsAndExtras(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	inFlight := 0
+	hook, err := NewSentryHook(server.DSN(),
+		WithLazyTags(map[string]func() string{
+			"in_flight": func() string { return strconv.Itoa(inFlight) },
+			"broken":    func() string { panic("oops") },
+		}),
+		WithLazyExtras(map[string]func() interface{}{
+			"queue_depth": func() interface{} { return inFlight * 10 },
+			"user":        func() interface{} { return "lazy" },
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	inFlight = 1
+	log.Error("first")
+	inFlight = 2
+	log.WithField("user", "entry").Error("second")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Tags["in_flight"] != "1" || events[1].Tags["in_flight"] != "2" {
+		t.Fatalf("expected fresh tag values, got %v and %v", events[0].Tags, events[1].Tags)
+	}
+	if events[0].Tags["broken"] != "<panic: oops>" {
+		t.Fatalf("expected the panic to be recorded, got %v", events[0].Tags)
+	}
+	if events[1].Extra["queue_depth"] != float64(20) || events[0].Extra["user"] != "lazy" || events[1].Extra["user"] != "entry" {
+		t.Fatalf("unexpected extras %v and %v", events[0].Extra, events[1].Extra)
+	}
+}
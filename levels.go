@@ -0,0 +1,60 @@
+package sentryhook
+
+import (
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultSentryLevel is the severity of entries whose level is not mapped.
+const defaultSentryLevel = sentrygo.LevelError
+
+// WithLevelMapping overrides how logrus levels translate to Sentry levels,
+// e.g. to report warnings as info or to map custom logrus levels. Levels
+// missing from the mapping keep their default translation; the hook also
+// registers for custom levels named in the mapping.
+func WithLevelMapping(mapping map[logrus.Level]sentrygo.Level) Option {
+	return func(hook *SentryHook) {
+		severities := make(map[logrus.Level]sentrygo.Level, len(severityMap)+len(mapping))
+		for k, v := range severityMap {
+			severities[k] = v
+		}
+		for k, v := range mapping {
+			severities[k] = v
+		}
+		hook.severities = severities
+	}
+}
+
+// WithDefaultSentryLevel sets the Sentry level of entries whose level has no
+// mapping. It defaults to sentrygo.LevelError.
+func WithDefaultSentryLevel(level sentrygo.Level) Option {
+	return func(hook *SentryHook) {
+		hook.defaultSeverity = level
+	}
+}
+
+// severity returns the Sentry level for a logrus level.
+func (hook *SentryHook) severity(level logrus.Level) sentrygo.Level {
+	severities := hook.severities
+	if severities == nil {
+		severities = severityMap
+	}
+	if s, ok := severities[level]; ok && s != "" {
+		return s
+	}
+	if hook.defaultSeverity != "" {
+		return hook.defaultSeverity
+	}
+	return defaultSentryLevel
+}
+
+// customLevels returns the mapped levels logrus does not define.
+func (hook *SentryHook) customLevels() []logrus.Level {
+	var levels []logrus.Level
+	for level := range hook.severities {
+		if level > logrus.TraceLevel {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
@@ -0,0 +1,50 @@
+package sentryhook
+
+import (
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLevelMapping(t *testing.T) {
+	const auditLevel = logrus.Level(10)
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(),
+		WithLevels([]logrus.Level{logrus.WarnLevel, logrus.ErrorLevel, auditLevel, logrus.Level(11)}),
+		WithLevelMapping(map[logrus.Level]sentrygo.Level{
+			logrus.WarnLevel: sentrygo.LevelInfo,
+			auditLevel:       sentrygo.LevelWarning,
+		}),
+		WithDefaultSentryLevel(sentrygo.LevelDebug),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.SetLevel(auditLevel)
+	log.Hooks.Add(hook)
+	log.Warn("warn")
+	log.Error("error")
+	log.Log(auditLevel, "audit")
+	if err := hook.Fire(&logrus.Entry{Logger: log, Level: logrus.Level(11), Message: "unmapped"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]sentrygo.Level{
+		"warn":     sentrygo.LevelInfo,
+		"error":    sentrygo.LevelError,
+		"audit":    sentrygo.LevelWarning,
+		"unmapped": sentrygo.LevelDebug,
+	}
+	events := server.Events()
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+	for _, event := range events {
+		if event.Level != want[event.Message] {
+			t.Errorf("%s: expected level %q, got %q", event.Message, want[event.Message], event.Level)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sentryhook
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LoadShedding configures how the hook reduces its own cost while the
+// process is under resource pressure.
+type LoadShedding struct {
+	// the fraction of the memory limit (GOMEMLIMIT, Go 1.19+) in use above
+	// which the process is under pressure; zero uses 0.9
+	MemoryThreshold float64
+	// the number of goroutines above which the process is under pressure;
+	// zero disables the check
+	MaxGoroutines int
+	// the rate at which events are sampled under pressure; zero drops all
+	SampleRate float64
+	// how often the pressure is measured; zero uses one second
+	Interval time.Duration
+}
+
+// WithLoadShedding samples events at cfg.SampleRate and skips capturing
+// stacktraces at the logging call site while the process is under memory or
+// goroutine pressure, tagging the events sent meanwhile "load_shedding".
+// The pressure is measured at most once per interval while logging; the
+// hook relaxes again once both measures fall below 90% of their thresholds.
+// Events dropped are counted in Stats.Shed.
+func WithLoadShedding(cfg LoadShedding) Option {
+	return func(hook *SentryHook) {
+		if cfg.MemoryThreshold <= 0 {
+			cfg.MemoryThreshold = 0.9
+		}
+		if cfg.Interval <= 0 {
+			cfg.Interval = time.Second
+		}
+		hook.shedder = &loadShedder{cfg: cfg, measure: measurePressure}
+	}
+}
+
+// loadShedding marks events sent under pressure.
+const loadSheddingTag = "load_shedding"
+
+type loadShedder struct {
+	cfg LoadShedding
+	// returns the fraction of the memory limit in use and the number of
+	// goroutines
+	measure func() (float64, int)
+
+	mu       sync.Mutex
+	measured time.Time
+	pressure bool
+}
+
+// underPressure reports whether the process is under pressure, measuring
+// it if the last measurement is older than the interval.
+func (s *loadShedder) underPressure(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// also measure if the clock was stepped back
+	if elapsed := now.Sub(s.measured); elapsed < s.cfg.Interval && elapsed >= 0 {
+		return s.pressure
+	}
+	s.measured = now
+	memory, goroutines := s.measure()
+	threshold := 1.0
+	if s.pressure {
+		// hysteresis, so the hook doesn't flap around the thresholds
+		threshold = 0.9
+	}
+	s.pressure = memory > s.cfg.MemoryThreshold*threshold ||
+		(s.cfg.MaxGoroutines > 0 && float64(goroutines) > float64(s.cfg.MaxGoroutines)*threshold)
+	return s.pressure
+}
+
+// shedding reports whether the hook is shedding load.
+func (hook *SentryHook) shedding() bool {
+	return hook.shedder != nil && hook.shedder.underPressure(hook.now())
+}
+
+// shed reports whether the event is dropped under pressure.
+func (hook *SentryHook) shed() bool {
+	if !hook.shedding() || hook.random() < hook.shedder.cfg.SampleRate {
+		return false
+	}
+	hook.stats.update(func(stats *Stats) { stats.Shed++ })
+	return true
+}
+
+func measurePressure() (float64, int) {
+	return memoryPressure(), runtime.NumGoroutine()
+}
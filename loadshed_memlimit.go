@@ -0,0 +1,31 @@
+//go:build go1.19
+// +build go1.19
+
+package sentryhook
+
+import (
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+)
+
+// memoryPressure returns the fraction of the memory limit in use, or zero
+// without a limit.
+func memoryPressure() float64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	for _, sample := range samples {
+		if sample.Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+	}
+	used := samples[0].Value.Uint64() - samples[1].Value.Uint64()
+	return float64(used) / float64(limit)
+}
@@ -0,0 +1,9 @@
+//go:build !go1.19
+// +build !go1.19
+
+package sentryhook
+
+// memoryPressure returns zero; memory limits were added in Go 1.19.
+func memoryPressure() float64 {
+	return 0
+}
@@ -0,0 +1,66 @@
+package sentryhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoadShedding(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	hook, err := NewSentryHook(server.DSN(),
+		WithTimeSource(func() time.Time { return now }),
+		WithLoadShedding(LoadShedding{MaxGoroutines: 100, Interval: time.Second}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goroutines := 150
+	hook.shedder.measure = func() (float64, int) { return 0, goroutines }
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("under pressure")
+	// still above 90% of the threshold
+	goroutines = 95
+	now = now.Add(time.Second)
+	log.Error("still under pressure")
+	goroutines = 80
+	log.Error("not measured again yet")
+	now = now.Add(time.Second)
+	log.Error("relaxed")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Message != "relaxed" {
+		t.Fatalf("expected only the event after relaxing, got %+v", events)
+	}
+	if events[0].Tags[loadSheddingTag] != "" || len(events[0].Exception) == 0 {
+		t.Fatalf("expected a regular event with a stacktrace, got %+v", events[0])
+	}
+	if shed := hook.Stats().Shed; shed != 3 {
+		t.Fatalf("expected 3 shed events, got %d", shed)
+	}
+}
+
+func TestLoadSheddingSampleRate(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithLoadShedding(LoadShedding{MemoryThreshold: 0.5, SampleRate: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.shedder.measure = func() (float64, int) { return 0.8, 0 }
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("sampled in")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Tags[loadSheddingTag] != "true" {
+		t.Fatalf("expected a tagged event, got %+v", events)
+	}
+	if len(events[0].Exception) != 0 {
+		t.Fatalf("expected no stacktrace under pressure, got %+v", events[0].Exception)
+	}
+}
@@ -0,0 +1,192 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultLogBatch is the number of log items sent in a single envelope.
+const defaultLogBatch = 100
+
+// logSeverities are the level names and severity numbers of Sentry logs.
+var logSeverities = map[logrus.Level]struct {
+	name   string
+	number int
+}{
+	logrus.TraceLevel: {"trace", 1},
+	logrus.DebugLevel: {"debug", 5},
+	logrus.InfoLevel:  {"info", 9},
+	logrus.WarnLevel:  {"warn", 13},
+	logrus.ErrorLevel: {"error", 17},
+	logrus.FatalLevel: {"fatal", 21},
+	logrus.PanicLevel: {"fatal", 24},
+}
+
+// WithLogItems sends entries of the given levels to Sentry's structured
+// logs instead of as events, so a single hook feeds both products, e.g.
+// Info and Warn as logs while Error and above remain events. Log items are
+// batched and sent every interval, when a batch is full and on Close; the
+// entry fields sent as extra data become attributes of the log.
+func WithLogItems(interval time.Duration, levels ...logrus.Level) Option {
+	return func(hook *SentryHook) {
+		l := &logBatcher{
+			interval: interval,
+			levels:   make(map[logrus.Level]bool, len(levels)),
+		}
+		for _, level := range levels {
+			l.levels[level] = true
+		}
+		hook.logs = l
+	}
+}
+
+// logItem is a single Sentry log.
+type logItem struct {
+	Timestamp      float64                 `json:"timestamp"`
+	TraceID        string                  `json:"trace_id"`
+	Level          string                  `json:"level"`
+	SeverityNumber int                     `json:"severity_number"`
+	Body           string                  `json:"body"`
+	Attributes     map[string]logAttribute `json:"attributes,omitempty"`
+}
+
+// logAttribute is a typed attribute of a log.
+type logAttribute struct {
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"`
+}
+
+// logBatcher collects log items and sends them in batches.
+type logBatcher struct {
+	interval time.Duration
+	levels   map[logrus.Level]bool
+	traceID  string
+
+	mu    sync.Mutex
+	items []logItem
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// handles reports whether entries of the level are sent as log items.
+func (l *logBatcher) handles(level logrus.Level) bool {
+	return l != nil && l.levels[level]
+}
+
+// start launches the goroutine sending the batches every interval.
+func (l *logBatcher) start(hook *SentryHook) {
+	l.traceID = hook.newID()
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go func() {
+		defer close(l.done)
+		if l.interval <= 0 {
+			<-l.stop
+			l.flush(hook)
+			return
+		}
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.flush(hook)
+			case <-l.stop:
+				l.flush(hook)
+				return
+			}
+		}
+	}()
+}
+
+// close sends the pending log items and stops the batcher.
+func (l *logBatcher) close() {
+	close(l.stop)
+	<-l.done
+}
+
+// add converts the entry into a log item, sending the batch once it is
+// full. Entries logged after Close are dropped.
+func (l *logBatcher) add(hook *SentryHook, entry *logrus.Entry) {
+	select {
+	case <-l.stop:
+		return
+	default:
+	}
+	severity := logSeverities[entry.Level]
+	item := logItem{
+		Timestamp:      float64(entry.Time.UnixNano()) / 1e9,
+		TraceID:        l.traceID,
+		Level:          severity.name,
+		SeverityNumber: severity.number,
+		Body:           entry.Message,
+		Attributes:     make(map[string]logAttribute, len(entry.Data)+2),
+	}
+	for k, v := range entry.Data {
+		if hook.extraAllowed(k) {
+			item.Attributes[k] = logAttributeOf(hook.extraLimits.sanitize(v))
+		}
+	}
+	if hook.release != "" {
+		item.Attributes["sentry.release"] = logAttribute{Value: hook.release, Type: "string"}
+	}
+	if client := hook.currentClient(); client != nil && client.Options().Environment != "" {
+		item.Attributes["sentry.environment"] = logAttribute{Value: client.Options().Environment, Type: "string"}
+	}
+
+	l.mu.Lock()
+	l.items = append(l.items, item)
+	full := len(l.items) >= defaultLogBatch
+	l.mu.Unlock()
+	if full {
+		l.flush(hook)
+	}
+}
+
+// flush sends the pending log items. Failures are reported on Errors, with
+// a nil Event.
+func (l *logBatcher) flush(hook *SentryHook) {
+	l.mu.Lock()
+	items := l.items
+	l.items = nil
+	l.mu.Unlock()
+	if len(items) == 0 {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{"items": items})
+	if err == nil {
+		ctx, cancel := hook.flushContext()
+		defer cancel()
+		err = hook.sendEnvelope(ctx, nil, envelopeItem{
+			Type:    "log",
+			Payload: payload,
+			Header: map[string]interface{}{
+				"item_count":   len(items),
+				"content_type": "application/vnd.sentry.items.log+json",
+			},
+		})
+	}
+	if err != nil && err != ErrNoDSN && err != ErrUnsupported {
+		hook.reportError(nil, nil, err)
+	}
+}
+
+// logAttributeOf types a sanitized value; values other than strings,
+// numbers and booleans are sent as their JSON text.
+func logAttributeOf(v interface{}) logAttribute {
+	switch x := v.(type) {
+	case string:
+		return logAttribute{Value: x, Type: "string"}
+	case bool:
+		return logAttribute{Value: x, Type: "boolean"}
+	case int64, uint64:
+		return logAttribute{Value: x, Type: "integer"}
+	case float64:
+		return logAttribute{Value: x, Type: "double"}
+	}
+	data, _ := json.Marshal(v)
+	return logAttribute{Value: string(data), Type: "string"}
+}
@@ -0,0 +1,58 @@
+package sentryhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogItems(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithRelease("api@2.0.0"),
+		WithLogItems(time.Hour, logrus.InfoLevel, logrus.WarnLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithFields(logrus.Fields{"user": 7, "cached": true}).Info("profile loaded")
+	log.Warn("slow query")
+	log.Debug("not sent")
+	log.Error("lookup failed")
+
+	if n := len(server.Events()); n != 1 {
+		t.Fatalf("expected the error as an event, got %d events", n)
+	}
+	if n := len(server.Items()); n != 0 {
+		t.Fatalf("expected log items to be batched, got %d items", n)
+	}
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	items := server.Items()
+	if len(items) != 1 || items[0].Type != "log" || items[0].Header["item_count"] != 2.0 {
+		t.Fatalf("expected a single batch of 2 logs, got %+v", items)
+	}
+	var batch struct {
+		Items []logItem `json:"items"`
+	}
+	if err := json.Unmarshal(items[0].Payload, &batch); err != nil {
+		t.Fatal(err)
+	}
+	info, warn := batch.Items[0], batch.Items[1]
+	if info.Body != "profile loaded" || info.Level != "info" || info.SeverityNumber != 9 || info.TraceID == "" {
+		t.Fatalf("unexpected log %+v", info)
+	}
+	if info.Attributes["user"].Type != "integer" || info.Attributes["cached"].Type != "boolean" ||
+		info.Attributes["sentry.release"].Value != "api@2.0.0" {
+		t.Fatalf("unexpected attributes %+v", info.Attributes)
+	}
+	if warn.Level != "warn" || warn.TraceID != info.TraceID {
+		t.Fatalf("unexpected log %+v", warn)
+	}
+}
@@ -0,0 +1,40 @@
+package sentryhook
+
+import (
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Tags describing how an error was captured, in place of the exception
+// mechanism, which the events of the SDK cannot carry.
+const (
+	// "yes" for errors the program logged and went on, "no" for fatal
+	// entries, panics and recovered panics
+	handledTag = "handled"
+	// "logrus" for logged entries, "panic" for panics reported with
+	// CapturePanic and "middleware" for panics recovered by the framework
+	// middlewares
+	mechanismTag = "mechanism"
+)
+
+// Mechanisms of captured errors.
+const (
+	mechanismLogrus     = "logrus"
+	mechanismPanic      = "panic"
+	mechanismMiddleware = "middleware"
+)
+
+// setMechanism tags the event with how it was captured.
+func setMechanism(event *sentrygo.Event, mechanism string, handled bool) {
+	event.Tags[mechanismTag] = mechanism
+	event.Tags[handledTag] = "yes"
+	if !handled {
+		event.Tags[handledTag] = "no"
+	}
+}
+
+// addMechanism tags the event of a logged entry: entries of the fatal and
+// panic levels end the program or goroutine, so they are unhandled.
+func addMechanism(event *sentrygo.Event, level logrus.Level) {
+	setMechanism(event, mechanismLogrus, level > logrus.FatalLevel)
+}
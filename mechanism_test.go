@@ -0,0 +1,41 @@
+package sentryhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMechanismTags(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithError(errors.New("timeout")).Error("retrying")
+	fatal := logrus.NewEntry(log)
+	fatal.Level = logrus.FatalLevel
+	fatal.Message = "cannot open database"
+	if err := hook.Fire(fatal); err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.CapturePanicContext(context.Background(), "nil map", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]string{{"logrus", "yes"}, {"logrus", "no"}, {"middleware", "no"}}
+	events := server.Events()
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+	for i, event := range events {
+		if event.Tags["mechanism"] != want[i][0] || event.Tags["handled"] != want[i][1] {
+			t.Errorf("%s: expected mechanism %s and handled %s, got %v", event.Message, want[i][0], want[i][1], event.Tags)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package sentryhook
+
+import "github.com/sirupsen/logrus"
+
+// MessageMode controls how the event message is built from an entry.
+type MessageMode int
+
+const (
+	// MessageModeEntry uses entry.Message as the event message. If a
+	// formatter is configured, its output is added to the event extra data
+	// under the "formatted" key.
+	MessageModeEntry MessageMode = iota
+	// MessageModeFormatted uses the formatter output as the event message.
+	// A JSONFormatter is used if no formatter is configured.
+	MessageModeFormatted
+)
+
+// formattedExtraKey is the extra data key holding the formatter output.
+const formattedExtraKey = "formatted"
+
+// MessageBuilder builds the event message, which Sentry uses as the issue
+// title, from an entry.
+type MessageBuilder interface {
+	Build(entry *logrus.Entry) string
+}
+
+// MessageBuilderFunc is an adapter to use ordinary functions as a
+// MessageBuilder.
+type MessageBuilderFunc func(entry *logrus.Entry) string
+
+// Build calls f(entry).
+func (f MessageBuilderFunc) Build(entry *logrus.Entry) string {
+	return f(entry)
+}
+
+// WithMessageBuilder sets a custom builder for the event message, e.g. to
+// prefix it with a service name or strip variable parts that break grouping.
+// The builder takes precedence over the message mode; a configured formatter
+// still adds its output to the extra data.
+func WithMessageBuilder(builder MessageBuilder) Option {
+	return func(hook *SentryHook) {
+		hook.messageBuilder = builder
+	}
+}
+
+// WithMessageMode sets how the event message is built, see MessageMode.
+func WithMessageMode(mode MessageMode) Option {
+	return func(hook *SentryHook) {
+		hook.messageMode = mode
+	}
+}
+
+// buildMessage returns the event message for the entry and, if a formatter
+// is configured in MessageModeEntry, the formatted log line.
+func (hook *SentryHook) buildMessage(entry *logrus.Entry) (message string, formatted string) {
+	formatter := hook.currentFormatter()
+	if hook.messageBuilder != nil {
+		if formatter != nil {
+			formatted = string(hook.createContent(formatter, entry))
+		}
+		return hook.messageBuilder.Build(entry), formatted
+	}
+	if hook.messageMode == MessageModeFormatted {
+		if formatter == nil {
+			formatter = &logrus.JSONFormatter{}
+		}
+		return string(hook.createContent(formatter, entry)), ""
+	}
+	if formatter != nil {
+		formatted = string(hook.createContent(formatter, entry))
+	}
+	return entry.Message, formatted
+}
+
+func (hook *SentryHook) createContent(formatter logrus.Formatter, entry *logrus.Entry) []byte {
+	msg, err := formatter.Format(entry)
+	if err != nil {
+		return []byte("")
+	}
+	return msg
+}
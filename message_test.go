@@ -0,0 +1,57 @@
+package sentryhook
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMessageModes(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+
+	log := logrus.New()
+	hook, err := NewSentryHook(server.DSN(), WithFormatter(&logrus.TextFormatter{DisableColors: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Hooks.Add(hook)
+	log.WithField("user", "42").Error("payment failed")
+
+	formattedHook, err := NewSentryHook(server.DSN(), WithMessageMode(MessageModeFormatted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.ReplaceHooks(logrus.LevelHooks{})
+	log.Hooks.Add(formattedHook)
+	log.Error("payment failed")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Message != "payment failed" {
+		t.Fatalf("expected entry message, got %q", events[0].Message)
+	}
+	formatted, _ := events[0].Extra[formattedExtraKey].(string)
+	if !strings.Contains(formatted, "user=42") {
+		t.Fatalf("expected formatted line in extra, got %q", formatted)
+	}
+	if !strings.HasPrefix(events[1].Message, "{") {
+		t.Fatalf("expected JSON formatted message, got %q", events[1].Message)
+	}
+}
+
+func TestMessageBuilder(t *testing.T) {
+	hook, err := NewSentryHook("", WithMessageBuilder(MessageBuilderFunc(func(entry *logrus.Entry) string {
+		return "billing: " + entry.Message
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, formatted := hook.buildMessage(&logrus.Entry{Message: "payment failed"})
+	if message != "billing: payment failed" || formatted != "" {
+		t.Fatalf("unexpected message %q, formatted %q", message, formatted)
+	}
+}
@@ -0,0 +1,213 @@
+package sentryhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Well-known locations of Kubernetes and cloud metadata; variables so tests
+// can point them elsewhere.
+var (
+	kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	awsMetadataURL          = "http://169.254.169.254/latest"
+	gcpMetadataURL          = "http://metadata.google.internal/computeMetadata/v1"
+	azureMetadataURL        = "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01"
+)
+
+// WithKubernetesMetadata tags events with the pod name, namespace, node and
+// container image. They are read from downward API environment variables
+// (POD_NAME, POD_NAMESPACE, NODE_NAME, CONTAINER_IMAGE and their K8S_
+// prefixed variants), falling back to the service account namespace file
+// and the hostname. Nothing is added outside of Kubernetes.
+func WithKubernetesMetadata() Option {
+	return func(hook *SentryHook) {
+		hook.addMetadata("kubernetes", "k8s.", kubernetesMetadata())
+	}
+}
+
+// WithCloudMetadata tags events with the provider, region, zone and instance
+// id of the AWS, GCP or Azure instance the process runs on. The metadata
+// services are probed concurrently once, when the hook is created, without
+// any proxy configured in the environment; the constructor waits until the
+// first of them answers, at most timeout for all their requests together.
+// Outside of a cloud, when no service answers, it therefore blocks for the
+// full timeout; keep it short, a second is plenty for link-local services.
+func WithCloudMetadata(timeout time.Duration) Option {
+	return func(hook *SentryHook) {
+		hook.addMetadata("cloud", "cloud.", cloudMetadata(timeout))
+	}
+}
+
+// addMetadata stores metadata as an event context and as prefixed tags.
+func (hook *SentryHook) addMetadata(context, tagPrefix string, metadata map[string]string) {
+	if len(metadata) == 0 {
+		return
+	}
+	if hook.metadataTags == nil {
+		hook.metadataTags = make(map[string]string)
+		hook.metadataContexts = make(map[string]interface{})
+	}
+	values := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		hook.metadataTags[tagPrefix+k] = v
+		values[k] = v
+	}
+	hook.metadataContexts[context] = values
+}
+
+func kubernetesMetadata() map[string]string {
+	metadata := make(map[string]string)
+	set := func(key string, envs ...string) {
+		for _, env := range envs {
+			if value := os.Getenv(env); value != "" {
+				metadata[key] = value
+				return
+			}
+		}
+	}
+	set("pod", "POD_NAME", "K8S_POD_NAME")
+	set("namespace", "POD_NAMESPACE", "K8S_NAMESPACE", "K8S_POD_NAMESPACE")
+	set("node", "NODE_NAME", "K8S_NODE_NAME")
+	set("image", "CONTAINER_IMAGE", "K8S_CONTAINER_IMAGE")
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" && len(metadata) == 0 {
+		return nil
+	}
+	if _, ok := metadata["namespace"]; !ok {
+		if data, err := ioutil.ReadFile(kubernetesNamespaceFile); err == nil {
+			metadata["namespace"] = strings.TrimSpace(string(data))
+		}
+	}
+	if _, ok := metadata["pod"]; !ok {
+		if hostname, err := os.Hostname(); err == nil {
+			metadata["pod"] = hostname
+		}
+	}
+	return metadata
+}
+
+// cloudMetadata probes all supported providers concurrently and returns the
+// metadata of the first one that answers.
+func cloudMetadata(timeout time.Duration) map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// stops the probes still running
+	defer cancel()
+	client := metadataClient(timeout)
+	defer client.CloseIdleConnections()
+	probes := []func(context.Context, *http.Client) map[string]string{awsMetadata, gcpMetadata, azureMetadata}
+
+	results := make(chan map[string]string, len(probes))
+	for _, probe := range probes {
+		go func(probe func(context.Context, *http.Client) map[string]string) {
+			results <- probe(ctx, client)
+		}(probe)
+	}
+	for range probes {
+		select {
+		case metadata := <-results:
+			if metadata != nil {
+				return metadata
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// metadataClient returns the client fetching metadata. It never uses a
+// proxy, as the metadata services are only reachable from the instance
+// itself and their responses, like the IMDS session token, must not leave
+// it.
+func metadataClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{Proxy: nil},
+		Timeout:   timeout,
+	}
+}
+
+// metadataGet fetches a single metadata value.
+func metadataGet(ctx context.Context, client *http.Client, method, url string, headers map[string]string) (string, bool) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return "", false
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+	return strings.TrimSpace(string(body)), true
+}
+
+func awsMetadata(ctx context.Context, client *http.Client) map[string]string {
+	// IMDSv2 requires a session token
+	token, ok := metadataGet(ctx, client, http.MethodPut, awsMetadataURL+"/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if !ok {
+		return nil
+	}
+	headers := map[string]string{"X-aws-ec2-metadata-token": token}
+	id, ok := metadataGet(ctx, client, http.MethodGet, awsMetadataURL+"/meta-data/instance-id", headers)
+	if !ok {
+		return nil
+	}
+	metadata := map[string]string{"provider": "aws", "instance_id": id}
+	if zone, ok := metadataGet(ctx, client, http.MethodGet, awsMetadataURL+"/meta-data/placement/availability-zone", headers); ok {
+		metadata["zone"] = zone
+	}
+	if region, ok := metadataGet(ctx, client, http.MethodGet, awsMetadataURL+"/meta-data/placement/region", headers); ok {
+		metadata["region"] = region
+	}
+	return metadata
+}
+
+func gcpMetadata(ctx context.Context, client *http.Client) map[string]string {
+	headers := map[string]string{"Metadata-Flavor": "Google"}
+	id, ok := metadataGet(ctx, client, http.MethodGet, gcpMetadataURL+"/instance/id", headers)
+	if !ok {
+		return nil
+	}
+	metadata := map[string]string{"provider": "gcp", "instance_id": id}
+	// the zone is returned as projects/<number>/zones/<zone>
+	if zone, ok := metadataGet(ctx, client, http.MethodGet, gcpMetadataURL+"/instance/zone", headers); ok {
+		zone = zone[strings.LastIndex(zone, "/")+1:]
+		metadata["zone"] = zone
+		if i := strings.LastIndex(zone, "-"); i > 0 {
+			metadata["region"] = zone[:i]
+		}
+	}
+	return metadata
+}
+
+func azureMetadata(ctx context.Context, client *http.Client) map[string]string {
+	body, ok := metadataGet(ctx, client, http.MethodGet, azureMetadataURL, map[string]string{"Metadata": "true"})
+	if !ok {
+		return nil
+	}
+	var compute struct {
+		VMID     string `json:"vmId"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+	}
+	if err := json.Unmarshal([]byte(body), &compute); err != nil || compute.VMID == "" {
+		return nil
+	}
+	metadata := map[string]string{"provider": "azure", "instance_id": compute.VMID, "region": compute.Location}
+	if compute.Zone != "" {
+		metadata["zone"] = compute.Zone
+	}
+	return metadata
+}
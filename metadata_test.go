@@ -0,0 +1,92 @@
+package sentryhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestKubernetesMetadata(t *testing.T) {
+	os.Setenv("POD_NAME", "api-7d9f")
+	os.Setenv("POD_NAMESPACE", "payments")
+	defer os.Unsetenv("POD_NAME")
+	defer os.Unsetenv("POD_NAMESPACE")
+
+	hook, err := NewSentryHook("", WithKubernetesMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.metadataTags["k8s.pod"] != "api-7d9f" || hook.metadataTags["k8s.namespace"] != "payments" {
+		t.Fatalf("unexpected tags %+v", hook.metadataTags)
+	}
+}
+
+func TestGCPMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/instance/id":
+			w.Write([]byte("1234"))
+		case "/instance/zone":
+			w.Write([]byte("projects/42/zones/europe-west1-b"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { gcpMetadataURL = url }(gcpMetadataURL)
+	gcpMetadataURL = server.URL
+	metadata := gcpMetadata(context.Background(), metadataClient(time.Second))
+	if metadata["instance_id"] != "1234" || metadata["zone"] != "europe-west1-b" || metadata["region"] != "europe-west1" {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+	if transport := metadataClient(time.Second).Transport.(*http.Transport); transport.Proxy != nil {
+		t.Fatal("expected metadata requests not to use a proxy")
+	}
+}
+
+func TestCloudMetadataTimeout(t *testing.T) {
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+	gcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/instance/id" {
+			w.Write([]byte("1234"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer gcp.Close()
+
+	defer func(aws, gcp, azure string) {
+		awsMetadataURL, gcpMetadataURL, azureMetadataURL = aws, gcp, azure
+	}(awsMetadataURL, gcpMetadataURL, azureMetadataURL)
+	awsMetadataURL, gcpMetadataURL, azureMetadataURL = hanging.URL, gcp.URL, hanging.URL
+
+	// the first answer wins without waiting for the other probes
+	start := time.Now()
+	if metadata := cloudMetadata(5 * time.Second); metadata["provider"] != "gcp" {
+		t.Fatalf("expected the gcp metadata, got %+v", metadata)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("expected the first answer to be returned at once, took %v", took)
+	}
+
+	// all requests share the timeout
+	gcpMetadataURL = hanging.URL
+	start = time.Now()
+	if metadata := cloudMetadata(100 * time.Millisecond); metadata != nil {
+		t.Fatalf("expected no metadata, got %+v", metadata)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("expected the probes to give up after the timeout, took %v", took)
+	}
+}
@@ -0,0 +1,76 @@
+package sentryhook
+
+import (
+	"context"
+	"net/http"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Middleware gives every request its own hub, whose scope is seeded with
+// the request, and its own breadcrumb trail, and stores both in the request
+// context, together with the dynamic sampling context of the request's
+// baggage header. Entries logged with the context, e.g.
+//
+//	logger.WithContext(r.Context()).Error("payment failed")
+//
+// get the request, and everything the handler sets on the hub's scope, like
+// the user or tags, in their events. The hook's own hub scope is applied on
+// top, as for all events. Handlers can reach the hub with
+// sentrygo.GetHubFromContext.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(RequestContext(r)))
+	})
+}
+
+// RequestContext returns the context Middleware serves the request with,
+// for adapters to other routers and frameworks.
+func RequestContext(r *http.Request) context.Context {
+	// a fresh scope, so nothing of the hook's hub is applied twice
+	hub := sentrygo.NewHub(sentrygo.CurrentHub().Client(), sentrygo.NewScope())
+	hub.Scope().SetRequest(r)
+	ctx := sentrygo.SetHubOnContext(r.Context(), hub)
+	if dsc := ParseBaggage(r.Header.Get("baggage")); dsc != nil {
+		ctx = ContextWithDynamicSamplingContext(ctx, dsc)
+	}
+	return BreadcrumbContext(ctx)
+}
+
+// contextHub returns the hub stored in the entry's context, unless it is
+// the hook's own hub, whose scope is applied when the event is sent.
+func (hook *SentryHook) contextHub(entry *logrus.Entry) *sentrygo.Hub {
+	if entry == nil || entry.Context == nil {
+		return nil
+	}
+	hub := sentrygo.GetHubFromContext(entry.Context)
+	if hub == hook.currentHub() {
+		return nil
+	}
+	return hub
+}
+
+// applyContextScope applies the scope of the entry's context hub to the
+// event. Requests from the scope are sanitized like request fields, and
+// merged tags are kept unless scope tags have the highest precedence. It
+// returns nil if an event processor of the scope drops the event.
+func (hook *SentryHook) applyContextScope(event *sentrygo.Event, entry *logrus.Entry) *sentrygo.Event {
+	hub := hook.contextHub(entry)
+	if hub == nil {
+		return event
+	}
+	tags := event.Tags
+	hadRequest := event.Request != nil
+	event = hub.Scope().ApplyToEvent(event, nil)
+	if event == nil {
+		return nil
+	}
+	if !hadRequest && event.Request != nil {
+		hook.sanitizeRequest(event.Request)
+	}
+	if hook.tagPrecedence != nil && hook.tagPrecedence[0] != TagSourceScope {
+		event.Tags = tags
+	}
+	return event
+}
@@ -0,0 +1,79 @@
+package sentryhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithBreadcrumbs(logrus.InfoLevel, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sentrygo.GetHubFromContext(r.Context()).Scope().SetUser(sentrygo.User{ID: "42"})
+		sentrygo.GetHubFromContext(r.Context()).Scope().SetTag("route", "/pay")
+		log.WithContext(r.Context()).Info("charging")
+		log.WithContext(r.Context()).Error("payment failed")
+	}))
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/pay?token=abc&id=7", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	log.Error("outside")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	event := events[0]
+	if event.Request == nil || event.Request.Method != http.MethodPost || event.Request.URL != "http://example.com/pay" {
+		t.Fatalf("expected the request in the event, got %+v", event.Request)
+	}
+	if event.Request.Headers["Authorization"] != filtered || event.Request.QueryString != "id=7&token=%5BFiltered%5D" {
+		t.Fatalf("expected a sanitized request, got %+v", event.Request)
+	}
+	if event.User.ID != "42" || event.Tags["route"] != "/pay" {
+		t.Fatalf("expected the request scope in the event, got user %+v and tags %v", event.User, event.Tags)
+	}
+	if len(event.Breadcrumbs) != 1 || event.Breadcrumbs[0].Message != "charging" {
+		t.Fatalf("expected the request breadcrumbs, got %+v", event.Breadcrumbs)
+	}
+
+	outside := events[1]
+	if outside.Request != nil || outside.User.ID != "" || outside.Tags["route"] != "" || len(outside.Breadcrumbs) != 0 {
+		t.Fatalf("expected the request data to stay with the request, got %+v", outside)
+	}
+}
+
+func TestCapturePanicContext(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				_ = hook.CapturePanicContext(r.Context(), recovered, nil)
+			}
+		}()
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://example.com/orders", nil))
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Request == nil || events[0].Request.URL != "http://example.com/orders" {
+		t.Fatalf("expected the panic event with the request, got %+v", events)
+	}
+}
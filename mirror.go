@@ -0,0 +1,61 @@
+package sentryhook
+
+import (
+	"fmt"
+	"net/http"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// mirrorDestination is the name of the mirror destination on Errors.
+const mirrorDestination = "mirror"
+
+// WithMirror sends a share of the events, rate between 0.0 and 1.0, to a
+// second Sentry as well, e.g. a self-hosted instance validated before
+// migrating to it. The mirror is a destination like those of
+// WithDestination: it gets its own copy of every event, is delivered to
+// independently and reports its failures on Errors as "mirror". It is sent
+// to through the proxy, CA certificates and network constraints of the
+// hook. An invalid DSN makes the constructor fail.
+func WithMirror(dsn string, rate float64) Option {
+	return func(hook *SentryHook) {
+		hook.mirror = &mirror{dsn: dsn, rate: rate}
+	}
+}
+
+// mirror is the second Sentry of WithMirror.
+type mirror struct {
+	dsn  string
+	rate float64
+}
+
+// addMirror adds the destination of WithMirror, once the options
+// configuring the transport are applied.
+func (hook *SentryHook) addMirror() error {
+	base, err := hook.baseTransport()
+	if err != nil {
+		return err
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	outcomes := &deliveryOutcomes{}
+	options := sentrygo.ClientOptions{
+		Dsn:           hook.mirror.dsn,
+		Release:       hook.release,
+		HTTPTransport: hook.deliveryTransport(&dscTransport{base: base, hook: hook}, outcomes),
+	}
+	client, err := sentrygo.NewClient(options)
+	if err != nil {
+		return fmt.Errorf("sentryhook: mirror: %v", err)
+	}
+	outcomes.setWired(options)
+	hook.destinations = append(hook.destinations, &destination{
+		name:     mirrorDestination,
+		client:   client,
+		sampled:  true,
+		rate:     hook.mirror.rate,
+		outcomes: outcomes,
+	})
+	return nil
+}
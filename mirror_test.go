@@ -0,0 +1,91 @@
+package sentryhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMirror(t *testing.T) {
+	primary := NewMockServer()
+	defer primary.Close()
+	mirror := NewMockServer()
+	defer mirror.Close()
+	hook, err := NewSentryHook(primary.DSN(), WithMirror(mirror.DSN(), 0.5), WithDeterministicMode(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	for i := 0; i < 20; i++ {
+		log.Error("payment failed")
+	}
+	if n := len(primary.Events()); n != 20 {
+		t.Fatalf("expected every event at the primary, got %d", n)
+	}
+	if n := len(mirror.Events()); n == 0 || n == 20 {
+		t.Fatalf("expected a share of the events at the mirror, got %d", n)
+	}
+
+	// failures of the mirror don't affect the primary
+	primary.Reset()
+	mirror.Reset()
+	mirror.FailNext(100, 500)
+	hook, err = NewSentryHook(primary.DSN(), WithMirror(mirror.DSN(), 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log = logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("payment failed")
+	if n := len(primary.Events()); n != 1 {
+		t.Fatalf("expected the event at the primary, got %d", n)
+	}
+
+	if _, err := NewSentryHook(primary.DSN(), WithMirror("not a dsn", 1)); err == nil {
+		t.Fatal("expected an error for an invalid mirror DSN")
+	}
+}
+
+func TestMirrorThroughProxy(t *testing.T) {
+	primary := NewMockServer()
+	defer primary.Close()
+	mirror := NewMockServer()
+	defer mirror.Close()
+	var mu sync.Mutex
+	var proxied int
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied++
+		mu.Unlock()
+		r.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	// the mirror option comes first, the proxy still applies to it
+	hook, err := NewSentryHook(primary.DSN(), WithMirror(mirror.DSN(), 1), WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("payment failed")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(primary.Events()) != 1 || len(mirror.Events()) != 1 || proxied != 2 {
+		t.Fatalf("expected both deliveries through the proxy, got %d proxied requests", proxied)
+	}
+}
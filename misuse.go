@@ -0,0 +1,27 @@
+package sentryhook
+
+import "errors"
+
+// ErrNilClient is returned when a hook is created with a nil client.
+var ErrNilClient = errors.New("sentryhook: nil client")
+
+// WithPanicOnMisuse makes the hook panic instead of returning an error on
+// obvious integration bugs, so they surface in tests and during development
+// rather than silently losing events in production: logging to or closing a
+// closed hook and creating a hook with a nil client. Callbacks need no such
+// check, as they are only set by options when the hook is created; what may
+// change while logging goes through the setters and ApplyConfig. It is not
+// meant for production use.
+func WithPanicOnMisuse() Option {
+	return func(hook *SentryHook) {
+		hook.panicOnMisuse = true
+	}
+}
+
+// misuse returns err, or panics with it in WithPanicOnMisuse mode.
+func (hook *SentryHook) misuse(err error) error {
+	if hook.panicOnMisuse {
+		panic(err)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func expectPanic(t *testing.T, want error, fn func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != want {
+			t.Fatalf("expected a panic with %v, got %v", want, r)
+		}
+	}()
+	fn()
+}
+
+func TestPanicOnMisuse(t *testing.T) {
+	if _, err := NewWithClientSentryHook(nil); err != ErrNilClient {
+		t.Fatalf("expected ErrNilClient, got %v", err)
+	}
+	expectPanic(t, ErrNilClient, func() {
+		_, _ = NewWithClientSentryHook(nil, WithPanicOnMisuse())
+	})
+
+	hook, err := NewSentryHook("", WithPanicOnMisuse(), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	expectPanic(t, ErrClosed, func() {
+		_ = hook.Fire(logrus.NewEntry(log).WithField("k", "v"))
+	})
+	expectPanic(t, ErrClosed, func() {
+		_, _ = hook.Close(context.Background())
+	})
+}
@@ -0,0 +1,245 @@
+package sentryhook
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+const mockServerPublicKey = "public"
+
+// MockEnvelopeItem is a single item of an envelope received by a MockServer.
+type MockEnvelopeItem struct {
+	Header  map[string]interface{}
+	Type    string
+	Payload []byte
+}
+
+// MockServer is a lightweight in-process Sentry server for integration tests.
+// It accepts store and envelope requests, decodes them and exposes what was
+// received, so tests can go end to end through DSN parsing, the transport
+// and Flush without talking to a real Sentry instance.
+type MockServer struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	events     []*sentrygo.Event
+	items      []MockEnvelopeItem
+	headers    []map[string]interface{}
+	requests   int
+	failNext   int
+	failStatus int
+}
+
+// NewMockServer starts a MockServer. Callers should Close it when done.
+func NewMockServer() *MockServer {
+	s := &MockServer{}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// DSN returns a DSN pointing at the mock server for project 1.
+func (s *MockServer) DSN() string {
+	return strings.Replace(s.server.URL, "://", "://"+mockServerPublicKey+"@", 1) + "/1"
+}
+
+// URL returns the base URL of the mock server.
+func (s *MockServer) URL() string {
+	return s.server.URL
+}
+
+// Close shuts the mock server down.
+func (s *MockServer) Close() {
+	s.server.Close()
+}
+
+// Events returns a copy of the events received so far, in arrival order.
+func (s *MockServer) Events() []*sentrygo.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make([]*sentrygo.Event, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+// Items returns a copy of the envelope items received so far which are not
+// events, such as attachments or check-ins.
+func (s *MockServer) Items() []MockEnvelopeItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := make([]MockEnvelopeItem, len(s.items))
+	copy(items, s.items)
+	return items
+}
+
+// EnvelopeHeaders returns the headers of the envelopes received so far, in
+// arrival order.
+func (s *MockServer) EnvelopeHeaders() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	headers := make([]map[string]interface{}, len(s.headers))
+	copy(headers, s.headers)
+	return headers
+}
+
+// Requests returns the number of requests received, including failed ones.
+func (s *MockServer) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// FailNext makes the server answer the next n requests with the given HTTP
+// status code instead of accepting them.
+func (s *MockServer) FailNext(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext = n
+	s.failStatus = status
+}
+
+// Reset forgets everything received so far.
+func (s *MockServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = nil
+	s.items = nil
+	s.headers = nil
+	s.requests = 0
+	s.failNext = 0
+}
+
+func (s *MockServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	if s.failNext > 0 {
+		s.failNext--
+		status := s.failStatus
+		s.mu.Unlock()
+		http.Error(w, "mock failure", status)
+		return
+	}
+	s.mu.Unlock()
+
+	if !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key="+mockServerPublicKey) &&
+		r.URL.Query().Get("sentry_key") != mockServerPublicKey {
+		http.Error(w, "missing or invalid sentry_key", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := readMockBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/store/"):
+		event := &sentrygo.Event{}
+		if err := json.Unmarshal(body, event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.events = append(s.events, event)
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"id":%q}`, event.EventID)
+	case strings.HasSuffix(r.URL.Path, "/envelope/"):
+		header, events, items, err := parseMockEnvelope(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.headers = append(s.headers, header)
+		s.events = append(s.events, events...)
+		s.items = append(s.items, items...)
+		s.mu.Unlock()
+		fmt.Fprint(w, `{}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func readMockBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	case "deflate":
+		zr, err := zlib.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		reader = zr
+	}
+	return ioutil.ReadAll(reader)
+}
+
+// parseMockEnvelope splits an envelope into its header and items. Event and
+// transaction items are decoded into events, everything else is returned as
+// raw items.
+func parseMockEnvelope(body []byte) (map[string]interface{}, []*sentrygo.Event, []MockEnvelopeItem, error) {
+	reader := bufio.NewReader(bytes.NewReader(body))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("envelope header: %v", err)
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, nil, nil, fmt.Errorf("envelope header: %v", err)
+	}
+	var events []*sentrygo.Event
+	var items []MockEnvelopeItem
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err != nil {
+				break
+			}
+			continue
+		}
+		item := MockEnvelopeItem{}
+		if err := json.Unmarshal(line, &item.Header); err != nil {
+			return nil, nil, nil, fmt.Errorf("item header: %v", err)
+		}
+		item.Type, _ = item.Header["type"].(string)
+		if length, ok := item.Header["length"].(float64); ok {
+			item.Payload = make([]byte, int(length))
+			if _, err := io.ReadFull(reader, item.Payload); err != nil {
+				return nil, nil, nil, fmt.Errorf("item payload: %v", err)
+			}
+		} else {
+			payload, _ := reader.ReadBytes('\n')
+			item.Payload = bytes.TrimRight(payload, "\n")
+		}
+		if item.Type == "event" || item.Type == "transaction" {
+			event := &sentrygo.Event{}
+			if err := json.Unmarshal(item.Payload, event); err != nil {
+				return nil, nil, nil, fmt.Errorf("event payload: %v", err)
+			}
+			events = append(events, event)
+		} else {
+			items = append(items, item)
+		}
+	}
+	return header, events, items, nil
+}
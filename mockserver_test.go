@@ -0,0 +1,72 @@
+package sentryhook
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMockServerReceivesEvents(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("mock server error")
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].Level != "error" {
+		t.Fatalf("expected level error, got %q", events[0].Level)
+	}
+}
+
+func TestMockServerFailNext(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	server.FailNext(1, http.StatusServiceUnavailable)
+
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("dropped")
+	log.Error("accepted")
+
+	if n := server.Requests(); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+	if n := len(server.Events()); n != 1 {
+		t.Fatalf("expected 1 accepted event, got %d", n)
+	}
+}
+
+func TestParseMockEnvelope(t *testing.T) {
+	body := []byte(`{"event_id":"abc"}
+{"type":"event","length":18}
+{"message":"boom"}
+{"type":"attachment","length":5,"filename":"a.txt"}
+hello
+`)
+	_, events, items, err := parseMockEnvelope(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Message != "boom" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if len(items) != 1 || items[0].Type != "attachment" || string(items[0].Payload) != "hello" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+}
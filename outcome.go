@@ -0,0 +1,147 @@
+package sentryhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// TransportError is reported when an event could not be sent to the
+// server, e.g. because of a connection, TLS or proxy failure, or when the
+// server did not accept it.
+type TransportError struct {
+	// the status of the server's response, zero if there was none
+	StatusCode int
+	// the error of the request, nil if the server responded
+	Err error
+}
+
+func (e TransportError) Error() string {
+	if e.Err != nil {
+		return "sentryhook: sending event failed: " + e.Err.Error()
+	}
+	return "sentryhook: event rejected with status " + strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+}
+
+// temporary reports whether sending the event again may succeed: the
+// server could not be reached, was rate limiting or had an error.
+func (e TransportError) temporary() bool {
+	return e.StatusCode == 0 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Unwrap returns the error of the request.
+func (e TransportError) Unwrap() error {
+	return e.Err
+}
+
+// errNotSent is the outcome of a tracked event no request was seen for.
+var errNotSent = errors.New("sentryhook: no request sent")
+
+// deliveryOutcomes holds the outcomes of the requests sending the events
+// being delivered through a client whose HTTP transport the hook wrapped,
+// by event id. The client reports neither failed requests nor error
+// statuses, so a flushed event would otherwise count as delivered.
+type deliveryOutcomes struct {
+	mu      sync.Mutex
+	pending map[sentrygo.EventID]error
+	// whether every event is sent through the wrapped transport, so an
+	// event without a request was dropped, e.g. by the client's rate limit
+	wired bool
+}
+
+// setWired records whether the events of the client are sent through the
+// wrapped transport: it has a DSN and no transport of its own.
+func (o *deliveryOutcomes) setWired(options sentrygo.ClientOptions) {
+	o.mu.Lock()
+	o.wired = options.Dsn != "" && options.Transport == nil
+	o.mu.Unlock()
+}
+
+// track registers the event until the returned function is called, which
+// returns the outcome of its request: nil if it was accepted or its
+// outcome is not known. It assigns the event id the client would
+// otherwise assign.
+func (o *deliveryOutcomes) track(hook *SentryHook, event *sentrygo.Event) func() error {
+	if event.EventID == "" {
+		event.EventID = sentrygo.EventID(hook.newID())
+	}
+	id := event.EventID
+	o.mu.Lock()
+	if o.pending == nil {
+		o.pending = make(map[sentrygo.EventID]error)
+	}
+	o.pending[id] = errNotSent
+	o.mu.Unlock()
+	return func() error {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		err := o.pending[id]
+		delete(o.pending, id)
+		if err == errNotSent {
+			if !o.wired {
+				return nil
+			}
+			return ErrEventDropped
+		}
+		return err
+	}
+}
+
+// record sets the outcome of the request of a tracked event.
+func (o *deliveryOutcomes) record(id sentrygo.EventID, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.pending[id]; ok {
+		o.pending[id] = err
+	}
+}
+
+// outcomeTransport records the outcome of the requests of tracked events.
+type outcomeTransport struct {
+	base     http.RoundTripper
+	outcomes *deliveryOutcomes
+}
+
+func (t *outcomeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, err := storeEventID(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if id != "" {
+		var outcome error
+		if err != nil {
+			outcome = TransportError{Err: err}
+		} else if resp.StatusCode >= 300 {
+			outcome = TransportError{StatusCode: resp.StatusCode}
+		}
+		t.outcomes.record(id, outcome)
+	}
+	return resp, err
+}
+
+// storeEventID returns the id of the event a request to the store endpoint
+// sends, leaving the body to be read again.
+func storeEventID(req *http.Request) (sentrygo.EventID, error) {
+	if req.Body == nil || !strings.HasSuffix(req.URL.Path, "/store/") {
+		return "", nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	var event struct {
+		EventID sentrygo.EventID `json:"event_id"`
+	}
+	_ = json.Unmarshal(body, &event)
+	return event.EventID, nil
+}
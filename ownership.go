@@ -0,0 +1,75 @@
+package sentryhook
+
+import (
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// ownerTag is the tag holding the team owning an event, for Sentry
+// ownership rules.
+const ownerTag = "owner"
+
+// WithOwnershipResolver tags events with the team returned by the resolver,
+// which is given the hash of the event fingerprint, as in RecentEvent, and
+// the entry. An empty result falls back to the package owners, see
+// WithPackageOwners.
+func WithOwnershipResolver(resolver func(fingerprint string, entry *logrus.Entry) string) Option {
+	return func(hook *SentryHook) {
+		hook.ownerResolver = resolver
+	}
+}
+
+// WithPackageOwners tags events with the team owning the code they come
+// from, like a CODEOWNERS file for packages: owners maps package path
+// prefixes to teams, and the innermost stack frame with an owner decides,
+// the longest prefix winning.
+func WithPackageOwners(owners map[string]string) Option {
+	return func(hook *SentryHook) {
+		hook.packageOwners = owners
+	}
+}
+
+// addOwner tags the event with its owning team.
+func (hook *SentryHook) addOwner(event *sentrygo.Event, entry *logrus.Entry) {
+	if hook.ownerResolver == nil && hook.packageOwners == nil {
+		return
+	}
+	owner := ""
+	if hook.ownerResolver != nil {
+		owner = hook.ownerResolver(fingerprintHash(event), entry)
+	}
+	if owner == "" {
+		owner = hook.packageOwner(event)
+	}
+	if owner != "" {
+		event.Tags[ownerTag] = owner
+	}
+}
+
+// packageOwner returns the owner of the innermost owned frame.
+func (hook *SentryHook) packageOwner(event *sentrygo.Event) string {
+	for i := len(event.Exception) - 1; i >= 0; i-- {
+		st := event.Exception[i].Stacktrace
+		if st == nil {
+			continue
+		}
+		for j := len(st.Frames) - 1; j >= 0; j-- {
+			if owner := longestPrefixOwner(hook.packageOwners, st.Frames[j].Module); owner != "" {
+				return owner
+			}
+		}
+	}
+	return ""
+}
+
+func longestPrefixOwner(owners map[string]string, module string) string {
+	owner, longest := "", -1
+	for prefix, team := range owners {
+		if len(prefix) > longest && (module == prefix || strings.HasPrefix(module, strings.TrimSuffix(prefix, "/")+"/")) {
+			owner, longest = team, len(prefix)
+		}
+	}
+	return owner
+}
@@ -0,0 +1,51 @@
+package sentryhook
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestOwnership(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(),
+		WithPackageOwners(map[string]string{
+			"github.com/ainiaa":            "platform",
+			"github.com/ainiaa/sentryhook": "observability",
+			"github.com/sirupsen":          "vendors",
+		}),
+		WithOwnershipResolver(func(fingerprint string, entry *logrus.Entry) string {
+			if fingerprint == "" {
+				t.Error("expected a fingerprint")
+			}
+			if team, ok := entry.Data["team"].(string); ok {
+				return team
+			}
+			return ""
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithField("team", "payments").Error("explicit")
+	log.Error("from the stack")
+	log.WithError(errors.New("no stack")).Error("no frames")
+
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Tags[ownerTag] != "payments" {
+		t.Fatalf("expected the resolver to win, got %v", events[0].Tags)
+	}
+	if events[1].Tags[ownerTag] != "observability" {
+		t.Fatalf("expected the longest package prefix to win, got %v", events[1].Tags)
+	}
+	if owner, ok := events[2].Tags[ownerTag]; ok {
+		t.Fatalf("expected no owner without frames, got %q", owner)
+	}
+}
@@ -0,0 +1,180 @@
+package sentryhook
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// RecoverAndReport reports a panic of the calling goroutine and then
+// continues panicking with the same value. It must be deferred directly:
+//
+//	go func() {
+//		defer hook.RecoverAndReport()
+//		...
+//	}()
+//
+// The report is delivered synchronously, see CapturePanic, so it is not
+// lost when the panic terminates the program.
+func (hook *SentryHook) RecoverAndReport() {
+	if recovered := recover(); recovered != nil {
+		_ = hook.CapturePanic(recovered, debug.Stack())
+		panic(recovered)
+	}
+}
+
+// CapturePanic reports a recovered panic value with the goroutine stack
+// captured by debug.Stack or runtime.Stack as a fatal event, tagged as an
+// unhandled panic. Unlike logged events it bypasses the queue and the
+// configured levels and blocks until the event is delivered or the flush
+// timeout expires. A nil stack captures the stack of the caller.
+func (hook *SentryHook) CapturePanic(recovered interface{}, stack []byte) error {
+	if stack == nil {
+		stack = debug.Stack()
+	}
+	return hook.CapturePanicContext(nil, recovered, stack)
+}
+
+// CapturePanicContext is like CapturePanic for a panic while serving the
+// context, e.g. of a request wrapped by Middleware, so the event gets the
+// scope of the context's hub and its breadcrumbs. The framework middlewares
+// report recovered panics with it; their events are tagged with the
+// mechanism "middleware".
+func (hook *SentryHook) CapturePanicContext(ctx context.Context, recovered interface{}, stack []byte) error {
+	if stack == nil {
+		stack = debug.Stack()
+	}
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Context = ctx
+	entry.Time = hook.now()
+	entry.Level = logrus.PanicLevel
+	entry.Message = fmt.Sprint(recovered)
+	event := hook.buildEvent(entry)
+	if event == nil {
+		return nil
+	}
+	event.Level = sentrygo.LevelFatal
+	if ctx != nil {
+		setMechanism(event, mechanismMiddleware, false)
+	} else {
+		setMechanism(event, mechanismPanic, false)
+	}
+	hook.trackSession(entry)
+
+	trace := parseStack(stack)
+	if err, ok := recovered.(error); ok && !isNil(err) {
+		event.Exception = hook.exceptions(err)
+		event.Exception[len(event.Exception)-1].Stacktrace = trace
+	} else {
+		event.Exception = []sentrygo.Exception{{
+			Type:       "panic",
+			Value:      entry.Message,
+			Stacktrace: trace,
+		}}
+	}
+	hook.markInApp(event)
+	hook.demangleFrames(event)
+	if event = hook.scrub(event); event == nil {
+		hook.debug(nil, logrus.Fields{"reason": "scrubber", "message": entry.Message}, "event dropped")
+		return nil
+	}
+	return hook.dispatchNow(event, entry)
+}
+
+// dispatchNow delivers the event to all destinations synchronously, also in
+// asynchronous mode, waiting at most the flush timeout for each, unless the
+// quarantine holds it back.
+func (hook *SentryHook) dispatchNow(event *sentrygo.Event, entry *logrus.Entry) error {
+	if hook.quarantined(event) {
+		hook.dropped(event, "quarantined")
+		return nil
+	}
+	hook.mu.RLock()
+	defer hook.mu.RUnlock()
+	if hook.closed {
+		return hook.misuse(ErrClosed)
+	}
+	if hook.buffer(event) {
+		return nil
+	}
+	for _, dest := range hook.destinations {
+		if c := dest.prepare(hook, event, entry); c != nil {
+			if err := hook.deliverWithin(dest, c, hook.flushTimeout); err != nil {
+				hook.reportError(dest, c, err)
+			}
+		}
+	}
+	err := hook.deliverWithin(nil, event, hook.flushTimeout)
+	if err == nil {
+		hook.sendAttachments(event, hook.entryAttachments(entry))
+	}
+	return err
+}
+
+// parseStack converts the text of a goroutine stack, as printed by
+// runtime.Stack, into a stacktrace. Frames up to and including the panic
+// call are left out, so the trace ends where the panic happened.
+func parseStack(stack []byte) *sentrygo.Stacktrace {
+	lines := strings.Split(string(stack), "\n")
+	var frames []sentrygo.Frame
+	panicAt := -1
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "goroutine ") {
+			if len(frames) > 0 {
+				// only the first goroutine
+				break
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		function := line
+		if strings.HasPrefix(function, "created by ") {
+			function = strings.TrimPrefix(function, "created by ")
+			if p := strings.Index(function, " in goroutine "); p > 0 {
+				function = function[:p]
+			}
+		} else if p := strings.LastIndex(function, "("); p > 0 {
+			function = function[:p]
+		}
+		frame := runtime.Frame{Function: function}
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			i++
+			location := strings.TrimSpace(lines[i])
+			if p := strings.LastIndex(location, " +0x"); p > 0 {
+				location = location[:p]
+			}
+			if p := strings.LastIndex(location, ":"); p > 0 {
+				frame.File = location[:p]
+				frame.Line, _ = strconv.Atoi(location[p+1:])
+			}
+		}
+		if function == "panic" || function == "runtime.gopanic" {
+			panicAt = len(frames)
+		}
+		frames = append(frames, sentrygo.NewFrame(frame))
+	}
+	if panicAt < 0 {
+		// no panic call, e.g. a stack captured by CapturePanic itself; leave
+		// out the capturing frames
+		for panicAt+1 < len(frames) && (frames[panicAt+1].Module == "runtime/debug" ||
+			strings.HasPrefix(frames[panicAt+1].Function, "(*SentryHook).")) {
+			panicAt++
+		}
+	}
+	frames = frames[panicAt+1:]
+
+	// sentry wants the oldest frame first
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return &sentrygo.Stacktrace{Frames: frames}
+}
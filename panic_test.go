@@ -0,0 +1,74 @@
+package sentryhook
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+func TestRecoverAndReport(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewAsyncSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var repanicked interface{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() { repanicked = recover() }()
+		defer hook.RecoverAndReport()
+		explode()
+	}()
+	wg.Wait()
+
+	if repanicked != "boom" {
+		t.Fatalf("expected the panic to continue, got %v", repanicked)
+	}
+	// delivered synchronously, without Flush
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Level != sentrygo.LevelFatal || event.Tags["handled"] != "no" || event.Tags["mechanism"] != "panic" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	exception := event.Exception[len(event.Exception)-1]
+	if exception.Value != "boom" || exception.Stacktrace == nil {
+		t.Fatalf("unexpected exception %+v", exception)
+	}
+	frames := exception.Stacktrace.Frames
+	if last := frames[len(frames)-1]; last.Function != "explode" || last.Lineno == 0 {
+		t.Fatalf("expected the trace to end in the panicking function, got %+v", last)
+	}
+}
+
+func explode() {
+	panic("boom")
+}
+
+func TestCapturePanicError(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.CapturePanic(errors.New("nil map"), nil); err != nil {
+		t.Fatal(err)
+	}
+	events := server.Events()
+	if len(events) != 1 || events[0].Exception[0].Value != "nil map" || events[0].Exception[0].Stacktrace == nil {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	frames := events[0].Exception[0].Stacktrace.Frames
+	if last := frames[len(frames)-1]; last.Function != "TestCapturePanicError" {
+		t.Fatalf("expected the trace to end in the caller, got %+v", last)
+	}
+}
@@ -0,0 +1,101 @@
+package sentryhook
+
+import (
+	"errors"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// ErrClientSet is returned by SetClient if the hook already has a client.
+var ErrClientSet = errors.New("sentryhook: client already set")
+
+// bufferedPreInitTag marks events logged before the hook had a client.
+const bufferedPreInitTag = "buffered_pre_init"
+
+// NewPendingSentryHook creates a hook without a client, for attaching it to
+// a logger before the client can be created, e.g. while the DSN is fetched
+// from a secrets manager. Events are buffered, up to max of them, until
+// SetClient is called; events beyond max are dropped and counted in
+// Stats.Dropped. As with NewWithClientSentryHook, options configuring the
+// client have no effect.
+func NewPendingSentryHook(max int, opts ...Option) *SentryHook {
+	hook := newSentryHook(opts...)
+	hook.pendingMax = max
+	return hook.init()
+}
+
+// SetClient sets the client of a hook created by NewPendingSentryHook and
+// sends the buffered events, tagged "buffered_pre_init". It returns the
+// first error sending them.
+func (hook *SentryHook) SetClient(client *sentrygo.Client) error {
+	if client == nil {
+		return hook.misuse(ErrNilClient)
+	}
+	set, err := hook.installClient(client)
+	if !set {
+		return hook.misuse(ErrClientSet)
+	}
+	return err
+}
+
+// installClient sets the client of a pending hook and sends the buffered
+// events. It reports false, leaving the hook alone, if the hook already has
+// a client.
+func (hook *SentryHook) installClient(client *sentrygo.Client) (bool, error) {
+	hook.mu.Lock()
+	if hook.client != nil {
+		hook.mu.Unlock()
+		return false, nil
+	}
+	hook.clientMu.Lock()
+	hook.client = client
+	hook.clientMu.Unlock()
+	hook.pendingMu.Lock()
+	buffered := hook.pending
+	hook.pending = nil
+	hook.pendingMu.Unlock()
+	hook.mu.Unlock()
+
+	var first error
+	for _, event := range buffered {
+		event.Tags[bufferedPreInitTag] = "true"
+		if err := hook.dispatchTo(event, nil, ""); err != nil && first == nil {
+			first = err
+		}
+	}
+	return true, first
+}
+
+// buffer holds the event back if the hook has no client yet, reporting
+// whether it did. It is called with hook.mu held.
+func (hook *SentryHook) buffer(event *sentrygo.Event) bool {
+	if hook.client != nil {
+		return false
+	}
+	hook.pendingMu.Lock()
+	defer hook.pendingMu.Unlock()
+	if len(hook.pending) >= hook.pendingMax {
+		hook.stats.update(func(stats *Stats) { stats.Dropped++ })
+		return true
+	}
+	if event.Tags == nil {
+		event.Tags = make(map[string]string)
+	}
+	hook.pending = append(hook.pending, event)
+	return true
+}
+
+// currentClient returns the hook's client, or nil while it is pending.
+func (hook *SentryHook) currentClient() *sentrygo.Client {
+	hook.mu.RLock()
+	defer hook.mu.RUnlock()
+	return hook.client
+}
+
+// deliveryClient returns the hook's client without holding mu, for
+// deliveries of the queue workers.
+func (hook *SentryHook) deliveryClient() *sentrygo.Client {
+	hook.clientMu.RLock()
+	defer hook.clientMu.RUnlock()
+	return hook.client
+}
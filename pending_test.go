@@ -0,0 +1,48 @@
+package sentryhook
+
+import (
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPendingSentryHook(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook := NewPendingSentryHook(2, WithExitHandler(false))
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("first")
+	log.Error("second")
+	log.Error("third")
+	if dropped := hook.Stats().Dropped; dropped != 1 {
+		t.Fatalf("expected 1 event dropped from the full buffer, got %d", dropped)
+	}
+
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Dsn: server.DSN()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.SetClient(client); err != nil {
+		t.Fatal(err)
+	}
+	log.Error("after")
+	if err := hook.SetClient(client); err != ErrClientSet {
+		t.Fatalf("expected ErrClientSet, got %v", err)
+	}
+
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for i, want := range []string{"first", "second", "after"} {
+		if events[i].Message != want {
+			t.Fatalf("expected event %d to be %q, got %q", i, want, events[i].Message)
+		}
+		if buffered := events[i].Tags[bufferedPreInitTag] == "true"; buffered != (i < 2) {
+			t.Fatalf("unexpected buffered_pre_init tag on %q: %v", want, events[i].Tags)
+		}
+	}
+}
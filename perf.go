@@ -0,0 +1,63 @@
+package sentryhook
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRecentGCPauses limits how many recent GC pauses the perf context lists.
+const maxRecentGCPauses = 10
+
+// WithPerfContext attaches a "perf" context describing recent runtime
+// pressure (GC pauses and, on Go 1.17+, scheduler latencies) to events
+// carrying any of the trigger tags or fields, e.g. {"type": "latency"}.
+// This helps to tell application bugs from a runtime under pressure.
+func WithPerfContext(trigger map[string]string) Option {
+	return func(hook *SentryHook) {
+		hook.perfTrigger = trigger
+	}
+}
+
+// perfTriggered reports whether the event or entry carries a trigger tag.
+func (hook *SentryHook) perfTriggered(event *sentrygo.Event, entry *logrus.Entry) bool {
+	for k, v := range hook.perfTrigger {
+		if event.Tags[k] == v {
+			return true
+		}
+		if value, ok := entry.Data[k]; ok && fmt.Sprint(value) == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (hook *SentryHook) addPerfContext(event *sentrygo.Event, entry *logrus.Entry) {
+	if len(hook.perfTrigger) == 0 || !hook.perfTriggered(event, entry) {
+		return
+	}
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	pauses := gc.Pause
+	if len(pauses) > maxRecentGCPauses {
+		pauses = pauses[:maxRecentGCPauses]
+	}
+	recent := make([]string, len(pauses))
+	for i, pause := range pauses {
+		recent[i] = pause.String()
+	}
+	perf := map[string]interface{}{
+		"gc_count":         gc.NumGC,
+		"gc_pause_total":   gc.PauseTotal.String(),
+		"gc_last":          gc.LastGC,
+		"gc_recent_pauses": recent,
+	}
+	addRuntimeMetrics(perf)
+
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]interface{})
+	}
+	event.Contexts["perf"] = perf
+}
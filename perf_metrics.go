@@ -0,0 +1,61 @@
+//go:build go1.17
+// +build go1.17
+
+package sentryhook
+
+import (
+	"math"
+	"runtime/metrics"
+	"time"
+)
+
+// addRuntimeMetrics adds scheduler latency and GC pause percentiles read
+// from runtime/metrics.
+func addRuntimeMetrics(perf map[string]interface{}) {
+	samples := []metrics.Sample{
+		{Name: "/sched/latencies:seconds"},
+		{Name: "/gc/pauses:seconds"},
+		{Name: "/sched/goroutines:goroutines"},
+	}
+	metrics.Read(samples)
+	for _, sample := range samples {
+		switch sample.Value.Kind() {
+		case metrics.KindFloat64Histogram:
+			prefix := "sched_latency"
+			if sample.Name == "/gc/pauses:seconds" {
+				prefix = "gc_pause"
+			}
+			h := sample.Value.Float64Histogram()
+			perf[prefix+"_p50"] = histogramQuantile(h, 0.5).String()
+			perf[prefix+"_p99"] = histogramQuantile(h, 0.99).String()
+			perf[prefix+"_max"] = histogramQuantile(h, 1).String()
+		case metrics.KindUint64:
+			perf["goroutines"] = sample.Value.Uint64()
+		}
+	}
+}
+
+// histogramQuantile returns the upper bound of the bucket holding the
+// quantile q of a histogram of seconds.
+func histogramQuantile(h *metrics.Float64Histogram, q float64) time.Duration {
+	var total uint64
+	for _, count := range h.Counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	threshold := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, count := range h.Counts {
+		seen += count
+		if seen >= threshold && count > 0 {
+			upper := h.Buckets[i+1]
+			if math.IsInf(upper, 1) {
+				upper = h.Buckets[i]
+			}
+			return time.Duration(upper * float64(time.Second))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,8 @@
+//go:build !go1.17
+// +build !go1.17
+
+package sentryhook
+
+// addRuntimeMetrics does nothing; runtime/metrics lacks scheduler latencies
+// before Go 1.17.
+func addRuntimeMetrics(perf map[string]interface{}) {}
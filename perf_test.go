@@ -0,0 +1,31 @@
+package sentryhook
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPerfContext(t *testing.T) {
+	runtime.GC()
+	hook, err := NewSentryHook("", WithPerfContext(map[string]string{"type": "latency"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+
+	event := hook.buildEvent(log.WithField("type", "latency").WithField("took", "3s"))
+	perf, ok := event.Contexts["perf"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a perf context, got %+v", event.Contexts)
+	}
+	if perf["gc_count"].(int64) < 1 {
+		t.Fatalf("expected at least one GC, got %v", perf["gc_count"])
+	}
+
+	event = hook.buildEvent(log.WithField("type", "validation"))
+	if _, ok := event.Contexts["perf"]; ok {
+		t.Fatal("expected no perf context without the trigger")
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !sentryhook_nopkgerrors
+// +build !sentryhook_nopkgerrors
+
+package sentryhook
+
+import "github.com/pkg/errors"
+
+// This file adapts the stacks of github.com/pkg/errors. Build with the
+// sentryhook_nopkgerrors tag to leave the dependency out.
+
+type pkgErrorStackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+func init() {
+	RegisterStackExtractor(pkgErrorsStack)
+}
+
+func pkgErrorsStack(err error) []uintptr {
+	tracer, ok := err.(pkgErrorStackTracer)
+	if !ok {
+		return nil
+	}
+	st := tracer.StackTrace()
+	pcs := make([]uintptr, len(st))
+	for i, frame := range st {
+		pcs[i] = uintptr(frame)
+	}
+	return pcs
+}
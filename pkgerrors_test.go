@@ -0,0 +1,80 @@
+//go:build !sentryhook_nopkgerrors
+// +build !sentryhook_nopkgerrors
+
+package sentryhook
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestExceptionChain(t *testing.T) {
+	hook, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := errors.New("connection refused")
+	wrapped := fmt.Errorf("query users: %w", errors.Wrap(root, "dial db"))
+
+	chain := hook.exceptions(wrapped)
+	if len(chain) != 3 {
+		t.Fatalf("expected 3 exceptions, got %d: %+v", len(chain), chain)
+	}
+	if chain[0].Value != "connection refused" || chain[0].Stacktrace == nil {
+		t.Fatalf("expected the root cause first with its stacktrace, got %+v", chain[0])
+	}
+	if chain[1].Value != "dial db: connection refused" || chain[1].Stacktrace == nil {
+		t.Fatalf("expected the pkg/errors wrapper with its stacktrace, got %+v", chain[1])
+	}
+	if chain[2].Value != wrapped.Error() || chain[2].Type != "*fmt.wrapError" {
+		t.Fatalf("expected the outermost error last, got %+v", chain[2])
+	}
+}
+
+func TestExceptionChainSwitched(t *testing.T) {
+	hook, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.StacktraceConfiguration.SendExceptionType = true
+	hook.StacktraceConfiguration.SwitchExceptionTypeAndMessage = true
+	wrapped := fmt.Errorf("handle request: %w", fmt.Errorf("query users: %w", errors.WithStack(errors.New("connection refused"))))
+
+	chain := hook.exceptions(wrapped)
+	if len(chain) != 3 {
+		t.Fatalf("expected 3 exceptions, got %d: %+v", len(chain), chain)
+	}
+	if chain[0].Type != "connection refused" || chain[0].Stacktrace == nil {
+		t.Fatalf("expected the withStack wrapper merged into the root cause, got %+v", chain[0])
+	}
+	if chain[1].Type != "query users: connection refused" || chain[2].Type != wrapped.Error() {
+		t.Fatalf("expected both fmt wrappers kept, got %+v", chain[1:])
+	}
+}
+
+func TestIncludeErrorBreadcrumb(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.StacktraceConfiguration.IncludeErrorBreadcrumb = true
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithError(errors.Wrap(errors.New("connection refused"), "dial db")).Error("query failed")
+
+	events := server.Events()
+	if len(events) != 1 || len(events[0].Breadcrumbs) != 1 {
+		t.Fatalf("expected one event with a breadcrumb, got %+v", events)
+	}
+	crumb := events[0].Breadcrumbs[0]
+	if crumb.Type != "error" || !strings.HasPrefix(crumb.Message, "connection refused\n") ||
+		!strings.Contains(crumb.Message, "TestIncludeErrorBreadcrumb") {
+		t.Fatalf("expected the error with its stack, got %q", crumb.Message)
+	}
+}
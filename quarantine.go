@@ -0,0 +1,211 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// ErrNotQuarantined is returned when releasing or discarding an event which
+// is not in quarantine.
+var ErrNotQuarantined = errors.New("sentryhook: event is not in quarantine")
+
+// A SuspicionRule inspects an event before it is sent and returns why it
+// is suspicious, or an empty string if it is not.
+type SuspicionRule func(event *sentrygo.Event) string
+
+// QuarantinedEvent is an event held back for review.
+type QuarantinedEvent struct {
+	ID     uint64
+	Event  *sentrygo.Event
+	Reason string
+	Time   time.Time
+}
+
+// quarantine holds suspicious events, dropping the oldest when full.
+type quarantine struct {
+	rules    []SuspicionRule
+	capacity int
+
+	mu     sync.Mutex
+	nextID uint64
+	events []QuarantinedEvent
+}
+
+// secretPatterns match likely credentials.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`(?i)bearer [a-z0-9._~+/-]{20,}`),
+	// quotes are escaped in the serialized event
+	regexp.MustCompile(`(?i)(password|passwd|secret|api_?key)(\\?["'])?\s*[:=]\s*(\\?["'])?[^\s"'\\,}]{6,}`),
+	regexp.MustCompile(`xox[abprs]-[0-9A-Za-z-]{10,}`),
+	regexp.MustCompile(`gh[pousr]_[0-9A-Za-z]{36}`),
+}
+
+// SecretRule flags events whose message, tags, extra data, exceptions or
+// breadcrumbs look like they contain credentials such as AWS keys, private
+// keys, bearer tokens or passwords.
+func SecretRule() SuspicionRule {
+	return func(event *sentrygo.Event) string {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return ""
+		}
+		for _, pattern := range secretPatterns {
+			if pattern.Match(data) {
+				return "possible secret matching " + pattern.String()
+			}
+		}
+		return ""
+	}
+}
+
+// OversizedRule flags events whose serialized size exceeds maxBytes.
+func OversizedRule(maxBytes int) SuspicionRule {
+	return func(event *sentrygo.Event) string {
+		data, err := json.Marshal(event)
+		if err == nil && len(data) > maxBytes {
+			return fmt.Sprintf("event of %d bytes exceeds %d bytes", len(data), maxBytes)
+		}
+		return ""
+	}
+}
+
+// BinaryRule flags events whose message or extra data contain binary data:
+// byte slices which are not UTF-8 text or strings with invalid UTF-8 or
+// control characters.
+func BinaryRule() SuspicionRule {
+	return func(event *sentrygo.Event) string {
+		if binary(event.Message) {
+			return "binary data in message"
+		}
+		for k, v := range event.Extra {
+			if containsBinary(v) {
+				return "binary data in extra " + k
+			}
+		}
+		return ""
+	}
+}
+
+func containsBinary(v interface{}) bool {
+	switch v := v.(type) {
+	case []byte:
+		return true
+	case string:
+		return binary(v)
+	case []interface{}:
+		for _, e := range v {
+			if containsBinary(e) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, e := range v {
+			if containsBinary(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func binary(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r < 0x20 && r != '\n' && r != '\r' && r != '\t'
+	}) >= 0
+}
+
+// defaultQuarantineCapacity is the number of events kept in quarantine if
+// no positive capacity is given.
+const defaultQuarantineCapacity = 100
+
+// WithQuarantine holds back events matching any of the rules instead of
+// sending them, logged entries as well as captured panics and commands and
+// the events the hook reports itself, keeping up to capacity events, 100
+// if capacity is not positive, for review with Quarantined,
+// ReleaseQuarantined and DiscardQuarantined. When full, the oldest event is
+// dropped.
+func WithQuarantine(capacity int, rules ...SuspicionRule) Option {
+	return func(hook *SentryHook) {
+		if capacity <= 0 {
+			capacity = defaultQuarantineCapacity
+		}
+		hook.quarantine = &quarantine{rules: rules, capacity: capacity}
+	}
+}
+
+// quarantined holds the event back if a rule flags it, reporting whether
+// it did.
+func (hook *SentryHook) quarantined(event *sentrygo.Event) bool {
+	q := hook.quarantine
+	if q == nil {
+		return false
+	}
+	for _, rule := range q.rules {
+		if reason := rule(event); reason != "" {
+			q.mu.Lock()
+			q.nextID++
+			q.events = append(q.events, QuarantinedEvent{ID: q.nextID, Event: event, Reason: reason, Time: hook.now()})
+			if over := len(q.events) - q.capacity; over > 0 {
+				q.events = append([]QuarantinedEvent(nil), q.events[over:]...)
+			}
+			q.mu.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// Quarantined returns the events held back for review, oldest first.
+func (hook *SentryHook) Quarantined() []QuarantinedEvent {
+	q := hook.quarantine
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QuarantinedEvent(nil), q.events...)
+}
+
+// ReleaseQuarantined sends a quarantined event after review.
+func (hook *SentryHook) ReleaseQuarantined(id uint64) error {
+	event, err := hook.takeQuarantined(id)
+	if err != nil {
+		return err
+	}
+	return hook.dispatchTo(event, nil, "")
+}
+
+// DiscardQuarantined drops a quarantined event after review.
+func (hook *SentryHook) DiscardQuarantined(id uint64) error {
+	_, err := hook.takeQuarantined(id)
+	return err
+}
+
+func (hook *SentryHook) takeQuarantined(id uint64) (*sentrygo.Event, error) {
+	q := hook.quarantine
+	if q == nil {
+		return nil, ErrNotQuarantined
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, e := range q.events {
+		if e.ID == id {
+			q.events = append(q.events[:i:i], q.events[i+1:]...)
+			return e.Event, nil
+		}
+	}
+	return nil, ErrNotQuarantined
+}
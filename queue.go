@@ -0,0 +1,287 @@
+package sentryhook
+
+import (
+	"errors"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultQueueSize is the number of events buffered in asynchronous mode.
+const defaultQueueSize = 1000
+
+var (
+	// ErrQueueFull is reported when an asynchronous hook drops an event
+	// because its queue is full.
+	ErrQueueFull = errors.New("sentryhook: queue is full")
+	// ErrClosed is returned when logging to or closing a closed hook.
+	ErrClosed = errors.New("sentryhook: hook is closed")
+	// ErrShutdownTimeout is reported for events which were still queued
+	// when Close ran out of time and no dead letter handler is configured.
+	ErrShutdownTimeout = errors.New("sentryhook: event not delivered before shutdown deadline")
+	// ErrStale is reported when an asynchronous hook drops an event which
+	// waited in the queue longer than the maximum queue age.
+	ErrStale = errors.New("sentryhook: event exceeded the maximum queue age")
+)
+
+// WithQueueSize sets how many events an asynchronous hook buffers before it
+// starts dropping them.
+func WithQueueSize(size int) Option {
+	return func(hook *SentryHook) {
+		hook.queueSize = size
+	}
+}
+
+// WithMaxQueueAge makes an asynchronous hook drop events which waited in
+// the queue longer than age instead of sending them, so that a backlog is
+// worked off quickly after an outage. Zero, the default, keeps all events.
+func WithMaxQueueAge(age time.Duration) Option {
+	return func(hook *SentryHook) {
+		hook.maxQueueAge = age
+	}
+}
+
+// WithDeadLetter sets a handler for events which were still queued when
+// Close ran out of time, e.g. to write them to disk.
+func WithDeadLetter(handler func(event *sentrygo.Event)) Option {
+	return func(hook *SentryHook) {
+		hook.deadLetter = handler
+	}
+}
+
+// WithRetries makes an asynchronous hook retry failed deliveries up to n
+// times, waiting backoff before the first retry and doubling it for every
+// further one. A queued event keeps track of the destinations it was
+// delivered to, so a retry only resends it to those that failed. Events
+// handed to a sentry client are only retried if the server could not be
+// reached or answered with a temporary error status, see TransportError;
+// after a timeout its transport may still deliver them. Retries hold up
+// the worker and end when the hook is closed, dead lettering the remaining
+// deliveries.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(hook *SentryHook) {
+		hook.retries = n
+		hook.retryBackoff = backoff
+	}
+}
+
+// deliveryState is the state of a delivery of a queued event.
+type deliveryState int
+
+const (
+	// not delivered yet, or failed and to be retried
+	deliveryPending deliveryState = iota
+	deliveryDone
+	// failed and not to be retried
+	deliveryFailed
+)
+
+// delivery is the delivery of a queued event to a destination, or to the
+// hook's own client if dest is nil.
+type delivery struct {
+	dest  *destination
+	event *sentrygo.Event
+	// the room reserved within the in-flight limits
+	size  int
+	state deliveryState
+	err   error
+}
+
+// queuedEvent is an event waiting in the queue of an asynchronous hook,
+// with its deliveries to the hook's client and the destinations.
+type queuedEvent struct {
+	deliveries []*delivery
+	hook       *SentryHook
+	enqueued   time.Time
+	// uploaded once the event is delivered to the hook's own client
+	attachments []Attachment
+}
+
+// add adds a delivery of the event to the destination, if it is admitted
+// within the in-flight limits.
+func (item *queuedEvent) add(dest *destination, event *sentrygo.Event) {
+	size, err := item.hook.admit(event)
+	if err != nil {
+		item.hook.reportError(dest, event, err)
+		return
+	}
+	item.deliveries = append(item.deliveries, &delivery{dest: dest, event: event, size: size})
+}
+
+// pending returns the deliveries not completed yet.
+func (item *queuedEvent) pending() []*delivery {
+	var pending []*delivery
+	for _, d := range item.deliveries {
+		if d.state == deliveryPending {
+			pending = append(pending, d)
+		}
+	}
+	return pending
+}
+
+// start launches the delivery worker of an asynchronous hook. Hooks
+// attached to a core use the core's workers instead.
+func (hook *SentryHook) start() {
+	hook.startOnce.Do(func() {
+		hook.stop = make(chan struct{})
+		if hook.core != nil {
+			return
+		}
+		size := hook.queueSize
+		if size <= 0 {
+			size = defaultQueueSize
+		}
+		hook.queue = make(chan *queuedEvent, size)
+		hook.workers.Add(1)
+		go hook.worker()
+	})
+}
+
+func (hook *SentryHook) worker() {
+	defer hook.workers.Done()
+	for item := range hook.queue {
+		hook.process(item)
+	}
+}
+
+// process delivers a queued event of the hook, or dead letters it once the
+// hook is closing.
+func (hook *SentryHook) process(item *queuedEvent) {
+	defer hook.wg.Done()
+	defer func() {
+		for _, d := range item.deliveries {
+			hook.releaseInFlight(d.size)
+		}
+	}()
+	select {
+	case <-hook.stop:
+		hook.deadLetterEvent(item)
+		return
+	default:
+	}
+	waited := hook.now().Sub(item.enqueued)
+	stale := hook.maxQueueAge > 0 && waited > hook.maxQueueAge
+	hook.stats.update(func(stats *Stats) {
+		stats.QueueLatency.observe(waited)
+		if stale {
+			stats.DroppedStale += int64(len(item.deliveries))
+		}
+	})
+	if stale {
+		for _, d := range item.deliveries {
+			hook.reportError(d.dest, d.event, ErrStale)
+		}
+		return
+	}
+
+	backoff := hook.retryBackoff
+	for attempt := 0; ; attempt++ {
+		hook.attempt(item)
+		if len(item.pending()) == 0 {
+			break
+		}
+		if attempt >= hook.retries {
+			for _, d := range item.pending() {
+				d.state = deliveryFailed
+			}
+			break
+		}
+		hook.debug(nil, logrus.Fields{"pending": len(item.pending()), "attempt": attempt + 1, "backoff": backoff}, "retrying delivery")
+		timer := time.NewTimer(backoff)
+		select {
+		case <-hook.stop:
+			timer.Stop()
+			hook.deadLetterEvent(item)
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+		hook.stats.update(func(stats *Stats) {
+			stats.Retried += int64(len(item.pending()))
+		})
+	}
+	for _, d := range item.deliveries {
+		if d.state == deliveryFailed {
+			hook.reportError(d.dest, d.event, d.err)
+		}
+	}
+}
+
+// attempt delivers the queued event to the destinations it has not been
+// delivered to yet.
+func (hook *SentryHook) attempt(item *queuedEvent) {
+	for _, d := range item.pending() {
+		client := (d.dest == nil || d.dest.sink == nil) && hook.dryRun == nil
+		event := d.event
+		if client && hook.retries > 0 {
+			// the client modifies the events it captures; a retry resends
+			// the event as it was queued
+			if event.EventID == "" {
+				event.EventID = sentrygo.EventID(hook.newID())
+			}
+			event = cloneEvent(event)
+		}
+		d.err = hook.deliver(d.dest, event)
+		var transportErr TransportError
+		switch {
+		case d.err == nil:
+			d.state = deliveryDone
+			if d.dest == nil {
+				hook.sendAttachments(d.event, item.attachments)
+			}
+		case client && errors.As(d.err, &transportErr) && transportErr.temporary():
+		case client || hook.dryRun != nil:
+			// a client may still deliver it
+			d.state = deliveryFailed
+		}
+	}
+}
+
+// enqueue hands the event with its deliveries to the worker without
+// blocking. It must be called with hook.mu held for reading.
+func (hook *SentryHook) enqueue(item *queuedEvent) {
+	if len(item.deliveries) == 0 {
+		return
+	}
+	hook.wg.Add(1)
+	item.enqueued = hook.now()
+	err := ErrQueueFull
+	if hook.core != nil {
+		err = hook.core.push(item)
+	} else {
+		select {
+		case hook.queue <- item:
+			err = nil
+		default:
+		}
+	}
+	if err != nil {
+		hook.wg.Done()
+		hook.stats.update(func(stats *Stats) {
+			stats.Dropped += int64(len(item.deliveries))
+		})
+		for _, d := range item.deliveries {
+			hook.releaseInFlight(d.size)
+			hook.reportError(d.dest, d.event, err)
+		}
+	}
+}
+
+// deadLetterEvent hands the deliveries of the queued event not completed
+// yet to the dead letter handler.
+func (hook *SentryHook) deadLetterEvent(item *queuedEvent) {
+	for _, d := range item.deliveries {
+		if d.state == deliveryDone {
+			continue
+		}
+		hook.stats.update(func(stats *Stats) {
+			stats.DeadLettered++
+		})
+		if hook.deadLetter != nil {
+			hook.deadLetter(d.event)
+			continue
+		}
+		hook.reportError(d.dest, d.event, ErrShutdownTimeout)
+	}
+}
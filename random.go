@@ -0,0 +1,43 @@
+package sentryhook
+
+import (
+	"encoding/hex"
+	"math/rand"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithDeterministicMode makes the random decisions of the hook, like
+// sampling, reproducible from the seed and gives events ids drawn from it
+// instead of random ones. Combined with WithTimeSource and fixed entry times
+// this produces byte-identical events for table-driven and golden file
+// tests. Sampling done by the client itself is not affected.
+func WithDeterministicMode(seed int64) Option {
+	return func(hook *SentryHook) {
+		hook.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// random returns a number in [0.0,1.0) from the hook's source.
+func (hook *SentryHook) random() float64 {
+	if hook.rng == nil {
+		return rand.Float64()
+	}
+	hook.rngMu.Lock()
+	defer hook.rngMu.Unlock()
+	return hook.rng.Float64()
+}
+
+// deterministicEventID returns an event id drawn from the hook's source, or
+// an empty id if the hook is not in deterministic mode and the client should
+// generate one.
+func (hook *SentryHook) deterministicEventID() sentrygo.EventID {
+	if hook.rng == nil {
+		return ""
+	}
+	var id [16]byte
+	hook.rngMu.Lock()
+	hook.rng.Read(id[:])
+	hook.rngMu.Unlock()
+	return sentrygo.EventID(hex.EncodeToString(id[:]))
+}
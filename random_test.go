@@ -0,0 +1,48 @@
+package sentryhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDeterministicMode(t *testing.T) {
+	ids := func(seed int64) []string {
+		server := NewMockServer()
+		defer server.Close()
+		hook, err := NewSentryHook(server.DSN(), WithDeterministicMode(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		for i := 0; i < 3; i++ {
+			log.WithTime(time.Unix(0, 0)).Error("boom")
+		}
+		var ids []string
+		for _, event := range server.Events() {
+			ids = append(ids, string(event.EventID))
+		}
+		if len(ids) != 3 {
+			t.Fatalf("expected 3 events, got %d", len(ids))
+		}
+		return ids
+	}
+
+	first, second, other := ids(42), ids(42), ids(7)
+	for i := range first {
+		if len(first[i]) != 32 {
+			t.Fatalf("expected a 32 character event id, got %q", first[i])
+		}
+		if first[i] != second[i] {
+			t.Fatalf("expected the same ids for the same seed, got %v and %v", first, second)
+		}
+		if first[i] == other[i] {
+			t.Fatalf("expected different ids for different seeds, got %v", first)
+		}
+	}
+	if first[0] == first[1] {
+		t.Fatalf("expected distinct ids, got %v", first)
+	}
+}
@@ -0,0 +1,88 @@
+package sentryhook
+
+import (
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// RecentEvent describes an event sent by the hook.
+type RecentEvent struct {
+	ID sentrygo.EventID
+	// a hash of what the hook considers the same kind of event: the explicit
+	// fingerprint if set, otherwise level, message and exception types
+	Fingerprint string
+	Level       sentrygo.Level
+	Message     string
+	Time        time.Time
+	Tags        map[string]string
+}
+
+// recentEvents is a ring buffer of the last events sent.
+type recentEvents struct {
+	mu     sync.Mutex
+	events []RecentEvent
+	next   int
+	full   bool
+}
+
+// WithRecentEvents keeps an index of the last n events sent, which can be
+// queried with RecentEvents, e.g. to show recent errors on a debug page
+// without calling the Sentry API.
+func WithRecentEvents(n int) Option {
+	return func(hook *SentryHook) {
+		if n <= 0 {
+			hook.recent = nil
+			return
+		}
+		hook.recent = &recentEvents{events: make([]RecentEvent, n)}
+	}
+}
+
+// RecentEvents returns the indexed events the filter accepts, newest first.
+// A nil filter accepts all events. It returns nil unless WithRecentEvents
+// is configured.
+func (hook *SentryHook) RecentEvents(filter func(event RecentEvent) bool) []RecentEvent {
+	r := hook.recent
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next
+	if r.full {
+		n = len(r.events)
+	}
+	var events []RecentEvent
+	for i := 1; i <= n; i++ {
+		event := r.events[(r.next-i+len(r.events))%len(r.events)]
+		if filter == nil || filter(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// recordRecent adds a sent event to the index.
+func (hook *SentryHook) recordRecent(eventID sentrygo.EventID, event *sentrygo.Event) {
+	r := hook.recent
+	if r == nil {
+		return
+	}
+	recent := RecentEvent{
+		ID:          eventID,
+		Fingerprint: fingerprintHash(event),
+		Level:       event.Level,
+		Message:     event.Message,
+		Time:        event.Timestamp,
+		Tags:        copyTags(event.Tags, 0),
+	}
+	r.mu.Lock()
+	r.events[r.next] = recent
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+}
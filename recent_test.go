@@ -0,0 +1,47 @@
+package sentryhook
+
+import (
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRecentEvents(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithRecentEvents(3), WithTags(map[string]string{"team": "a"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := hook.RecentEvents(nil); len(events) != 0 {
+		t.Fatalf("expected no events yet, got %+v", events)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("one")
+	log.Warn("two")
+	log.Error("three")
+	log.Error("one")
+
+	events := hook.RecentEvents(nil)
+	if len(events) != 3 {
+		t.Fatalf("expected the last 3 events, got %d", len(events))
+	}
+	if events[0].Message != "one" || events[1].Message != "three" || events[2].Message != "two" {
+		t.Fatalf("expected newest first, got %+v", events)
+	}
+	if events[0].ID != hook.LastEventID() || events[0].Tags["team"] != "a" {
+		t.Fatalf("unexpected newest event %+v", events[0])
+	}
+	if events[0].Fingerprint == events[1].Fingerprint {
+		t.Fatal("expected different fingerprints for different messages")
+	}
+
+	errors := hook.RecentEvents(func(event RecentEvent) bool {
+		return event.Level == sentrygo.LevelError
+	})
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", errors)
+	}
+}
@@ -0,0 +1,137 @@
+package sentryhook
+
+import (
+	"fmt"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// levelsUpTo returns all levels at least as severe as level.
+func levelsUpTo(level logrus.Level) []logrus.Level {
+	levels := make([]logrus.Level, 0, len(logrus.AllLevels))
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// SetLevel makes the hook fire for entries of the level and all more severe
+// levels. It is safe to call while logging.
+func (hook *SentryHook) SetLevel(level logrus.Level) {
+	hook.SetLevels(levelsUpTo(level))
+}
+
+// SetLevels makes the hook fire for entries of exactly the given levels. It
+// is safe to call while logging.
+func (hook *SentryHook) SetLevels(levels []logrus.Level) {
+	levels = append([]logrus.Level(nil), levels...)
+	hook.configMu.Lock()
+	hook.levels = levels
+	hook.configMu.Unlock()
+}
+
+// SetFormatter replaces the formatter set by WithFormatter, nil removes it.
+// It is safe to call while logging.
+func (hook *SentryHook) SetFormatter(formatter logrus.Formatter) {
+	hook.configMu.Lock()
+	hook.formatter = formatter
+	hook.configMu.Unlock()
+}
+
+// SetTag adds or replaces a static tag. It is safe to call while logging.
+func (hook *SentryHook) SetTag(key, value string) {
+	hook.configMu.Lock()
+	defer hook.configMu.Unlock()
+	tags := copyTags(hook.tags, 1)
+	tags[key] = value
+	hook.tags = tags
+}
+
+// DeleteTag removes a static tag. It is safe to call while logging.
+func (hook *SentryHook) DeleteTag(key string) {
+	hook.configMu.Lock()
+	defer hook.configMu.Unlock()
+	tags := copyTags(hook.tags, 0)
+	delete(tags, key)
+	hook.tags = tags
+}
+
+// ApplyConfig swaps the levels, static tags, sample rate, scrubbers and
+// rules of the hook for those of the configuration, e.g. when a watched
+// configuration file or ConfigMap changes. Logging continues while it runs:
+// each setting is swapped at once, but an event built meanwhile may see
+// some settings old and others new, e.g. the old levels and the new rules.
+// The DSN, timeouts, asynchronous mode and stacktrace settings are fixed
+// when the hook is created; use SetClient to switch the client.
+func (hook *SentryHook) ApplyConfig(config Config) error {
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return fmt.Errorf("sentryhook: invalid sample rate %v, expected a value between 0 and 1", config.SampleRate)
+	}
+	levels := append([]logrus.Level(nil), config.Levels...)
+	if config.Levels == nil {
+		levels = DefaultLevels()
+	}
+	tags := copyTags(config.Tags, 0)
+	rules := append([]Rule(nil), config.Rules...)
+	scrubbers := append([]func(event *sentrygo.Event) *sentrygo.Event(nil), config.Scrubbers...)
+
+	hook.configMu.Lock()
+	defer hook.configMu.Unlock()
+	hook.levels = levels
+	hook.tags = tags
+	hook.sampleRate = config.SampleRate
+	hook.scrubbers = scrubbers
+	hook.rules = rules
+	return nil
+}
+
+// scrub applies the scrubbers to the event, nil if one drops it.
+func (hook *SentryHook) scrub(event *sentrygo.Event) *sentrygo.Event {
+	hook.configMu.RLock()
+	scrubbers := hook.scrubbers
+	hook.configMu.RUnlock()
+	for _, scrubber := range scrubbers {
+		if event = scrubber(event); event == nil {
+			return nil
+		}
+	}
+	return event
+}
+
+// enabled reports whether the hook fires for the level.
+func (hook *SentryHook) enabled(level logrus.Level) bool {
+	hook.configMu.RLock()
+	defer hook.configMu.RUnlock()
+	for _, l := range hook.levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// currentFormatter returns the configured formatter.
+func (hook *SentryHook) currentFormatter() logrus.Formatter {
+	hook.configMu.RLock()
+	defer hook.configMu.RUnlock()
+	return hook.formatter
+}
+
+// staticTags returns the configured tags. The map is replaced, never
+// modified, on updates and must not be modified by the caller.
+func (hook *SentryHook) staticTags() map[string]string {
+	hook.configMu.RLock()
+	defer hook.configMu.RUnlock()
+	return hook.tags
+}
+
+func copyTags(tags map[string]string, extra int) map[string]string {
+	c := make(map[string]string, len(tags)+extra)
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
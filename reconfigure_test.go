@@ -0,0 +1,194 @@
+package sentryhook
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"regexp"
+	"sync"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRuntimeReconfiguration(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithLevel(logrus.ErrorLevel), WithTags(map[string]string{"team": "a"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Warn("ignored")
+	hook.SetLevel(logrus.WarnLevel)
+	hook.SetTag("team", "b")
+	log.Warn("captured")
+	hook.DeleteTag("team")
+	hook.SetLevels([]logrus.Level{logrus.ErrorLevel})
+	log.Warn("ignored again")
+	log.Error("untagged")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Message != "captured" || events[0].Tags["team"] != "b" {
+		t.Fatalf("unexpected first event %+v", events[0])
+	}
+	if _, ok := events[1].Tags["team"]; ok {
+		t.Fatalf("expected the tag to be deleted, got %+v", events[1].Tags)
+	}
+}
+
+func TestConcurrentReconfiguration(t *testing.T) {
+	hook, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			log.Error("concurrent")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			hook.SetTag("i", "x")
+			hook.SetLevel(logrus.AllLevels[i%len(logrus.AllLevels)])
+			hook.DeleteTag("i")
+		}
+	}()
+	wg.Wait()
+}
+
+func TestApplyConfig(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithTags(map[string]string{"team": "a"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	err = hook.ApplyConfig(Config{
+		Levels: []logrus.Level{logrus.WarnLevel},
+		Tags:   map[string]string{"team": "b"},
+		Rules:  []Rule{{Message: regexp.MustCompile("^noisy"), Action: RuleDrop}},
+		Scrubbers: []func(event *sentrygo.Event) *sentrygo.Event{func(event *sentrygo.Event) *sentrygo.Event {
+			delete(event.Extra, "email")
+			return event
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Error("ignored")
+	log.Warn("noisy retry")
+	log.WithField("email", "jane@example.com").Warn("captured")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Message != "captured" {
+		t.Fatalf("expected only the captured event, got %+v", events)
+	}
+	if events[0].Tags["team"] != "b" || events[0].Extra["email"] != nil {
+		t.Fatalf("expected the new tags and scrubbers, got %+v", events[0])
+	}
+
+	if err := hook.ApplyConfig(Config{SampleRate: 1e-9}); err != nil {
+		t.Fatal(err)
+	}
+	log.Error("sampled out")
+	if len(server.Events()) != 1 || hook.Stats().Sampled != 1 {
+		t.Fatalf("expected the event to be sampled out, got %+v", hook.Stats())
+	}
+	if err := hook.ApplyConfig(Config{SampleRate: 2}); err == nil {
+		t.Fatal("expected an error for an invalid sample rate")
+	}
+}
+
+// TestConcurrentConfiguration is meant to run with the race detector: it
+// logs from several goroutines, synchronously and asynchronously, while
+// the configuration is changed.
+func TestConcurrentConfiguration(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	for _, async := range []bool{false, true} {
+		hook, err := NewSentryHook(server.DSN(), WithAsync(async), WithExitHandler(false),
+			WithFormatter(&logrus.TextFormatter{}), WithSink("archive", WriterSink(ioutil.Discard), Profile{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 25; i++ {
+					log.WithField("i", i).Error("concurrent")
+					_ = hook.Stats()
+					_ = hook.LastEventID()
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				hook.SetFormatter(&logrus.JSONFormatter{})
+				hook.SetTag("round", "x")
+				hook.SetRules([]Rule{{Message: regexp.MustCompile("^never"), Action: RuleDrop}})
+				_ = hook.ApplyConfig(Config{Tags: map[string]string{"team": "b"}, SampleRate: 0.5})
+				hook.SetFormatter(nil)
+				hook.DeleteTag("team")
+				_ = hook.ConfigHash()
+			}
+		}()
+		wg.Wait()
+		hook.Flush()
+	}
+}
+
+func TestScrubbersOnCapturedEvents(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.DSN = server.DSN()
+	config.Scrubbers = []func(event *sentrygo.Event) *sentrygo.Event{func(event *sentrygo.Event) *sentrygo.Event {
+		event.Tags["scrubbed"] = "true"
+		return event
+	}}
+	hook, err := NewFromConfig(config, WithStartupEvent(), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hook.CapturePanic("token=abc", nil); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sh", "-c", "exit 3")
+	if err := hook.CaptureExec(cmd, cmd.Run()); err != nil {
+		t.Fatal(err)
+	}
+
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected the startup, panic and exec events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Tags["scrubbed"] != "true" {
+			t.Fatalf("expected the event %q to be scrubbed, got %+v", event.Message, event.Tags)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package sentryhook
+
+import (
+	"runtime/debug"
+)
+
+// Release is the release reported by WithAutoRelease. It is meant to be set
+// at build time:
+//
+//	go build -ldflags "-X github.com/ainiaa/sentryhook.Release=v1.2.3"
+var Release string
+
+// WithRelease sets the release events are reported for. It is set on the
+// client options, so it has no effect on a client passed to
+// NewWithClientSentryHook.
+func WithRelease(release string) Option {
+	return func(hook *SentryHook) {
+		hook.release = release
+	}
+}
+
+// WithAutoRelease derives the release from the binary. It uses, in order,
+// the Release variable set with -ldflags, the version of the main module,
+// or the VCS revision embedded by the Go toolchain.
+func WithAutoRelease() Option {
+	return func(hook *SentryHook) {
+		info, _ := debug.ReadBuildInfo()
+		if release := detectRelease(Release, info); release != "" {
+			hook.release = release
+		}
+	}
+}
+
+// detectRelease returns the release of a binary built with the release
+// variable and the build info, an empty string if the binary carries none.
+func detectRelease(release string, info *debug.BuildInfo) string {
+	if release != "" {
+		return release
+	}
+	if info == nil {
+		return ""
+	}
+	if version := info.Main.Version; version != "" && version != "(devel)" {
+		return info.Main.Path + "@" + version
+	}
+	return vcsRevision(info)
+}
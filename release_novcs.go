@@ -0,0 +1,12 @@
+//go:build !go1.18
+// +build !go1.18
+
+package sentryhook
+
+import "runtime/debug"
+
+// vcsRevision returns an empty string; build info carries no VCS revision
+// before Go 1.18.
+func vcsRevision(info *debug.BuildInfo) string {
+	return ""
+}
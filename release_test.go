@@ -0,0 +1,55 @@
+//go:build go1.18
+// +build go1.18
+
+package sentryhook
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestDetectRelease(t *testing.T) {
+	vcs := []debug.BuildSetting{{Key: "vcs.revision", Value: "4f2a9c1"}, {Key: "vcs.modified", Value: "false"}}
+	tests := []struct {
+		name    string
+		release string
+		info    *debug.BuildInfo
+		want    string
+	}{
+		{
+			name:    "ldflags",
+			release: "v1.2.3",
+			info:    &debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "v0.9.0"}, Settings: vcs},
+			want:    "v1.2.3",
+		},
+		{
+			name: "main module version",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "v0.9.0"}, Settings: vcs},
+			want: "example.com/app@v0.9.0",
+		},
+		{
+			name: "vcs revision",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "(devel)"}, Settings: vcs},
+			want: "4f2a9c1",
+		},
+		{
+			name: "modified vcs revision",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "example.com/app"}, Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "4f2a9c1"}, {Key: "vcs.modified", Value: "true"},
+			}},
+			want: "4f2a9c1-dirty",
+		},
+		{
+			name: "no vcs",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "(devel)"}},
+		},
+		{
+			name: "no build info",
+		},
+	}
+	for _, test := range tests {
+		if got := detectRelease(test.release, test.info); got != test.want {
+			t.Errorf("%s: expected release %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build go1.18
+// +build go1.18
+
+package sentryhook
+
+import "runtime/debug"
+
+// vcsRevision returns the VCS revision the binary was built from, marked
+// with a "-dirty" suffix if the working tree had local modifications.
+func vcsRevision(info *debug.BuildInfo) string {
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if revision != "" && modified == "true" {
+		revision += "-dirty"
+	}
+	return revision
+}
@@ -0,0 +1,137 @@
+package sentryhook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestField is the default field holding the *http.Request an entry is
+// about, see WithRequestFields.
+const RequestField = "http_request"
+
+// filtered replaces sensitive values.
+const filtered = "[Filtered]"
+
+// sensitiveNames are parts of header and query parameter names whose values
+// are filtered.
+var sensitiveNames = []string{
+	"auth", "cookie", "password", "passwd", "secret", "token",
+	"api_key", "apikey", "api-key", "session", "signature", "credential",
+}
+
+// RequestFields names the entry fields mapped into the HTTP request of
+// events, so web errors show the request in Sentry. Headers and query
+// parameters with sensitive names are filtered; cookies and the remote
+// address are left out.
+type RequestFields struct {
+	// the field holding an *http.Request; it is not sent as extra data
+	Request string
+	// fields holding the method, URL and response status code, used for
+	// entries without a request object
+	Method string
+	URL    string
+	Status string
+	// additional parts of header and query parameter names to filter
+	Sensitive []string
+}
+
+// WithRequestFields sets the entry fields mapped into the HTTP request of
+// events. By default only the RequestField holding an *http.Request is
+// mapped.
+func WithRequestFields(fields RequestFields) Option {
+	return func(hook *SentryHook) {
+		hook.requestFields = fields
+	}
+}
+
+// addRequest maps the request fields of the entry into the event.
+func (hook *SentryHook) addRequest(event *sentrygo.Event, entry *logrus.Entry) {
+	fields := hook.requestFields
+	var request *sentrygo.Request
+	if r, ok := entry.Data[fields.Request].(*http.Request); ok && r != nil && fields.Request != "" {
+		request = sentrygo.NewRequest(r)
+	} else {
+		method, hasMethod := entry.Data[fields.Method]
+		rawURL, hasURL := entry.Data[fields.URL]
+		if (hasMethod && fields.Method != "") || (hasURL && fields.URL != "") {
+			request = &sentrygo.Request{}
+			if hasMethod {
+				request.Method = fmt.Sprint(method)
+			}
+			if hasURL {
+				request.URL = fmt.Sprint(rawURL)
+				if u, err := url.Parse(request.URL); err == nil {
+					request.QueryString = u.RawQuery
+					u.RawQuery = ""
+					u.Fragment = ""
+					request.URL = u.String()
+				}
+			}
+		}
+	}
+	if request != nil {
+		hook.sanitizeRequest(request)
+		event.Request = request
+	}
+
+	if status, ok := entry.Data[fields.Status]; ok && fields.Status != "" {
+		code := fmt.Sprint(status)
+		if event.Contexts == nil {
+			event.Contexts = make(map[string]interface{})
+		}
+		response := map[string]interface{}{"status_code": code}
+		if n, err := strconv.Atoi(code); err == nil {
+			response["status_code"] = n
+		}
+		event.Contexts["response"] = response
+		event.Tags["http.status_code"] = code
+	}
+}
+
+// sanitizeRequest filters sensitive headers and query parameters and drops
+// cookies and the remote address.
+func (hook *SentryHook) sanitizeRequest(request *sentrygo.Request) {
+	request.Cookies = ""
+	for k := range request.Headers {
+		if strings.EqualFold(k, "Cookie") {
+			delete(request.Headers, k)
+		} else if hook.sensitive(k) {
+			request.Headers[k] = filtered
+		}
+	}
+	if request.QueryString != "" {
+		if values, err := url.ParseQuery(request.QueryString); err == nil {
+			for k := range values {
+				if hook.sensitive(k) {
+					values[k] = []string{filtered}
+				}
+			}
+			request.QueryString = values.Encode()
+		} else {
+			request.QueryString = filtered
+		}
+	}
+	request.Env = nil
+}
+
+// sensitive reports whether values of a header or parameter are filtered.
+func (hook *SentryHook) sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	for _, s := range hook.requestFields.Sensitive {
+		if strings.Contains(name, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
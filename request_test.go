@@ -0,0 +1,84 @@
+package sentryhook
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRequestFromField(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	r := httptest.NewRequest("POST", "http://shop.example.com/checkout?item=7&access_token=abc", nil)
+	r.Header.Set("Authorization", "Bearer abc")
+	r.Header.Set("Cookie", "session=abc")
+	r.Header.Set("User-Agent", "test")
+	log.WithField(RequestField, r).Error("checkout failed")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Request == nil {
+		t.Fatalf("expected an event with a request, got %+v", events)
+	}
+	request := events[0].Request
+	if request.Method != "POST" || request.URL != "http://shop.example.com/checkout" {
+		t.Fatalf("unexpected request %+v", request)
+	}
+	if request.QueryString != "access_token=%5BFiltered%5D&item=7" {
+		t.Fatalf("expected the token to be filtered, got %q", request.QueryString)
+	}
+	if request.Headers["Authorization"] != filtered || request.Headers["User-Agent"] != "test" {
+		t.Fatalf("unexpected headers %v", request.Headers)
+	}
+	if _, ok := request.Headers["Cookie"]; ok || request.Cookies != "" || request.Env != nil {
+		t.Fatalf("expected cookies and the remote address to be dropped, got %+v", request)
+	}
+	if _, ok := events[0].Extra[RequestField]; ok {
+		t.Fatal("expected the request not to be sent as extra data")
+	}
+}
+
+func TestRequestFromFields(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithRequestFields(RequestFields{
+		Method:    "method",
+		URL:       "url",
+		Status:    "status",
+		Sensitive: []string{"card"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithFields(logrus.Fields{
+		"method": "GET",
+		"url":    "https://api.example.com/pay?card_number=4111&amount=3",
+		"status": 502,
+	}).Error("upstream failed")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Request == nil {
+		t.Fatalf("expected an event with a request, got %+v", events)
+	}
+	request := events[0].Request
+	if request.Method != "GET" || request.URL != "https://api.example.com/pay" ||
+		request.QueryString != "amount=3&card_number=%5BFiltered%5D" {
+		t.Fatalf("unexpected request %+v", request)
+	}
+	if events[0].Tags["http.status_code"] != "502" {
+		t.Fatalf("unexpected tags %v", events[0].Tags)
+	}
+	response, _ := events[0].Contexts["response"].(map[string]interface{})
+	if response["status_code"] != float64(502) {
+		t.Fatalf("unexpected response context %v", events[0].Contexts["response"])
+	}
+}
@@ -0,0 +1,57 @@
+package sentryhook
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrMissingTags is returned by Fire for events rejected because required
+// tags are missing, see WithRequiredTags.
+var ErrMissingTags = errors.New("sentryhook: event is missing required tags")
+
+// missingTagsTag lists the required tags an event is missing.
+const missingTagsTag = "missing_required_tags"
+
+// WithRequiredTags lists tags every event must have, from any source
+// including the scope, e.g. "service", "env" and "version". Events missing
+// some of them are tagged "missing_required_tags" with the missing keys or,
+// if strict, rejected with ErrMissingTags and counted in Stats.Rejected.
+func WithRequiredTags(strict bool, keys ...string) Option {
+	return func(hook *SentryHook) {
+		hook.requiredTags = append([]string(nil), keys...)
+		hook.strictRequiredTags = strict
+	}
+}
+
+// checkRequiredTags annotates an event missing required tags, or returns
+// ErrMissingTags in strict mode.
+func (hook *SentryHook) checkRequiredTags(event *sentrygo.Event, entry *logrus.Entry) error {
+	var missing, scope []string
+	for _, k := range hook.requiredTags {
+		if _, ok := event.Tags[k]; !ok {
+			scope = append(scope, k)
+		}
+	}
+	if len(scope) > 0 {
+		tags := hook.sourceTags(TagSourceScope, entry)
+		for _, k := range scope {
+			if _, ok := tags[k]; !ok {
+				missing = append(missing, k)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if hook.strictRequiredTags {
+		hook.stats.update(func(stats *Stats) { stats.Rejected++ })
+		return ErrMissingTags
+	}
+	sort.Strings(missing)
+	event.Tags[missingTagsTag] = strings.Join(missing, ",")
+	return nil
+}
@@ -0,0 +1,43 @@
+package sentryhook
+
+import (
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRequiredTags(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hub := sentrygo.NewHub(nil, sentrygo.NewScope())
+	hub.Scope().SetTag("env", "prod")
+	hook, err := NewSentryHook(server.DSN(), WithHub(hub), WithTags(map[string]string{"service": "billing"}),
+		WithRequiredTags(false, "service", "env", "version", "region"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("boom")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Tags[missingTagsTag] != "region,version" {
+		t.Fatalf("expected the missing tags annotated, got %+v", events)
+	}
+}
+
+func TestRequiredTagsStrict(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithRequiredTags(true, "service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.Fire(logrus.NewEntry(logrus.New()).WithField("service", "billing")); err != ErrMissingTags {
+		t.Fatalf("expected ErrMissingTags, got %v", err)
+	}
+	if len(server.Events()) != 0 || hook.Stats().Rejected != 1 {
+		t.Fatalf("expected the event to be rejected, got %d events and stats %+v", len(server.Events()), hook.Stats())
+	}
+}
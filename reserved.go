@@ -0,0 +1,64 @@
+package sentryhook
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SkipField is a reserved field which, when set to true, keeps the entry
+// from being sent to sentry regardless of its level:
+//
+//	log.WithField(sentryhook.SkipField, true).Error("local only")
+const SkipField = "sentry_skip"
+
+// reservedFields are fields interpreted by the hook; they are never sent
+// as extra data.
+var reservedFields = map[string]bool{
+	SkipField:         true,
+	SkipFramesField:   true,
+	TraceField:        true,
+	AttachmentsField:  true,
+	SampleWeightField: true,
+}
+
+type skipKey struct{}
+
+// SkipContext returns a context which keeps entries logged with it, via
+// logrus' WithContext, from being sent to sentry.
+func SkipContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// skipped reports whether the entry opted out of being sent.
+func skipped(entry *logrus.Entry) bool {
+	if skip, ok := entry.Data[SkipField].(bool); ok && skip {
+		return true
+	}
+	if entry.Context != nil {
+		if skip, ok := entry.Context.Value(skipKey{}).(bool); ok && skip {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFilter adds a filter deciding which entries are sent: entries for
+// which it returns false are dropped before any work is done, so known
+// noise like health checks or expected errors costs next to nothing.
+// Filters run on the logging goroutine, in the order they were added.
+func WithFilter(filter func(entry *logrus.Entry) bool) Option {
+	return func(hook *SentryHook) {
+		hook.filters = append(hook.filters, filter)
+	}
+}
+
+// accepts reports whether all filters let the entry through.
+func (hook *SentryHook) accepts(entry *logrus.Entry) bool {
+	for _, filter := range hook.filters {
+		if !filter(entry) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSkipAnnotations(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.WithField(SkipField, true).Error("skipped by field")
+	log.WithContext(SkipContext(context.Background())).Error("skipped by context")
+	log.WithField(SkipField, false).Error("sent")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Message != "sent" {
+		t.Fatalf("expected only the unskipped event, got %+v", events)
+	}
+	if _, ok := events[0].Extra[SkipField]; ok {
+		t.Fatal("expected the reserved field to be left out of extra data")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	var formatted int
+	hook, err := NewSentryHook(server.DSN(),
+		WithFormatter(formatterFunc(func(entry *logrus.Entry) ([]byte, error) {
+			formatted++
+			return []byte(entry.Message), nil
+		})),
+		WithFilter(func(entry *logrus.Entry) bool {
+			return entry.Data["path"] != "/healthz"
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.WithField("path", "/healthz").Error("probe failed")
+	log.WithField("path", "/orders").Error("order failed")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Message != "order failed" {
+		t.Fatalf("expected only the unfiltered event, got %+v", events)
+	}
+	if formatted != 1 {
+		t.Fatalf("expected the filtered entry not to be formatted, got %d calls", formatted)
+	}
+}
+
+type formatterFunc func(entry *logrus.Entry) ([]byte, error)
+
+func (f formatterFunc) Format(entry *logrus.Entry) ([]byte, error) {
+	return f(entry)
+}
@@ -0,0 +1,37 @@
+package sentryhook
+
+import (
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// processStart approximates the start of the process for the uptime.
+var processStart = time.Now()
+
+// WithResourceContext controls whether Fatal and Panic events get a
+// "resources" context with the resource usage and limits of the process,
+// which is the default: open file descriptors and their limit, resident
+// memory, CPU time and uptime, so crashes caused by resource exhaustion
+// stand out. Values which cannot be read on the platform are left out.
+func WithResourceContext(enable bool) Option {
+	return func(hook *SentryHook) {
+		hook.noResourceContext = !enable
+	}
+}
+
+// addResourceContext fills the resources context of fatal events.
+func (hook *SentryHook) addResourceContext(event *sentrygo.Event, level logrus.Level) {
+	if hook.noResourceContext || level > logrus.FatalLevel {
+		return
+	}
+	resources := map[string]interface{}{
+		"uptime_seconds": time.Since(processStart).Seconds(),
+	}
+	readResources(resources)
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]interface{})
+	}
+	event.Contexts["resources"] = resources
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package sentryhook
+
+// readResources adds nothing on platforms without getrlimit and getrusage
+// support; only the uptime is reported.
+func readResources(resources map[string]interface{}) {}
@@ -0,0 +1,56 @@
+package sentryhook
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestResourceContext(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.ExitFunc = func(int) {}
+	log.Error("not fatal")
+	log.Fatal("out of file descriptors")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if _, ok := events[0].Contexts["resources"]; ok {
+		t.Fatal("expected no resources context below fatal")
+	}
+	resources, ok := events[1].Contexts["resources"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a resources context, got %+v", events[1].Contexts)
+	}
+	want := []string{"uptime_seconds"}
+	if runtime.GOOS == "linux" {
+		want = append(want, "fd_open", "fd_limit", "rss_bytes", "max_rss_bytes", "cpu_user_seconds")
+	}
+	for _, key := range want {
+		if _, ok := resources[key]; !ok {
+			t.Fatalf("expected %s in %+v", key, resources)
+		}
+	}
+
+	server.Reset()
+	hook, err = NewSentryHook(server.DSN(), WithExitHandler(false), WithResourceContext(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log = logrus.New()
+	log.Hooks.Add(hook)
+	log.ExitFunc = func(int) {}
+	log.Fatal("out of file descriptors")
+	if events := server.Events(); len(events) != 1 || events[0].Contexts["resources"] != nil {
+		t.Fatalf("expected no resources context when disabled, got %+v", events)
+	}
+}
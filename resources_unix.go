@@ -0,0 +1,48 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sentryhook
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// readResources adds the resource usage and limits of the process read
+// from getrlimit, getrusage and, on Linux, procfs.
+func readResources(resources map[string]interface{}) {
+	var limit syscall.Rlimit
+	if syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit) == nil {
+		resources["fd_limit"] = limit.Cur
+	}
+	fdDir := "/dev/fd"
+	if runtime.GOOS == "linux" {
+		fdDir = "/proc/self/fd"
+	}
+	if fds, err := ioutil.ReadDir(fdDir); err == nil {
+		// reading the directory opens a descriptor of its own
+		resources["fd_open"] = len(fds) - 1
+	}
+	var usage syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &usage) == nil {
+		resources["cpu_user_seconds"] = float64(usage.Utime.Nano()) / 1e9
+		resources["cpu_system_seconds"] = float64(usage.Stime.Nano()) / 1e9
+		maxRSS := int64(usage.Maxrss)
+		if runtime.GOOS == "linux" {
+			// kilobytes on Linux, bytes on macOS
+			maxRSS *= 1024
+		}
+		resources["max_rss_bytes"] = maxRSS
+	}
+	if statm, err := ioutil.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(statm)); len(fields) > 1 {
+			if pages, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				resources["rss_bytes"] = pages * int64(os.Getpagesize())
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package sentryhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRetriesOnlyFailedDestinations(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	var flaky, stable, broken int32
+	hook, err := NewAsyncSentryHook(server.DSN(), WithRetries(3, time.Millisecond), WithExitHandler(false),
+		WithSink("flaky", SinkFunc(func(ctx context.Context, event *sentrygo.Event) error {
+			if atomic.AddInt32(&flaky, 1) <= 2 {
+				return errors.New("connection reset")
+			}
+			return nil
+		}), Profile{}),
+		WithSink("stable", SinkFunc(func(ctx context.Context, event *sentrygo.Event) error {
+			atomic.AddInt32(&stable, 1)
+			return nil
+		}), Profile{}),
+		WithSink("broken", SinkFunc(func(ctx context.Context, event *sentrygo.Event) error {
+			atomic.AddInt32(&broken, 1)
+			return errors.New("broker unavailable")
+		}), Profile{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("payment failed")
+	hook.Flush()
+
+	if flaky != 3 || stable != 1 || broken != 4 {
+		t.Fatalf("expected retries of the failed sinks only, got flaky %d, stable %d, broken %d", flaky, stable, broken)
+	}
+	if n := len(server.Events()); n != 1 {
+		t.Fatalf("expected the event sent to sentry once, got %d", n)
+	}
+	select {
+	case e := <-hook.Errors():
+		if e.Destination != "broken" {
+			t.Fatalf("unexpected delivery error %+v", e)
+		}
+	default:
+		t.Fatal("expected the exhausted retries to be reported")
+	}
+	if len(hook.Errors()) != 0 {
+		t.Fatal("expected a single delivery error")
+	}
+	if stats := hook.Stats(); stats.Retried != 5 {
+		t.Fatalf("expected 5 retried deliveries, got %+v", stats)
+	}
+}
+
+func TestRetriesRejectedClientDeliveries(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewAsyncSentryHook(server.DSN(), WithRetries(3, time.Millisecond), WithExitHandler(false), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	server.FailNext(2, http.StatusServiceUnavailable)
+	log.Error("payment failed")
+	hook.Flush()
+	events := server.Events()
+	if len(events) != 1 || events[0].Message != "payment failed" {
+		t.Fatalf("expected the event delivered on the third attempt, got %d events", len(events))
+	}
+	if len(hook.Errors()) != 0 {
+		t.Fatalf("expected no delivery error, got %+v", <-hook.Errors())
+	}
+
+	server.FailNext(1, http.StatusBadRequest)
+	log.Error("invalid event")
+	hook.Flush()
+	select {
+	case e := <-hook.Errors():
+		var transportErr TransportError
+		if !errors.As(e, &transportErr) || transportErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("unexpected delivery error %+v", e)
+		}
+	default:
+		t.Fatal("expected the rejected event to be reported")
+	}
+	if stats := hook.Stats(); stats.Retried != 2 {
+		t.Fatalf("expected 2 retried deliveries, got %+v", stats)
+	}
+}
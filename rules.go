@@ -0,0 +1,205 @@
+package sentryhook
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// RuleAction is what a Rule does with the events it matches.
+type RuleAction string
+
+const (
+	// RuleDrop drops the event.
+	RuleDrop RuleAction = "drop"
+	// RuleRoute sends the event only to the destination named by the rule,
+	// instead of the hook's client and all destinations.
+	RuleRoute RuleAction = "route"
+	// RuleRetag sets the tags of the rule on the event.
+	RuleRetag RuleAction = "retag"
+	// RuleFingerprint sets the fingerprint of the rule on the event.
+	RuleFingerprint RuleAction = "fingerprint"
+	// RuleEscalate raises the event to the level of the rule, LevelFatal if
+	// it has none, and tags it "escalated".
+	RuleEscalate RuleAction = "escalate"
+)
+
+// Rule adjusts the handling of the events it matches. A rule matches
+// events meeting all of its conditions; a rule without conditions matches
+// every event.
+type Rule struct {
+	// identifies the rule, e.g. in the escalation_rule tag
+	Name string
+
+	// matches the event message
+	Message *regexp.Regexp
+	// the tags the event must have, with these values
+	Tags map[string]string
+	// the levels of the entries matched, all levels if empty
+	Levels []logrus.Level
+
+	Action RuleAction
+	// the destination of RuleRoute
+	Destination string
+	// the tags of RuleRetag
+	SetTags map[string]string
+	// the fingerprint of RuleFingerprint
+	Fingerprint []string
+	// the level of RuleEscalate
+	Level sentrygo.Level
+}
+
+// WithRules applies the rules to every event, in order, after it is built
+// and before sampling. All matching rules apply, except that a dropping
+// rule ends the evaluation. Routing applies to events which are not
+// aggregated.
+func WithRules(rules ...Rule) Option {
+	return func(hook *SentryHook) {
+		hook.rules = append([]Rule(nil), rules...)
+	}
+}
+
+// SetRules replaces the rules, e.g. after reloading them from a
+// configuration file. It is safe to call while logging.
+func (hook *SentryHook) SetRules(rules []Rule) {
+	rules = append([]Rule(nil), rules...)
+	hook.configMu.Lock()
+	hook.rules = rules
+	hook.configMu.Unlock()
+}
+
+// matches reports whether the rule matches the event of the entry.
+func (rule *Rule) matches(event *sentrygo.Event, entry *logrus.Entry) bool {
+	if rule.Message != nil && !rule.Message.MatchString(event.Message) {
+		return false
+	}
+	for k, v := range rule.Tags {
+		if event.Tags[k] != v {
+			return false
+		}
+	}
+	if len(rule.Levels) == 0 {
+		return true
+	}
+	for _, level := range rule.Levels {
+		if level == entry.Level {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules applies the rules to the event. It reports whether the event
+// is dropped, and the destination it is routed to, if any.
+func (hook *SentryHook) applyRules(event *sentrygo.Event, entry *logrus.Entry) (route string, drop bool) {
+	hook.configMu.RLock()
+	rules := hook.rules
+	hook.configMu.RUnlock()
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.matches(event, entry) {
+			continue
+		}
+		switch rule.Action {
+		case RuleDrop:
+			hook.stats.update(func(stats *Stats) { stats.DroppedByRule++ })
+			return "", true
+		case RuleRoute:
+			route = rule.Destination
+		case RuleRetag:
+			for k, v := range rule.SetTags {
+				event.Tags[k] = v
+			}
+		case RuleFingerprint:
+			event.Fingerprint = append([]string(nil), rule.Fingerprint...)
+		case RuleEscalate:
+			event.Level = rule.Level
+			if event.Level == "" {
+				event.Level = sentrygo.LevelFatal
+			}
+			event.Tags["escalated"] = "true"
+			event.Tags["escalation_rule"] = rule.Name
+		}
+	}
+	return route, false
+}
+
+// fileRule is the on-disk representation of a Rule.
+type fileRule struct {
+	Name  string `json:"name" yaml:"name"`
+	Match struct {
+		Message string            `json:"message" yaml:"message"`
+		Tags    map[string]string `json:"tags" yaml:"tags"`
+		// level names or ranges like "warn..fatal"
+		Levels []string `json:"levels" yaml:"levels"`
+	} `json:"match" yaml:"match"`
+	Action      string            `json:"action" yaml:"action"`
+	Destination string            `json:"destination" yaml:"destination"`
+	Tags        map[string]string `json:"tags" yaml:"tags"`
+	Fingerprint []string          `json:"fingerprint" yaml:"fingerprint"`
+	Level       string            `json:"level" yaml:"level"`
+}
+
+func (fr fileRule) toRule() (Rule, error) {
+	rule := Rule{
+		Name:        fr.Name,
+		Tags:        fr.Match.Tags,
+		Action:      RuleAction(fr.Action),
+		Destination: fr.Destination,
+		SetTags:     fr.Tags,
+		Fingerprint: fr.Fingerprint,
+	}
+	if fr.Match.Message != "" {
+		re, err := regexp.Compile(fr.Match.Message)
+		if err != nil {
+			return Rule{}, fmt.Errorf("sentryhook: rule %q: invalid message pattern: %v", fr.Name, err)
+		}
+		rule.Message = re
+	}
+	for _, name := range fr.Match.Levels {
+		levels, err := parseLevelRange(name)
+		if err != nil {
+			return Rule{}, fmt.Errorf("sentryhook: rule %q: %v", fr.Name, err)
+		}
+		rule.Levels = append(rule.Levels, levels...)
+	}
+	if fr.Level != "" {
+		level, err := logrus.ParseLevel(fr.Level)
+		if err != nil {
+			return Rule{}, fmt.Errorf("sentryhook: rule %q: invalid level: %v", fr.Name, err)
+		}
+		rule.Level = severityMap[level]
+	}
+	switch rule.Action {
+	case RuleDrop, RuleRetag, RuleFingerprint, RuleEscalate:
+	case RuleRoute:
+		if rule.Destination == "" {
+			return Rule{}, fmt.Errorf("sentryhook: rule %q: route without destination", fr.Name)
+		}
+	default:
+		return Rule{}, fmt.Errorf("sentryhook: rule %q: invalid action %q", fr.Name, fr.Action)
+	}
+	return rule, nil
+}
+
+// parseLevelRange parses a level name or a range of levels like
+// "warn..fatal", in either order.
+func parseLevelRange(s string) ([]logrus.Level, error) {
+	parts := strings.SplitN(s, "..", 2)
+	levels, err := parseLevels(parts)
+	if err != nil || len(levels) == 1 {
+		return levels, err
+	}
+	from, to := levels[0], levels[1]
+	if from > to {
+		from, to = to, from
+	}
+	levels = levels[:0]
+	for l := from; l <= to; l++ {
+		levels = append(levels, l)
+	}
+	return levels, nil
+}
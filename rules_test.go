@@ -0,0 +1,69 @@
+package sentryhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRules(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	var audit bytes.Buffer
+	hook, err := NewSentryHook(server.DSN(),
+		WithSink("audit", WriterSink(&audit), Profile{}),
+		WithFieldTags("component"),
+		WithRules(
+			Rule{Message: regexp.MustCompile(`^health check`), Action: RuleDrop},
+			Rule{Tags: map[string]string{"component": "billing"}, Action: RuleRetag, SetTags: map[string]string{"team": "payments"}},
+			Rule{Tags: map[string]string{"team": "payments"}, Levels: []logrus.Level{logrus.ErrorLevel}, Action: RuleEscalate, Name: "payments"},
+			Rule{Message: regexp.MustCompile(`timeout`), Action: RuleFingerprint, Fingerprint: []string{"timeouts"}},
+			Rule{Message: regexp.MustCompile(`^login`), Action: RuleRoute, Destination: "audit"},
+		))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("health check failed")
+	log.WithField("component", "billing").Error("charge failed")
+	log.WithField("component", "billing").Warn("charge slow")
+	log.Error("upstream timeout")
+	log.Error("login failed")
+
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events at the client, got %d", len(events))
+	}
+	charge, slow, timeout := events[0], events[1], events[2]
+	if charge.Tags["team"] != "payments" || charge.Level != sentrygo.LevelFatal || charge.Tags["escalation_rule"] != "payments" {
+		t.Fatalf("expected the charge failure to be retagged and escalated, got %+v", charge)
+	}
+	if slow.Tags["team"] != "payments" || slow.Level != sentrygo.LevelWarning {
+		t.Fatalf("expected the warning to be retagged only, got %+v", slow)
+	}
+	if len(timeout.Fingerprint) != 1 || timeout.Fingerprint[0] != "timeouts" {
+		t.Fatalf("unexpected fingerprint %v", timeout.Fingerprint)
+	}
+	var routed []sentrygo.Event
+	for dec := json.NewDecoder(&audit); dec.More(); {
+		var event sentrygo.Event
+		if err := dec.Decode(&event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Message == "login failed" {
+			routed = append(routed, event)
+		}
+	}
+	if len(routed) != 1 {
+		t.Fatalf("expected the login failure in the audit sink, got %s", audit.String())
+	}
+	if s := hook.Stats(); s.DroppedByRule != 1 {
+		t.Fatalf("expected 1 event dropped by rule, got %+v", s)
+	}
+}
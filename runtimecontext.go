@@ -0,0 +1,57 @@
+package sentryhook
+
+import (
+	"os"
+	"runtime"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithRuntimeContext adds Go runtime details to the "runtime" and "device"
+// contexts of every event: Go version, OS and architecture, hostname, PID,
+// GOMAXPROCS and the number of goroutines. With memStats set, heap and GC
+// statistics are added as well; reading them briefly stops the world, so
+// it is best limited to low-volume levels.
+func WithRuntimeContext(memStats bool) Option {
+	return func(hook *SentryHook) {
+		hook.runtimeContext = true
+		hook.memStats = memStats
+		hook.hostname, _ = os.Hostname()
+	}
+}
+
+// addRuntimeContext fills the runtime and device contexts of the event.
+func (hook *SentryHook) addRuntimeContext(event *sentrygo.Event) {
+	if !hook.runtimeContext {
+		return
+	}
+	if event.Contexts == nil {
+		event.Contexts = make(map[string]interface{})
+	}
+	rt := map[string]interface{}{
+		"name":           "go",
+		"version":        runtime.Version(),
+		"go_maxprocs":    runtime.GOMAXPROCS(0),
+		"go_numroutines": runtime.NumGoroutine(),
+		"pid":            os.Getpid(),
+	}
+	if hook.memStats {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		rt["go_heap_alloc"] = m.HeapAlloc
+		rt["go_heap_sys"] = m.HeapSys
+		rt["go_heap_objects"] = m.HeapObjects
+		rt["go_sys"] = m.Sys
+		rt["go_num_gc"] = m.NumGC
+		rt["go_pause_total_ns"] = m.PauseTotalNs
+	}
+	event.Contexts["runtime"] = rt
+	event.Contexts["device"] = map[string]interface{}{
+		"arch":     runtime.GOARCH,
+		"num_cpu":  runtime.NumCPU(),
+		"hostname": hook.hostname,
+	}
+	event.Contexts["os"] = map[string]interface{}{
+		"name": runtime.GOOS,
+	}
+}
@@ -0,0 +1,29 @@
+package sentryhook
+
+import (
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+func TestRuntimeContext(t *testing.T) {
+	hook, err := NewSentryHook("", WithRuntimeContext(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := sentrygo.NewEvent()
+	hook.addRuntimeContext(event)
+
+	rt, ok := event.Contexts["runtime"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a runtime context, got %+v", event.Contexts)
+	}
+	for _, key := range []string{"version", "go_maxprocs", "go_numroutines", "pid", "go_heap_alloc"} {
+		if _, ok := rt[key]; !ok {
+			t.Errorf("expected runtime context key %q", key)
+		}
+	}
+	if _, ok := event.Contexts["device"].(map[string]interface{})["hostname"]; !ok {
+		t.Error("expected the hostname in the device context")
+	}
+}
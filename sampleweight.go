@@ -0,0 +1,78 @@
+package sentryhook
+
+import (
+	"math"
+	"strconv"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// SampleWeightField is a reserved field holding a sample weight for the
+// event, a positive number, e.g. to ask dynamic sampling to keep a rare,
+// valuable event more likely than the common ones:
+//
+//	log.WithField(sentryhook.SampleWeightField, 10).Error("ledger mismatch")
+const SampleWeightField = "sentry_sample_weight"
+
+// sampleWeightTag carries the sample weight of an event.
+const sampleWeightTag = "sample_weight"
+
+// WithSampleWeight sets a function weighting events for dynamic sampling,
+// used for entries without a SampleWeightField. The weight, if positive,
+// is sent as the tag "sample_weight", which Relay's dynamic sampling rules
+// can match on, and in the dynamic sampling context of events logged with
+// one, see ContextWithDynamicSamplingContext. The hook itself does not
+// sample by it.
+func WithSampleWeight(weight func(event *sentrygo.Event, entry *logrus.Entry) float64) Option {
+	return func(hook *SentryHook) {
+		hook.sampleWeight = weight
+	}
+}
+
+// addSampleWeight adds the sample weight of the entry to the event.
+func (hook *SentryHook) addSampleWeight(event *sentrygo.Event, entry *logrus.Entry) {
+	weight, ok := toFloat(entry.Data[SampleWeightField])
+	if !ok && hook.sampleWeight != nil {
+		weight, ok = hook.sampleWeight(event, entry), true
+	}
+	if !ok || !(weight > 0) || math.IsInf(weight, 0) {
+		return
+	}
+	value := strconv.FormatFloat(weight, 'g', -1, 64)
+	event.Tags[sampleWeightTag] = value
+	if t, ok := event.Contexts["trace"].(traceContext); ok {
+		dsc := make(DynamicSamplingContext, len(t.dsc)+1)
+		for k, v := range t.dsc {
+			dsc[k] = v
+		}
+		dsc[sampleWeightTag] = value
+		event.Contexts["trace"] = traceContext{dsc: dsc}
+	}
+}
+
+// toFloat converts a numeric field value, or a string holding one.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
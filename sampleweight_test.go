@@ -0,0 +1,46 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSampleWeight(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithSampleWeight(func(event *sentrygo.Event, entry *logrus.Entry) float64 {
+		if entry.Level == logrus.FatalLevel {
+			return 5
+		}
+		return 0
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	ctx := ContextWithDynamicSamplingContext(context.Background(), DynamicSamplingContext{"trace_id": "771a43a4192642f0b136d5159a501700"})
+	log.WithContext(ctx).WithField(SampleWeightField, 10).Error("ledger mismatch")
+	log.Error("common")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Tags["sample_weight"] != "10" || events[0].Extra[SampleWeightField] != nil {
+		t.Fatalf("expected the weight as a tag, got %+v", events[0])
+	}
+	if _, ok := events[1].Tags["sample_weight"]; ok {
+		t.Fatalf("expected no weight for the common event, got %+v", events[1].Tags)
+	}
+	headers := server.EnvelopeHeaders()
+	if len(headers) != 1 {
+		t.Fatalf("expected 1 envelope, got %d", len(headers))
+	}
+	if trace, _ := headers[0]["trace"].(map[string]interface{}); trace["sample_weight"] != "10" {
+		t.Fatalf("expected the weight in the dynamic sampling context, got %v", headers[0])
+	}
+}
@@ -0,0 +1,66 @@
+package sentryhook
+
+import (
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithFirstThenSample always sends the first n events of every fingerprint
+// in each window and samples the rest at rate, between 0.0 and 1.0. A
+// window starts with the first event after the previous one ended and is
+// measured on the monotonic clock, see WithTimeSource, so stepping the wall
+// clock or logging entries with other times does not affect it. Sampled
+// out events are counted in Stats.Sampled.
+func WithFirstThenSample(n int, window time.Duration, rate float64) Option {
+	return func(hook *SentryHook) {
+		hook.sampler = &firstThenSample{
+			first:  n,
+			window: window,
+			rate:   rate,
+			counts: make(map[string]int),
+		}
+	}
+}
+
+// firstThenSample counts events per fingerprint in the current window.
+type firstThenSample struct {
+	first  int
+	window time.Duration
+	rate   float64
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+// keep reports whether the event is sent.
+func (s *firstThenSample) keep(hook *SentryHook, event *sentrygo.Event) bool {
+	key := dedupKey(event)
+	now := hook.now()
+	s.mu.Lock()
+	// a clock stepped back, possible only with a time source without
+	// monotonic readings, starts a new window
+	if elapsed := now.Sub(s.start); s.start.IsZero() || elapsed >= s.window || elapsed < 0 {
+		s.start = now
+		s.counts = make(map[string]int)
+	}
+	s.counts[key]++
+	count := s.counts[key]
+	s.mu.Unlock()
+	return count <= s.first || hook.random() < s.rate
+}
+
+// sampled reports whether the event is dropped by sampling.
+func (hook *SentryHook) sampled(event *sentrygo.Event) bool {
+	hook.configMu.RLock()
+	rate := hook.sampleRate
+	hook.configMu.RUnlock()
+	keep := rate <= 0 || rate >= 1 || hook.random() < rate
+	if keep && (hook.sampler == nil || hook.sampler.keep(hook, event)) {
+		return false
+	}
+	hook.stats.update(func(stats *Stats) { stats.Sampled++ })
+	return true
+}
@@ -0,0 +1,58 @@
+package sentryhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFirstThenSample(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	clock := newFakeClock()
+	hook, err := NewSentryHook(server.DSN(), WithFirstThenSample(2, time.Minute, 0), WithTimeSource(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 5; i++ {
+		log.Error("boom")
+		clock.Advance(time.Second)
+	}
+	log.Error("other")
+	clock.Advance(time.Minute)
+	log.Error("boom")
+
+	counts := make(map[string]int)
+	for _, event := range server.Events() {
+		counts[event.Message]++
+	}
+	if counts["boom"] != 3 || counts["other"] != 1 {
+		t.Fatalf("expected the first 2 events per window and fingerprint, got %v", counts)
+	}
+	if sampled := hook.Stats().Sampled; sampled != 3 {
+		t.Fatalf("expected 3 sampled out events, got %d", sampled)
+	}
+}
+
+func TestFirstThenSampleRate(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithFirstThenSample(1, time.Hour, 0.5), WithDeterministicMode(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 200; i++ {
+		log.WithTime(now).Error("boom")
+	}
+	if sent := len(server.Events()); sent < 60 || sent > 140 {
+		t.Fatalf("expected about half of the events to be sent, got %d", sent)
+	}
+}
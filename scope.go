@@ -0,0 +1,37 @@
+package sentryhook
+
+import (
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// WithScopeConfigurator calls fn with a fresh scope for every entry, e.g.
+// to set the user, tags, contexts or an event processor from its fields:
+//
+//	sentryhook.WithScopeConfigurator(func(scope *sentrygo.Scope, entry *logrus.Entry) {
+//		if id, ok := entry.Data["user_id"].(string); ok {
+//			scope.SetUser(sentrygo.User{ID: id})
+//		}
+//	})
+//
+// The scope is applied to the entry's event only, after the scope of the
+// entry's context hub, so nothing leaks into other events. Its tags
+// overwrite those of the event. An event processor returning nil drops the
+// event. fn is called from the logging goroutine and must not log to the
+// hook's logger.
+func WithScopeConfigurator(fn func(scope *sentrygo.Scope, entry *logrus.Entry)) Option {
+	return func(hook *SentryHook) {
+		hook.scopeConfigurator = fn
+	}
+}
+
+// applyEntryScope applies the scope of the configurator to the event. It
+// returns nil if an event processor of the scope drops the event.
+func (hook *SentryHook) applyEntryScope(event *sentrygo.Event, entry *logrus.Entry) *sentrygo.Event {
+	if hook.scopeConfigurator == nil || entry == nil {
+		return event
+	}
+	scope := sentrygo.NewScope()
+	hook.scopeConfigurator(scope, entry)
+	return scope.ApplyToEvent(event, nil)
+}
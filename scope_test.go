@@ -0,0 +1,67 @@
+package sentryhook
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestScopeConfigurator(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hub := sentrygo.NewHub(nil, sentrygo.NewScope())
+	hub.Scope().SetTag("service", "billing")
+	hook, err := NewSentryHook(server.DSN(), WithHub(hub), WithTimeout(5*time.Second), WithScopeConfigurator(func(scope *sentrygo.Scope, entry *logrus.Entry) {
+		if id, ok := entry.Data["user_id"].(string); ok {
+			scope.SetUser(sentrygo.User{ID: id})
+			scope.SetTag("user", id)
+		}
+		if entry.Data["drop"] == true {
+			scope.AddEventProcessor(func(event *sentrygo.Event, hint *sentrygo.EventHint) *sentrygo.Event {
+				return nil
+			})
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprint("u", i)
+			log.WithField("user_id", id).Error(id)
+		}(i)
+	}
+	wg.Wait()
+	log.Error("anonymous")
+	log.WithField("drop", true).Error("dropped")
+
+	events := server.Events()
+	if len(events) != 21 {
+		t.Fatalf("expected 21 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Tags["service"] != "billing" {
+			t.Fatalf("expected the hub scope applied, got %+v", event.Tags)
+		}
+		if event.Message == "anonymous" {
+			if event.User.ID != "" || event.Tags["user"] != "" {
+				t.Fatalf("expected no user to leak into %+v", event)
+			}
+		} else if event.User.ID != event.Message || event.Tags["user"] != event.Message {
+			t.Fatalf("expected the user of the entry, got %+v for %q", event.User, event.Message)
+		}
+	}
+	if _, ok := hub.Scope().Clone().ApplyToEvent(sentrygo.NewEvent(), nil).Tags["user"]; ok {
+		t.Fatal("expected the hub scope to stay untouched")
+	}
+}
@@ -0,0 +1,109 @@
+package sentryhook
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// selfReportInterval is how often the same failure of the hook is reported
+// with WithSelfReporting; repeats in between are counted.
+const selfReportInterval = time.Minute
+
+// maxSelfReportWindows bounds the number of distinct failures whose repeats
+// are counted; the counts are reset once it is reached.
+const maxSelfReportWindows = 256
+
+// deliberateDrops are the drop reasons which follow the hook's
+// configuration and are not reported with WithSelfReporting.
+var deliberateDrops = map[string]bool{
+	"rule":       true,
+	"sampled":    true,
+	"aggregated": true,
+}
+
+// WithSelfReporting reports the hook's own failures to the Sentry project
+// of the DSN, keeping the monitoring of the hook out of the application's
+// project: events which could not be delivered, events dropped by load
+// shedding, budgets, quarantine or missing required tags, and panics of
+// lazy tag and extra functions. Drops following the configuration, like
+// sampling, rules and aggregation, are not reported. The same failure is
+// reported at most once a minute, with the number of repeats in between.
+// An invalid DSN makes the constructor fail.
+func WithSelfReporting(dsn string) Option {
+	return func(hook *SentryHook) {
+		client, err := sentrygo.NewClient(sentrygo.ClientOptions{Dsn: dsn})
+		if err != nil {
+			hook.optionErr = fmt.Errorf("sentryhook: self reporting: %v", err)
+			return
+		}
+		hook.selfReporter = &selfReporter{client: client, windows: make(map[string]selfReportWindow)}
+	}
+}
+
+// selfReporter sends the hook's own failures to a separate project.
+type selfReporter struct {
+	client  *sentrygo.Client
+	mu      sync.Mutex
+	windows map[string]selfReportWindow
+}
+
+// selfReportWindow counts the repeats of a failure since it was reported.
+type selfReportWindow struct {
+	start    time.Time
+	repeated int
+}
+
+// admit reports whether the failure with the key is to be reported now,
+// and how often it was repeated since it was last reported.
+func (r *selfReporter) admit(key string, now time.Time) (repeated int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, seen := r.windows[key]
+	if elapsed := now.Sub(w.start); seen && elapsed >= 0 && elapsed < selfReportInterval {
+		w.repeated++
+		r.windows[key] = w
+		return 0, false
+	}
+	if !seen && len(r.windows) >= maxSelfReportWindows {
+		r.windows = make(map[string]selfReportWindow)
+	}
+	r.windows[key] = selfReportWindow{start: now}
+	return w.repeated, true
+}
+
+// selfReport reports a failure of the hook of the kind, "delivery", "drop"
+// or "panic", about the event, if any.
+func (hook *SentryHook) selfReport(kind, reason string, event *sentrygo.Event, extra map[string]interface{}) {
+	r := hook.selfReporter
+	if r == nil {
+		return
+	}
+	repeated, ok := r.admit(kind+"\x00"+reason, hook.now())
+	if !ok {
+		return
+	}
+	report := sentrygo.NewEvent()
+	report.Level = sentrygo.LevelWarning
+	report.Platform = "Golang"
+	report.Logger = "sentryhook"
+	report.Release = hook.release
+	report.Environment = hook.clientOptions.Environment
+	report.Message = "sentryhook: " + kind + ": " + reason
+	report.Fingerprint = []string{"sentryhook", kind, reason}
+	report.Tags = map[string]string{"failure": kind}
+	report.Extra = make(map[string]interface{}, len(extra)+3)
+	for k, v := range extra {
+		report.Extra[k] = v
+	}
+	if repeated > 0 {
+		report.Extra["repeated"] = repeated
+	}
+	if event != nil {
+		report.Extra["event_id"] = string(event.EventID)
+		report.Extra["event_message"] = event.Message
+	}
+	r.client.CaptureEvent(report, nil, nil)
+}
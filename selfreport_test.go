@@ -0,0 +1,88 @@
+package sentryhook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSelfReporting(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	self := NewMockServer()
+	defer self.Close()
+	clock := newFakeClock()
+	hook, err := NewSentryHook(server.DSN(),
+		WithSelfReporting(self.DSN()),
+		WithTimeSource(clock.Now),
+		WithRequiredTags(true, "team"),
+		WithLazyTags(map[string]func() string{
+			"flag": func() string { panic("flag store down") },
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("first")
+	log.Error("second")
+	clock.Advance(2 * selfReportInterval)
+	log.Error("third")
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if events := server.Events(); len(events) != 0 {
+		t.Fatalf("expected no events in the application's project, got %d", len(events))
+	}
+	reports := make(map[string]int)
+	repeated := 0
+	for _, report := range self.Events() {
+		reports[report.Message]++
+		if n, ok := report.Extra["repeated"].(float64); ok {
+			repeated += int(n)
+		}
+	}
+	if reports["sentryhook: panic: lazy tag function panicked"] != 2 {
+		t.Fatalf("expected the panic to be reported once per interval, got %v", reports)
+	}
+	if reports["sentryhook: drop: missing required tags"] != 2 {
+		t.Fatalf("expected the drop to be reported once per interval, got %v", reports)
+	}
+	if repeated != 2 {
+		t.Fatalf("expected one repeat of each failure, got %d", repeated)
+	}
+}
+
+func TestSelfReportingInvalidDSN(t *testing.T) {
+	if _, err := NewSentryHook("", WithSelfReporting("not a dsn")); err == nil {
+		t.Fatal("expected an error for an invalid self reporting DSN")
+	}
+}
+
+func TestSelfReportingTransportErrors(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	self := NewMockServer()
+	defer self.Close()
+	hook, err := NewSentryHook(server.DSN(), WithSelfReporting(self.DSN()), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FailNext(1, http.StatusServiceUnavailable)
+	if err := hook.Fire(logrus.NewEntry(logrus.New())); err == nil {
+		t.Fatal("expected the rejection to be returned")
+	}
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	reports := self.Events()
+	if len(reports) != 1 || reports[0].Tags["failure"] != "delivery" || reports[0].Extra["destination"] != "sentry" {
+		t.Fatalf("expected the rejection to be self-reported, got %+v", reports)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"runtime"
 
 	sentrygo "github.com/getsentry/sentry-go"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,43 +29,61 @@ type causer interface {
 	Cause() error
 }
 
-type pkgErrorStackTracer interface {
-	StackTrace() errors.StackTrace
+// A StackExtractor returns the program counters of the stack carried by an
+// error, innermost call first, or nil if the error carries no stack.
+type StackExtractor func(err error) []uintptr
+
+// stackExtractors are consulted for errors which do not implement
+// Stacktracer. Adapters for error packages register themselves here.
+var stackExtractors []StackExtractor
+
+// RegisterStackExtractor adds an extractor for the stacks of a third party
+// error package. It is not safe to call concurrently with logging and is
+// meant to be called from init functions.
+func RegisterStackExtractor(extractor StackExtractor) {
+	stackExtractors = append(stackExtractors, extractor)
 }
 
 // StackTraceConfiguration allows for configuring stacktraces
 type StackTraceConfiguration struct {
-	// whether stacktraces should be enabled
+	// whether Level, Skip and Context apply. Without it stacktraces of
+	// logging call sites are captured for all levels, see
+	// WithDisableStacktrace
 	Enable bool
-	// the level at which to start capturing stacktraces
+	// the level at which to start capturing stacktraces of logging call
+	// sites; errors carrying a stack of their own keep it
 	Level logrus.Level
-	// how many stack frames to skip before stacktrace starts recording
+	// how many stack frames to skip before stacktrace starts recording,
+	// when logrus does not report callers; SkipFramesField overrides it
 	Skip int
-	// the number of lines to include around a stack frame for context
+	// the number of lines to include around a stack frame for context,
+	// replacing the client's default of 5 if positive
 	Context int
 	// the prefixes that will be matched against the stack frame.
 	// if the stack frame's package matches one of these prefixes
 	// sentry will identify the stack frame as "in_app"
 	InAppPrefixes []string
+	// in-app decisions by module prefix, e.g. to mark generated code or
+	// vendored forks of a monorepo as not in-app. The longest prefix
+	// matching a frame's package wins, including InAppPrefixes and the main
+	// module of auto detection.
+	InAppRules map[string]bool
 	// whether sending exception type should be enabled.
 	SendExceptionType bool
 	// whether the exception type and message should be switched.
 	SwitchExceptionTypeAndMessage bool
-	// whether to include a breadcrumb with the full error stack
+	// whether to include a breadcrumb with the full error chain rendered
+	// with %+v, including pkg/errors stacks
 	IncludeErrorBreadcrumb bool
 }
 
-func setAsync(hook *SentryHook) *SentryHook {
-	if hook == nil {
-		return nil
-	}
-	hook.asynchronous = true
-	return hook
-}
-
 // Flush waits for the log queue to empty. This function only does anything in
 // asynchronous mode.
 func (hook *SentryHook) Flush() {
+	if hook.parent != nil {
+		hook.parent.Flush()
+		return
+	}
 	if !hook.asynchronous {
 		return
 	}
@@ -78,36 +95,25 @@ func (hook *SentryHook) Flush() {
 
 func (hook *SentryHook) findStacktrace(err error) *sentrygo.Stacktrace {
 	var stacktrace *sentrygo.Stacktrace
-	var stackErr errors.StackTrace
-	for err != nil {
-		// Find the earliest *raven.Stacktrace, or error.StackTrace
-		if tracer, ok := err.(Stacktracer); ok {
-			stacktrace = tracer.GetStacktrace()
-			stackErr = nil
-		} else if tracer, ok := err.(pkgErrorStackTracer); ok {
-			stacktrace = nil
-			stackErr = tracer.StackTrace()
-		}
-		if cause, ok := err.(causer); ok {
-			err = cause.Cause()
-		} else {
-			break
+	for !isNil(err) {
+		// Find the earliest stacktrace
+		if st := hook.errorStacktrace(err); st != nil {
+			stacktrace = st
 		}
-	}
-	if stackErr != nil {
-		stacktrace = hook.convertStackTrace(stackErr)
+		err = unwrap(err)
 	}
 	return stacktrace
 }
 
-// convertStackTrace converts an errors.StackTrace into a natively consumable
-// *raven.Stacktrace
-func (hook *SentryHook) convertStackTrace(st errors.StackTrace) *sentrygo.Stacktrace {
-	stFrames := []errors.Frame(st)
-	frames := make([]sentrygo.Frame, 0, len(stFrames))
-	for i := range stFrames {
-		pc := uintptr(stFrames[i])
+// convertStackTrace converts program counters, innermost call first, into a
+// natively consumable *sentrygo.Stacktrace
+func (hook *SentryHook) convertStackTrace(pcs []uintptr) *sentrygo.Stacktrace {
+	frames := make([]sentrygo.Frame, 0, len(pcs))
+	for _, pc := range pcs {
 		fn := runtime.FuncForPC(pc)
+		if fn == nil {
+			continue
+		}
 		file, line := fn.FileLine(pc)
 		rFrame := runtime.Frame{
 			PC:       pc,
@@ -0,0 +1,89 @@
+// Package sentryecho provides Echo middleware reporting to a
+// sentryhook.SentryHook. It is a separate module so that the hook does not
+// depend on Echo.
+package sentryecho
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/ainiaa/sentryhook"
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/labstack/echo/v4"
+)
+
+// Option configures the middleware.
+type Option func(*middleware)
+
+// WithRepanic continues panicking after a handler panic is reported,
+// e.g. for an outer recovery middleware, instead of returning an error
+// with status 500.
+func WithRepanic(repanic bool) Option {
+	return func(m *middleware) {
+		m.repanic = repanic
+	}
+}
+
+type middleware struct {
+	hook    *sentryhook.SentryHook
+	repanic bool
+}
+
+// New returns middleware serving every request with the context of
+// sentryhook.Middleware: a hub of its own seeded with the request, and a
+// breadcrumb trail of its own. Entries logged with
+//
+//	logger.WithContext(c.Request().Context())
+//
+// are enriched accordingly and tagged with the route. Handler panics are
+// reported as fatal events, tagged with the status 500, and delivered
+// before the error is returned.
+func New(hook *sentryhook.SentryHook, opts ...Option) echo.MiddlewareFunc {
+	m := &middleware{hook: hook}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m.handle
+}
+
+func (m *middleware) handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) (err error) {
+		r := c.Request()
+		ctx := sentryhook.RequestContext(r)
+		hub := sentrygo.GetHubFromContext(ctx)
+		if route := c.Path(); route != "" {
+			hub.Scope().SetTag("http.route", route)
+			hub.Scope().SetTransaction(r.Method + " " + route)
+		}
+		c.SetRequest(r.WithContext(ctx))
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			hub.Scope().SetTag("http.status_code", strconv.Itoa(http.StatusInternalServerError))
+			_ = m.hook.CapturePanicContext(ctx, recovered, debug.Stack())
+			if m.repanic {
+				panic(recovered)
+			}
+			err = echo.NewHTTPError(http.StatusInternalServerError)
+		}()
+		err = next(c)
+		hub.Scope().SetTag("http.status_code", strconv.Itoa(status(c, err)))
+		return err
+	}
+}
+
+// status returns the status the request is answered with. Errors are only
+// turned into responses by the error handler after the middleware returns.
+func status(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
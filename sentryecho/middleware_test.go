@@ -0,0 +1,54 @@
+package sentryecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ainiaa/sentryhook"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := sentryhook.NewMockServer()
+	defer server.Close()
+	hook, err := sentryhook.NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	e := echo.New()
+	e.Use(New(hook))
+	e.GET("/orders/:id", func(c echo.Context) error {
+		log.WithContext(c.Request().Context()).Error("lookup failed")
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+	e.GET("/panic", func(c echo.Context) error {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/7", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the handler status, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500 after a panic, got %d", rec.Code)
+	}
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Tags["http.route"] != "/orders/:id" || events[0].Request == nil || events[0].Request.URL != "http://example.com/orders/7" {
+		t.Fatalf("expected the route and request, got %+v", events[0])
+	}
+	if events[1].Level != "fatal" || events[1].Tags["http.route"] != "/panic" || events[1].Tags["http.status_code"] != "500" {
+		t.Fatalf("expected a fatal panic event, got %+v", events[1])
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/ainiaa/sentryhook/sentrygin
+
+go 1.13
+
+require (
+	github.com/ainiaa/sentryhook v0.0.0
+	github.com/getsentry/sentry-go v0.8.0
+	github.com/gin-gonic/gin v1.9.1
+	github.com/sirupsen/logrus v1.7.0
+)
+
+replace github.com/ainiaa/sentryhook => ../
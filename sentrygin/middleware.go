@@ -0,0 +1,73 @@
+// Package sentrygin provides Gin middleware reporting to a
+// sentryhook.SentryHook. It is a separate module so that the hook does not
+// depend on Gin.
+package sentrygin
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/ainiaa/sentryhook"
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+// Option configures the middleware.
+type Option func(*middleware)
+
+// WithRepanic continues panicking after a handler panic is reported,
+// e.g. for an outer recovery middleware, instead of aborting the request
+// with status 500.
+func WithRepanic(repanic bool) Option {
+	return func(m *middleware) {
+		m.repanic = repanic
+	}
+}
+
+type middleware struct {
+	hook    *sentryhook.SentryHook
+	repanic bool
+}
+
+// New returns middleware serving every request with the context of
+// sentryhook.Middleware: a hub of its own seeded with the request, and a
+// breadcrumb trail of its own. Entries logged with
+//
+//	logger.WithContext(c.Request.Context())
+//
+// are enriched accordingly and tagged with the route. Handler panics are
+// reported as fatal events, tagged with the status 500, and delivered
+// before the request is aborted.
+func New(hook *sentryhook.SentryHook, opts ...Option) gin.HandlerFunc {
+	m := &middleware{hook: hook}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m.handle
+}
+
+func (m *middleware) handle(c *gin.Context) {
+	ctx := sentryhook.RequestContext(c.Request)
+	hub := sentrygo.GetHubFromContext(ctx)
+	if route := c.FullPath(); route != "" {
+		hub.Scope().SetTag("http.route", route)
+		hub.Scope().SetTransaction(c.Request.Method + " " + route)
+	}
+	c.Request = c.Request.WithContext(ctx)
+
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		hub.Scope().SetTag("http.status_code", strconv.Itoa(http.StatusInternalServerError))
+		_ = m.hook.CapturePanicContext(ctx, recovered, debug.Stack())
+		if m.repanic {
+			panic(recovered)
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}()
+	c.Next()
+	hub.Scope().SetTag("http.status_code", strconv.Itoa(c.Writer.Status()))
+}
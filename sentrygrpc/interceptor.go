@@ -0,0 +1,144 @@
+// Package sentrygrpc provides gRPC server interceptors reporting to a
+// sentryhook.SentryHook. It is a separate module so that the hook does not
+// depend on gRPC.
+package sentrygrpc
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/ainiaa/sentryhook"
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// defaultCodes are the status codes of handler errors reported by default,
+// the ones pointing at a server fault.
+var defaultCodes = []codes.Code{codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable}
+
+// Option configures the interceptors.
+type Option func(*interceptor)
+
+// WithCodes sets the status codes of handler errors which are reported as
+// events. It defaults to Unknown, Internal, DataLoss and Unavailable.
+func WithCodes(report ...codes.Code) Option {
+	return func(i *interceptor) {
+		i.codes = make(map[codes.Code]bool, len(report))
+		for _, code := range report {
+			i.codes[code] = true
+		}
+	}
+}
+
+// WithRepanic continues panicking after a handler panic is reported,
+// instead of returning an Internal error to the client.
+func WithRepanic(repanic bool) Option {
+	return func(i *interceptor) {
+		i.repanic = repanic
+	}
+}
+
+type interceptor struct {
+	hook    *sentryhook.SentryHook
+	codes   map[codes.Code]bool
+	repanic bool
+}
+
+func newInterceptor(hook *sentryhook.SentryHook, opts []Option) *interceptor {
+	i := &interceptor{hook: hook}
+	WithCodes(defaultCodes...)(i)
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// UnaryServerInterceptor gives every call its own hub, tagged with the
+// method and the peer address, and its own breadcrumb trail, like
+// sentryhook.Middleware does for HTTP requests. Entries logged with the
+// call's context are enriched accordingly. Handler errors with one of the
+// reported status codes are sent as events tagged with the code, handler
+// panics are reported as fatal events and delivered before the call
+// returns.
+func UnaryServerInterceptor(hook *sentryhook.SentryHook, opts ...Option) grpc.UnaryServerInterceptor {
+	i := newInterceptor(hook, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		ctx = i.context(ctx, info.FullMethod)
+		defer i.recover(ctx, &err)
+		resp, err = handler(ctx, req)
+		i.report(ctx, info.FullMethod, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func StreamServerInterceptor(hook *sentryhook.SentryHook, opts ...Option) grpc.StreamServerInterceptor {
+	i := newInterceptor(hook, opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := i.context(ss.Context(), info.FullMethod)
+		defer i.recover(ctx, &err)
+		err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		i.report(ctx, info.FullMethod, err)
+		return err
+	}
+}
+
+// serverStream overrides the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// context returns the call context with a hub of its own.
+func (i *interceptor) context(ctx context.Context, method string) context.Context {
+	hub := sentrygo.NewHub(sentrygo.CurrentHub().Client(), sentrygo.NewScope())
+	hub.Scope().SetTag("grpc.method", method)
+	hub.Scope().SetTransaction(method)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		hub.Scope().SetTag("grpc.peer", p.Addr.String())
+	}
+	ctx = sentrygo.SetHubOnContext(ctx, hub)
+	return sentryhook.BreadcrumbContext(ctx)
+}
+
+// report sends a handler error if its status code is reported.
+func (i *interceptor) report(ctx context.Context, method string, err error) {
+	if err == nil {
+		return
+	}
+	code := status.Code(err)
+	if !i.codes[code] {
+		return
+	}
+	sentrygo.GetHubFromContext(ctx).Scope().SetTag("grpc.code", code.String())
+	entry := logrus.NewEntry(logrus.StandardLogger()).WithContext(ctx).WithError(err)
+	entry.Time = time.Now()
+	entry.Level = logrus.ErrorLevel
+	entry.Message = method + " failed: " + code.String()
+	_ = i.hook.Fire(entry)
+}
+
+// recover reports a handler panic and turns it into an Internal error,
+// unless the interceptor repanics.
+func (i *interceptor) recover(ctx context.Context, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	sentrygo.GetHubFromContext(ctx).Scope().SetTag("grpc.code", codes.Internal.String())
+	_ = i.hook.CapturePanicContext(ctx, recovered, debug.Stack())
+	if i.repanic {
+		panic(recovered)
+	}
+	*err = status.Errorf(codes.Internal, "panic: %v", recovered)
+}
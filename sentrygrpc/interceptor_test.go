@@ -0,0 +1,68 @@
+package sentrygrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ainiaa/sentryhook"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	server := sentryhook.NewMockServer()
+	defer server.Close()
+	hook, err := sentryhook.NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	intercept := UnaryServerInterceptor(hook)
+	info := &grpc.UnaryServerInfo{FullMethod: "/shop.Payments/Charge"}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4242}})
+
+	_, err = intercept(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		log.WithContext(ctx).Warn("retrying")
+		return nil, status.Error(codes.NotFound, "no such order")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+	_, err = intercept(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("database is gone")
+	})
+	if status.Code(err) != codes.Unknown {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+	_, err = intercept(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected an Internal error for a panic, got %v", err)
+	}
+
+	events := server.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Tags["grpc.method"] != info.FullMethod || event.Tags["grpc.peer"] != "10.0.0.1:4242" {
+			t.Fatalf("expected the call tags, got %v", event.Tags)
+		}
+	}
+	if events[0].Message != "retrying" || events[0].Tags["grpc.code"] != "" {
+		t.Fatalf("unexpected event logged by the handler %+v", events[0])
+	}
+	if events[1].Tags["grpc.code"] != "Unknown" {
+		t.Fatalf("expected the handler error tagged with its code, got %v", events[1].Tags)
+	}
+	if events[2].Level != "fatal" || events[2].Tags["grpc.code"] != "Internal" {
+		t.Fatalf("expected a fatal panic event, got %+v", events[2])
+	}
+}
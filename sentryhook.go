@@ -1,61 +1,211 @@
 package sentryhook
 
 import (
+	"fmt"
+	"math/rand"
+	"net"
 	"sync"
 	"time"
 
-	"github.com/ainiaa/bytesconv"
 	sentrygo "github.com/getsentry/sentry-go"
 	"github.com/sirupsen/logrus"
 )
 
-// SentryHook is a hook to handle writing to kafka log files.
-// SentryHook delivers logs to a sentry server.
+// SentryHook delivers logs to a sentry server and, optionally, to further
+// destinations and sinks. It is safe for concurrent use. The configuration
+// which can change while logging, like the levels, tags, formatter, rules
+// and sample rate, is changed with the setters and ApplyConfig; the
+// exported fields must not be modified once the hook is in use.
 type SentryHook struct {
 	Timeout                 time.Duration
 	StacktraceConfiguration StackTraceConfiguration
 	flushTimeout            time.Duration
 	client                  *sentrygo.Client
+	clientOptions           sentrygo.ClientOptions
+	constraints             *NetworkConstraints
+	capabilities            capabilities
 	levels                  []logrus.Level
+	severities              map[logrus.Level]sentrygo.Level
+	defaultSeverity         sentrygo.Level
 	hub                     *sentrygo.Hub
 	tags                    map[string]string
+	tagPrecedence           []TagSource
+	tagConflicts            func(conflict TagConflict)
+	fieldTags               []string
+	lazyTags                map[string]func() string
+	lazyExtras              map[string]func() interface{}
+	breadcrumbs             *breadcrumbTrail
+	breadcrumbLevel         logrus.Level
+	maxBreadcrumbs          int
+	filters                 []func(entry *logrus.Entry) bool
+	extraAllow              map[string]bool
+	extraDeny               map[string]bool
+	disableExtra            bool
+	extraLimits             ExtraLimits
+	requestFields           RequestFields
+	destinations            []*destination
 	disableStacktrace       bool
-	level                   logrus.Level
+	errorStackFallback      bool
+	selfReporter            *selfReporter
+	outcomes                deliveryOutcomes
+	parent                  *SentryHook
 	asynchronous            bool
 	formatter               logrus.Formatter
+	messageMode             MessageMode
+	messageBuilder          MessageBuilder
+	goroutineIDTag          bool
+	aggregator              *aggregator
+	aggregateStrategy       AggregateStrategy
+	release                 string
+	runtimeContext          bool
+	memStats                bool
+	hostname                string
+	perfTrigger             map[string]string
+	metadataTags            map[string]string
+	metadataContexts        map[string]interface{}
+	mainModule              string
+	autoInApp               bool
+	sources                 map[*logrus.Logger]string
+	sourcesMu               sync.RWMutex
+	webURL                  string
+	org                     string
+	project                 string
+	lastEventID             sentrygo.EventID
+	lastEventMu             sync.Mutex
+	recent                  *recentEvents
+	startupEvent            bool
+	ownerResolver           func(fingerprint string, entry *logrus.Entry) string
+	packageOwners           map[string]string
+	quarantine              *quarantine
+	sampler                 *firstThenSample
+	pending                 []*sentrygo.Event
+	pendingMax              int
+	pendingMu               sync.Mutex
+	requiredTags            []string
+	strictRequiredTags      bool
+	demangle                bool
+	nilErrorMode            NilErrorMode
+	proxyURL                string
+	caCerts                 [][]byte
+	shedder                 *loadShedder
+	session                 *session
+	attachmentExtractor     func(entry *logrus.Entry) []Attachment
+	logs                    *logBatcher
+	escalations             []*escalation
+	traces                  pendingTraces
+	budget                  *budget
+	rules                   []Rule
+	optionErr               error
+	mirror                  *mirror
+	dryRun                  *writerSink
+	validationTimeout       time.Duration
+	validated               chan struct{}
+	validationErr           error
+	resolver                hostResolver
+	finalizer               func(event *sentrygo.Event, id *sentrygo.EventID, err error)
+	noExitHandler           bool
+	noResourceContext       bool
+	panicOnMisuse           bool
+	errors                  chan DeliveryError
+	queueSize               int
+	maxQueueAge             time.Duration
+	interner                *interner
+	queue                   chan *queuedEvent
+	core                    *Core
+	stop                    chan struct{}
+	startOnce               sync.Once
+	workers                 sync.WaitGroup
+	deadLetter              func(event *sentrygo.Event)
+	stats                   stats
+	now                     func() time.Time
+	rng                     *rand.Rand
+	rngMu                   sync.Mutex
+	closed                  bool
+	closeOnce               sync.Once
+	sampleRate              float64
+	scrubbers               []func(event *sentrygo.Event) *sentrygo.Event
+	dsnRotation             *dsnRotation
+	scopeConfigurator       func(scope *sentrygo.Scope, entry *logrus.Entry)
+	temporaryTags           goroutineTags
+	sampleWeight            func(event *sentrygo.Event, entry *logrus.Entry) float64
+	debugLogger             logrus.FieldLogger
+	loggerField             string
+	inFlight                *inFlight
+	retries                 int
+	retryBackoff            time.Duration
+	configMu                sync.RWMutex
+	clientMu                sync.RWMutex
 	mu                      sync.RWMutex
 	wg                      sync.WaitGroup
 }
 
 type Option func(hook *SentryHook)
 
+// WithTimeout bounds every delivery: capturing and flushing an event in
+// synchronous mode, each attempt of the queue worker in asynchronous mode.
+// Deliveries exceeding it fail with ErrTimeout. Zero disables the bound.
 func WithTimeout(timeout time.Duration) Option {
 	return func(hook *SentryHook) {
 		hook.Timeout = timeout
 	}
 }
 
+// WithLevels sets the levels the hook fires for.
 func WithLevels(levels []logrus.Level) Option {
 	return func(hook *SentryHook) {
 		hook.levels = levels
 	}
 }
 
+// WithLevel makes the hook fire for the level and all more severe levels.
 func WithLevel(level logrus.Level) Option {
 	return func(hook *SentryHook) {
-		hook.level = level
+		hook.levels = levelsUpTo(level)
 	}
 }
 
+// WithFormatter sets a formatter for entries. How its output is used depends
+// on the message mode, see WithMessageMode.
 func WithFormatter(formatter logrus.Formatter) Option {
 	return func(hook *SentryHook) {
 		hook.formatter = formatter
 	}
 }
 
+// WithTags sets static tags added to every event.
 func WithTags(tags map[string]string) Option {
 	return func(hook *SentryHook) {
-		hook.tags = tags
+		hook.tags = copyTags(tags, 0)
+	}
+}
+
+// WithDisableStacktrace disables capturing the log-site stacktrace for
+// entries without an error.
+func WithDisableStacktrace(disable bool) Option {
+	return func(hook *SentryHook) {
+		hook.disableStacktrace = disable
+	}
+}
+
+// WithFlushTimeout sets how long to wait for the client to flush an event.
+func WithFlushTimeout(timeout time.Duration) Option {
+	return func(hook *SentryHook) {
+		hook.flushTimeout = timeout
+	}
+}
+
+// WithHub sets the hub whose scope is applied to events. It defaults to
+// sentrygo.CurrentHub().
+func WithHub(hub *sentrygo.Hub) Option {
+	return func(hook *SentryHook) {
+		hook.hub = hub
+	}
+}
+
+// WithAsync enables or disables asynchronous delivery.
+func WithAsync(async bool) Option {
+	return func(hook *SentryHook) {
+		hook.asynchronous = async
 	}
 }
 
@@ -63,101 +213,388 @@ func WithTags(tags map[string]string) Option {
 // and initializes the raven client.
 // This method sets the timeout to 100 milliseconds.
 func NewSentryHook(DSN string, opts ...Option) (*SentryHook, error) {
-	client, err := sentrygo.NewClient(sentrygo.ClientOptions{
-		Dsn: DSN,
-	})
+	hook := newSentryHook(opts...)
+	if hook.optionErr != nil {
+		return nil, hook.optionErr
+	}
+	if hook.dsnRotation != nil {
+		dsn, err := hook.dsnRotation.provider()
+		if err != nil {
+			return nil, fmt.Errorf("sentryhook: fetching the DSN: %v", err)
+		}
+		DSN = dsn
+		hook.dsnRotation.current = dsn
+	}
+	client, err := hook.newClient(DSN)
 	if err != nil {
 		return nil, err
 	}
-	return NewWithClientSentryHook(client, opts...)
+	if hook.validationTimeout > 0 && DSN != "" {
+		hook.validateInBackground(client)
+		return hook.init(), nil
+	}
+	hook.client = client
+	return hook.init(), nil
+}
+
+// newClient creates a client for the DSN with the hook's client options.
+func (hook *SentryHook) newClient(dsn string) (*sentrygo.Client, error) {
+	clientOptions := hook.clientOptions
+	clientOptions.Dsn = dsn
+	if hook.release != "" {
+		clientOptions.Release = hook.release
+	}
+	if clientOptions.HTTPClient == nil && clientOptions.HTTPTransport == nil {
+		base, err := hook.baseTransport()
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.HTTPTransport = base
+	}
+	if httpClient := clientOptions.HTTPClient; httpClient != nil {
+		// the client's transport is used instead of HTTPTransport
+		c := *httpClient
+		c.Transport = hook.wrapTransport(c.Transport)
+		clientOptions.HTTPClient = &c
+	} else {
+		clientOptions.HTTPTransport = hook.wrapTransport(clientOptions.HTTPTransport)
+	}
+	if config := hook.StacktraceConfiguration; config.Enable && config.Context > 0 {
+		clientOptions.Integrations = withContextLines(clientOptions.Integrations, config.Context)
+	}
+	client, err := sentrygo.NewClient(clientOptions)
+	if err == nil {
+		hook.outcomes.setWired(clientOptions)
+	}
+	return client, err
 }
 
 // NewWithClientSentryHook creates a hook using an initialized sentrygo client.
-// This method sets the timeout to 100 milliseconds.
+// This method sets the timeout to 100 milliseconds. Options configuring the
+// client, like WithNetworkConstraints, have no effect.
 func NewWithClientSentryHook(client *sentrygo.Client, opts ...Option) (*SentryHook, error) {
+	hook := newSentryHook(opts...)
+	if client == nil {
+		return nil, hook.misuse(ErrNilClient)
+	}
+	if hook.optionErr != nil {
+		return nil, hook.optionErr
+	}
+	hook.client = client
+	return hook.init(), nil
+}
+
+// newSentryHook creates a hook with the default settings and applies the
+// options.
+func newSentryHook(opts ...Option) *SentryHook {
 	hook := &SentryHook{
-		Timeout: 100 * time.Millisecond,
-		StacktraceConfiguration: StackTraceConfiguration{
-			Enable:            false,
-			Level:             logrus.WarnLevel,
-			Skip:              6,
-			Context:           0,
-			InAppPrefixes:     nil,
-			SendExceptionType: true,
-		},
-		flushTimeout: 3 * time.Second,
-		client:       client,
-	}
-	levels := make([]logrus.Level, 4)
-	levels[0] = logrus.WarnLevel
-	levels[1] = logrus.FatalLevel
-	levels[2] = logrus.ErrorLevel
-	levels[3] = logrus.PanicLevel
-	hook.levels = levels
-	hook.formatter = &logrus.JSONFormatter{}
+		Timeout:                 100 * time.Millisecond,
+		StacktraceConfiguration: DefaultStackTraceConfiguration(),
+		levels:                  DefaultLevels(),
+		flushTimeout:            3 * time.Second,
+		extraLimits:             DefaultExtraLimits(),
+		requestFields:           RequestFields{Request: RequestField},
+		errors:                  make(chan DeliveryError, defaultErrorsBuffer),
+		now:                     time.Now,
+		resolver:                net.DefaultResolver,
+	}
 	for _, o := range opts {
 		o(hook)
 	}
-	return hook, nil
+	if hook.mirror != nil && hook.optionErr == nil {
+		hook.optionErr = hook.addMirror()
+	}
+	return hook
+}
+
+// init starts the background work of a configured hook.
+func (hook *SentryHook) init() *SentryHook {
+	if hook.asynchronous {
+		hook.start()
+	}
+	if hook.aggregator != nil {
+		hook.aggregator.start(hook)
+	}
+	if hook.logs != nil {
+		hook.logs.start(hook)
+	}
+	if hook.budget != nil {
+		hook.budget.start(hook)
+	}
+	if hook.dsnRotation != nil {
+		hook.dsnRotation.start(hook)
+	}
+	if hook.startupEvent {
+		hook.sendStartupEvent()
+	}
+	hook.startSession()
+	hook.registerExitHandler()
+	return hook
 }
 
 // NewAsyncSentryHook creates a hook same as NewSentryHook, but in asynchronous
 // mode.
-func NewAsyncSentryHook(DSN string) (*SentryHook, error) {
-	hook, err := NewSentryHook(DSN)
-	return setAsync(hook), err
+func NewAsyncSentryHook(DSN string, opts ...Option) (*SentryHook, error) {
+	return NewSentryHook(DSN, append(opts, WithAsync(true))...)
 }
 
 // Fire writes the log file to defined path or using the defined writer.
 // User who run this function needs write permissions to the file or directory if the file does not yet exist.
 func (hook *SentryHook) Fire(entry *logrus.Entry) error {
+	if hook.parent != nil {
+		return hook.fireScoped(entry)
+	}
+	return hook.fire(entry, hook.enabled(entry.Level))
+}
+
+// fire is Fire with the level filter applied by the caller, the hook or
+// one of its children.
+func (hook *SentryHook) fire(entry *logrus.Entry, enabled bool) error {
+	if skipped(entry) {
+		return nil
+	}
+	if logged := hook.logs.handles(entry.Level); logged || !enabled {
+		if (logged || hook.breadcrumbs != nil) && hook.accepts(entry) {
+			if hook.breadcrumbs != nil {
+				hook.recordBreadcrumb(entry)
+			}
+			if logged {
+				hook.logs.add(hook, entry)
+			}
+		}
+		return nil
+	}
+	if !hook.accepts(entry) {
+		return nil
+	}
+	event := hook.buildEvent(entry)
+	if event == nil {
+		hook.debug(nil, logrus.Fields{"reason": "nil error or event processor", "message": entry.Message}, "event dropped")
+		return nil
+	}
+	hook.debug(event, logrus.Fields{"level": event.Level, "tags": len(event.Tags), "extra": len(event.Extra)}, "event built")
+	return hook.handle(event, entry)
+}
+
+// handle takes a built event through the rules, scrubbers and volume
+// controls and dispatches it if it is kept.
+func (hook *SentryHook) handle(event *sentrygo.Event, entry *logrus.Entry) error {
+	route, drop := hook.applyRules(event, entry)
+	if drop {
+		hook.dropped(event, "rule")
+		return nil
+	}
+	if event = hook.scrub(event); event == nil {
+		hook.debug(nil, logrus.Fields{"reason": "scrubber", "message": entry.Message}, "event dropped")
+		return nil
+	}
+	if err := hook.checkRequiredTags(event, entry); err != nil {
+		hook.dropped(event, "missing required tags")
+		return err
+	}
+	hook.trackSession(entry)
+	hook.escalate(event)
+	if hook.quarantined(event) {
+		hook.dropped(event, "quarantined")
+		return nil
+	}
+	if hook.sampled(event) {
+		hook.dropped(event, "sampled")
+		return nil
+	}
+	if hook.shed() {
+		hook.dropped(event, "load shedding")
+		return nil
+	}
+	if hook.aggregator != nil && hook.aggregator.add(event, entry) {
+		hook.dropped(event, "aggregated")
+		return nil
+	}
+	if hook.overBudget(event) {
+		hook.dropped(event, "over budget")
+		return nil
+	}
+	return hook.dispatchTo(event, entry, route)
+}
+
+// buildEvent converts a log entry into a sentry event. It returns nil if
+// the event is dropped for a nil error, see WithNilErrorMode, or by an
+// event processor of the entry's context hub.
+func (hook *SentryHook) buildEvent(entry *logrus.Entry) *sentrygo.Event {
+	if hook.nilErrorMode == NilErrorDrop && nilError(entry) {
+		return nil
+	}
 	// We may be crashing the program, so should flush any buffered events.
-	content := hook.createContent(entry)
+	message, formatted := hook.buildMessage(entry)
 
 	event := sentrygo.NewEvent()
-	event.Message = bytesconv.BytesToString(content)
+	event.EventID = hook.deterministicEventID()
+	event.Message = message
 	event.Timestamp = entry.Time
-	event.Level = severityMap[entry.Level]
+	event.Level = hook.severity(entry.Level)
 	event.Platform = "Golang"
-	event.Extra = entry.Data
-	event.Tags = hook.tags
-
-	if !hook.disableStacktrace {
-		trace := sentrygo.NewStacktrace()
-		if trace != nil {
-			value := ""
-			if entry.Caller != nil {
-				value = entry.Caller.File
+	event.Logger = hook.loggerName(entry)
+	event.Extra = make(map[string]interface{}, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		if hook.extraAllowed(k) {
+			event.Extra[k] = hook.extraLimits.sanitize(v)
+		}
+	}
+	for k, fn := range hook.lazyExtras {
+		if _, ok := event.Extra[k]; !ok && !hook.disableExtra {
+			event.Extra[k] = hook.extraLimits.sanitize(hook.resolveExtra(k, fn))
+		}
+	}
+	if formatted != "" && !hook.disableExtra {
+		event.Extra[formattedExtraKey] = formatted
+	}
+	event.Tags = hook.eventTags(entry)
+	shedding := hook.shedding()
+	if shedding {
+		event.Tags[loadSheddingTag] = "true"
+	}
+	if hook.nilErrorMode == NilErrorTag && nilError(entry) {
+		event.Tags[nilErrorTag] = "true"
+	}
+	hook.addRequest(event, entry)
+	hook.attachBreadcrumbs(event, entry)
+
+	caller, hasCaller := callerFrame(entry)
+	skipFrames, trace := hook.frameOverrides(entry, hasCaller)
+	if err := entryError(entry); err != nil {
+		event.Exception = hook.exceptions(err)
+		// show where the error was logged if the error itself has no stack
+		last := len(event.Exception) - 1
+		if hook.errorStackFallback && !shedding && trace && !hasStacktrace(event.Exception) {
+			if st := logSiteStacktrace(caller, hasCaller, skipFrames); st != nil {
+				event.Exception[last].Stacktrace = st
+				event.Tags[stackSourceTag] = "log_site"
+			}
+		} else if hasCaller && trace && event.Exception[last].Stacktrace == nil {
+			if skipFrames > 0 {
+				event.Exception[last].Stacktrace = logSiteStacktrace(caller, hasCaller, skipFrames)
+			} else {
+				event.Exception[last].Stacktrace = withCallerFrame(nil, caller)
 			}
+		}
+		if hook.StacktraceConfiguration.IncludeErrorBreadcrumb {
+			event.Breadcrumbs = append(event.Breadcrumbs, hook.errorBreadcrumb(err, entry))
+		}
+	} else if !hook.disableStacktrace && !shedding && trace {
+		if st := logSiteStacktrace(caller, hasCaller, skipFrames); st != nil {
 			event.Exception = []sentrygo.Exception{{
-				Type:       entry.Message,
-				Value:      value,
-				Stacktrace: trace,
+				Type:       event.Message,
+				Stacktrace: st,
 			}}
 		}
 	}
 
-	hub := hook.hub
-	if hub == nil {
-		hub = sentrygo.CurrentHub()
+	hook.markInApp(event)
+	hook.demangleFrames(event)
+	addMechanism(event, entry.Level)
+	hook.addOwner(event, entry)
+	hook.addRuntimeContext(event)
+	hook.addResourceContext(event, entry.Level)
+	hook.addTraceContext(event, entry)
+	hook.addPerfContext(event, entry)
+	for k, v := range hook.metadataContexts {
+		if event.Contexts == nil {
+			event.Contexts = make(map[string]interface{})
+		}
+		event.Contexts[k] = v
 	}
-	_ = hook.client.CaptureEvent(event, nil, hub.Scope())
-	//if entry.Level > logrus.ErrorLevel {
-		hook.client.Flush(hook.flushTimeout)
-	//}
+	if event = hook.applyContextScope(event, entry); event == nil {
+		return nil
+	}
+	if event = hook.applyEntryScope(event, entry); event == nil {
+		return nil
+	}
+	hook.addSampleWeight(event, entry)
+	orderBreadcrumbs(event)
+	hook.extraLimits.limitEventSize(event)
+	hook.internEvent(event)
+	return event
+}
 
-	return nil
+// dispatch hands the event over for delivery: to the queue in asynchronous
+// mode, directly to the client otherwise. Every destination gets its own
+// copy, prepared from the entry if there is one. Failures of destinations
+// are reported on Errors; only the failure of the hook's own client is
+// returned. Events flagged by the quarantine are held back instead.
+func (hook *SentryHook) dispatch(event *sentrygo.Event, entry *logrus.Entry) error {
+	if hook.quarantined(event) {
+		hook.dropped(event, "quarantined")
+		return nil
+	}
+	return hook.dispatchTo(event, entry, "")
 }
 
-func (hook *SentryHook) createContent(entry *logrus.Entry) []byte {
-	msg, err := hook.formatter.Format(entry)
-	if err != nil {
-		return []byte("")
+// dispatchTo is dispatch for events the quarantine has already let through,
+// delivering only to the destination with the name route, if it is not
+// empty and such a destination exists.
+func (hook *SentryHook) dispatchTo(event *sentrygo.Event, entry *logrus.Entry, route string) error {
+	if route != "" && hook.destination(route) == nil {
+		route = ""
+	}
+	hook.mu.RLock()
+	defer hook.mu.RUnlock()
+	if hook.closed {
+		return hook.misuse(ErrClosed)
 	}
-	return msg
+	if hook.buffer(event) {
+		return nil
+	}
+	var item *queuedEvent
+	if hook.asynchronous {
+		item = &queuedEvent{hook: hook}
+	}
+	// copies are taken first, the client modifies events it captures
+	for _, dest := range hook.destinations {
+		if route != "" && dest.name != route {
+			continue
+		}
+		c := dest.prepare(hook, event, entry)
+		if c == nil {
+			continue
+		}
+		if item != nil {
+			item.add(dest, c)
+		} else if err := hook.deliverAdmitted(dest, c); err != nil {
+			hook.reportError(dest, c, err)
+		}
+	}
+	if item != nil {
+		if route == "" {
+			item.add(nil, event)
+			item.attachments = hook.entryAttachments(entry)
+		}
+		hook.enqueue(item)
+		return nil
+	}
+	if route != "" {
+		return nil
+	}
+	attachments := hook.entryAttachments(entry)
+	err := hook.deliverAdmitted(nil, event)
+	if err == nil {
+		hook.sendAttachments(event, attachments)
+	} else {
+		hook.selfReport("delivery", err.Error(), event, map[string]interface{}{"destination": "sentry"})
+	}
+	return err
 }
 
-// Levels returns configured log levels.
+// Levels returns all log levels, so logrus passes every entry to the hook
+// and levels can be changed at runtime with SetLevel or SetLevels; Fire
+// ignores entries of levels the hook is not configured for. Custom levels
+// are included if they are mapped with WithLevelMapping.
 func (hook *SentryHook) Levels() []logrus.Level {
-	return hook.levels
+	if hook.parent != nil {
+		return hook.parent.Levels()
+	}
+	custom := hook.customLevels()
+	if len(custom) == 0 {
+		return logrus.AllLevels
+	}
+	return append(append([]logrus.Level(nil), logrus.AllLevels...), custom...)
 }
@@ -3,7 +3,9 @@ package sentryhook
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	sentrygo "github.com/getsentry/sentry-go"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,3 +24,20 @@ func TestNewSyncHook(t *testing.T) {
 
 	log.Error("test log error efdd")
 }
+
+func TestOptionParity(t *testing.T) {
+	hub := sentrygo.NewHub(nil, sentrygo.NewScope())
+	hook, err := NewAsyncSentryHook("",
+		WithDisableStacktrace(true),
+		WithFlushTimeout(time.Second),
+		WithHub(hub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hook.disableStacktrace || hook.flushTimeout != time.Second || hook.hub != hub || !hook.asynchronous {
+		t.Fatalf("options not applied: %+v", hook)
+	}
+	if hook.queue == nil {
+		t.Fatal("expected the asynchronous worker to be started")
+	}
+}
@@ -0,0 +1,161 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The statuses of sessions. A session which ends with errors but did not
+// crash is reported as exited with an error count, Sentry counts it as
+// errored.
+const (
+	sessionOK      = "ok"
+	sessionExited  = "exited"
+	sessionCrashed = "crashed"
+)
+
+// WithSessionTracking reports the lifetime of the hook as a release health
+// session: it starts when the hook is created, is marked errored when the
+// first entry of Error level or above is logged and crashed on Fatal and
+// Panic, and ends on Close. Updates are sent on these transitions only,
+// waiting at most the flush timeout. Sentry requires a release for
+// sessions, see WithRelease. Failed updates are reported on Errors, with
+// a nil Event.
+func WithSessionTracking() Option {
+	return func(hook *SentryHook) {
+		hook.session = &session{}
+	}
+}
+
+// session is the release health session of a hook.
+type session struct {
+	mu      sync.Mutex
+	id      string
+	started time.Time
+	status  string
+	errors  int
+	sent    bool
+	ended   bool
+}
+
+// sessionUpdate is the payload of a session envelope item.
+type sessionUpdate struct {
+	ID        string            `json:"sid"`
+	Init      bool              `json:"init,omitempty"`
+	Started   string            `json:"started"`
+	Timestamp string            `json:"timestamp"`
+	Status    string            `json:"status"`
+	Errors    int               `json:"errors"`
+	Duration  float64           `json:"duration,omitempty"`
+	Attrs     map[string]string `json:"attrs"`
+}
+
+// startSession starts the hook's session, if session tracking is enabled.
+func (hook *SentryHook) startSession() {
+	s := hook.session
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.id = hook.newID()
+	s.started = hook.now()
+	s.status = sessionOK
+	update := hook.sessionUpdate(s)
+	s.mu.Unlock()
+	hook.sendSession(update)
+}
+
+// trackSession records a logged entry in the hook's session and reports the
+// session as errored or crashed when its state changes.
+func (hook *SentryHook) trackSession(entry *logrus.Entry) {
+	s := hook.session
+	if s == nil || entry.Level > logrus.ErrorLevel {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.errors++
+	changed := s.errors == 1
+	if entry.Level <= logrus.FatalLevel && s.status != sessionCrashed {
+		s.status = sessionCrashed
+		s.ended = true
+		changed = true
+	}
+	var update sessionUpdate
+	if changed {
+		update = hook.sessionUpdate(s)
+	}
+	s.mu.Unlock()
+	if changed {
+		hook.sendSession(update)
+	}
+}
+
+// endSession reports the hook's session as exited, unless it crashed.
+func (hook *SentryHook) endSession() {
+	s := hook.session
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.status = sessionExited
+	update := hook.sessionUpdate(s)
+	s.mu.Unlock()
+	hook.sendSession(update)
+}
+
+// sessionUpdate describes the current state of the session. It must be
+// called with s.mu held.
+func (hook *SentryHook) sessionUpdate(s *session) sessionUpdate {
+	now := hook.now()
+	update := sessionUpdate{
+		ID:        s.id,
+		Init:      !s.sent,
+		Started:   s.started.UTC().Format(time.RFC3339Nano),
+		Timestamp: now.UTC().Format(time.RFC3339Nano),
+		Status:    s.status,
+		Errors:    s.errors,
+		Attrs:     map[string]string{"release": hook.release},
+	}
+	if s.ended {
+		update.Duration = now.Sub(s.started).Seconds()
+	}
+	if client := hook.currentClient(); client != nil {
+		options := client.Options()
+		if update.Attrs["release"] == "" {
+			update.Attrs["release"] = options.Release
+		}
+		if options.Environment != "" {
+			update.Attrs["environment"] = options.Environment
+		}
+	}
+	s.sent = true
+	return update
+}
+
+// sendSession sends a session update. Failures are reported on Errors.
+func (hook *SentryHook) sendSession(update sessionUpdate) {
+	if !hook.Supports(CapabilitySessions) {
+		return
+	}
+	payload, err := json.Marshal(update)
+	if err == nil {
+		ctx, cancel := hook.flushContext()
+		defer cancel()
+		err = hook.sendEnvelope(ctx, nil, envelopeItem{Type: "session", Payload: payload})
+	}
+	if err != nil && err != ErrNoDSN && err != ErrUnsupported {
+		hook.reportError(nil, nil, err)
+	}
+}
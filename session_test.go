@@ -0,0 +1,81 @@
+package sentryhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func sessionUpdates(t *testing.T, server *MockServer) []sessionUpdate {
+	t.Helper()
+	var updates []sessionUpdate
+	for _, item := range server.Items() {
+		if item.Type != "session" {
+			continue
+		}
+		var u sessionUpdate
+		if err := json.Unmarshal(item.Payload, &u); err != nil {
+			t.Fatal(err)
+		}
+		updates = append(updates, u)
+	}
+	return updates
+}
+
+func TestSessionTracking(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithSessionTracking(), WithRelease("billing@1.2.3"), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Warn("retrying")
+	log.Error("charge failed")
+	log.Error("charge failed again")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := hook.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := sessionUpdates(t, server)
+	if len(updates) != 3 {
+		t.Fatalf("expected start, errored and end updates, got %+v", updates)
+	}
+	start, errored, end := updates[0], updates[1], updates[2]
+	if !start.Init || start.Status != sessionOK || start.Errors != 0 || start.Attrs["release"] != "billing@1.2.3" {
+		t.Fatalf("unexpected start %+v", start)
+	}
+	if errored.Init || errored.Errors != 1 || errored.ID != start.ID {
+		t.Fatalf("unexpected errored update %+v", errored)
+	}
+	if end.Status != sessionExited || end.Errors != 2 || end.Duration <= 0 {
+		t.Fatalf("unexpected end %+v", end)
+	}
+}
+
+func TestSessionCrashed(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithSessionTracking(), WithRelease("billing@1.2.3"), WithExitHandler(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.ExitFunc = func(int) {}
+	log.Fatal("out of disk")
+	if _, err := hook.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := sessionUpdates(t, server)
+	if len(updates) != 2 || updates[1].Status != sessionCrashed || updates[1].Errors != 1 {
+		t.Fatalf("expected a crashed session and no exit update, got %+v", updates)
+	}
+}
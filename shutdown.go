@@ -0,0 +1,141 @@
+package sentryhook
+
+import (
+	"context"
+	"time"
+)
+
+// Shutdown stages, in the order Close runs them.
+const (
+	StageStopIntake = "stop_intake"
+	StageDrainQueue = "drain_queue"
+	StageFlush      = "flush_client"
+	StageDeadLetter = "dead_letter"
+)
+
+// StageReport describes the outcome of a single shutdown stage.
+type StageReport struct {
+	Stage    string
+	Duration time.Duration
+	Err      error
+}
+
+// ShutdownReport describes the outcome of Close.
+type ShutdownReport struct {
+	Stages []StageReport
+	// the number of queued events handed to the dead letter handler
+	DeadLettered int
+}
+
+func (r *ShutdownReport) add(stage string, start time.Time, err error) {
+	r.Stages = append(r.Stages, StageReport{Stage: stage, Duration: time.Since(start), Err: err})
+}
+
+// Close shuts the hook down in stages, partitioning the time left until the
+// context deadline between them:
+//
+//  1. stop intake: pending aggregation summaries, log items and budget
+//     reports are sent, then further log entries are rejected with
+//     ErrClosed and the session, if tracked, ends
+//  2. drain queue: wait for queued events to be delivered, using half of
+//     the remaining time
+//  3. flush client: flush the sentry clients with the rest of the time
+//  4. dead letter: hand events still queued to the dead letter handler
+//
+// Without a deadline on ctx, twice the flush timeout is used as the budget.
+// The returned report lists the outcome of every stage; the error is the
+// first stage error, if any.
+func (hook *SentryHook) Close(ctx context.Context) (ShutdownReport, error) {
+	var report ShutdownReport
+	if hook.parent != nil {
+		return report, nil
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(2 * hook.flushTimeout)
+	}
+
+	start := time.Now()
+	hook.closeOnce.Do(func() {
+		// pending summaries still have to make it into the queue
+		if hook.aggregator != nil {
+			hook.aggregator.close()
+		}
+		if hook.logs != nil {
+			hook.logs.close()
+		}
+		if hook.budget != nil {
+			hook.budget.close()
+		}
+		if hook.dsnRotation != nil {
+			hook.dsnRotation.close()
+		}
+	})
+	hook.mu.Lock()
+	if hook.closed {
+		hook.mu.Unlock()
+		return report, hook.misuse(ErrClosed)
+	}
+	hook.closed = true
+	if hook.queue != nil {
+		close(hook.queue)
+	}
+	hook.mu.Unlock()
+	hook.unregisterExitHandler()
+	hook.endSession()
+	report.add(StageStopIntake, start, nil)
+
+	queued := hook.queue != nil || hook.core != nil
+	if queued {
+		start = time.Now()
+		report.add(StageDrainQueue, start, waitTimeout(ctx, &hook.wg, start.Add(time.Until(deadline)/2)))
+	}
+
+	start = time.Now()
+	var err error
+	if !hook.flushClients(time.Until(deadline)) {
+		err = ErrFlushTimeout
+	}
+	report.add(StageFlush, start, err)
+
+	if queued {
+		start = time.Now()
+		close(hook.stop)
+		// the worker may still be busy delivering an event; give it until
+		// the deadline to dead letter the rest of the queue. The workers of
+		// a core keep running, so wait for the hook's own events instead.
+		if hook.core != nil {
+			err = waitTimeout(ctx, &hook.wg, deadline)
+		} else {
+			err = waitTimeout(ctx, &hook.workers, deadline)
+		}
+		report.DeadLettered = int(hook.Stats().DeadLettered)
+		report.add(StageDeadLetter, start, err)
+	}
+
+	for _, stage := range report.Stages {
+		if stage.Err != nil {
+			return report, stage.Err
+		}
+	}
+	return report, nil
+}
+
+// waitTimeout waits for wg until the deadline or until ctx is done.
+func waitTimeout(ctx context.Context, wg interface{ Wait() }, deadline time.Time) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return context.DeadlineExceeded
+	}
+}
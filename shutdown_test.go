@@ -0,0 +1,87 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// blockingTransport blocks every SendEvent until release is closed.
+type blockingTransport struct {
+	release chan struct{}
+}
+
+func (t *blockingTransport) Configure(options sentrygo.ClientOptions) {}
+
+func (t *blockingTransport) SendEvent(event *sentrygo.Event) {
+	<-t.release
+}
+
+func (t *blockingTransport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func TestCloseDeliversQueuedEvents(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+
+	hook, err := NewAsyncSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("first")
+	log.Error("second")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report, err := hook.Close(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v (%+v)", err, report)
+	}
+	if len(report.Stages) != 4 || report.DeadLettered != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if n := len(server.Events()); n != 2 {
+		t.Fatalf("expected 2 events, got %d", n)
+	}
+	if err := hook.Fire(logrus.NewEntry(log)); err != ErrClosed {
+		t.Fatalf("expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestCloseDeadLettersLeftovers(t *testing.T) {
+	transport := &blockingTransport{release: make(chan struct{})}
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deadLettered []*sentrygo.Event
+	hook, err := NewWithClientSentryHook(client, WithDeadLetter(func(event *sentrygo.Event) {
+		deadLettered = append(deadLettered, event)
+	}), WithAsync(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("stuck in transport")
+	log.Error("left in queue")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	report, err := hook.Close(ctx)
+	if err == nil {
+		t.Fatal("expected the drain stage to time out")
+	}
+	close(transport.release)
+	hook.workers.Wait()
+
+	if len(deadLettered) != 1 || deadLettered[0].Message != "left in queue" {
+		t.Fatalf("expected the queued event to be dead lettered, got %+v (%+v)", deadLettered, report)
+	}
+}
@@ -0,0 +1,100 @@
+package sentryhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// A Sink receives a copy of every event besides Sentry, e.g. a Kafka
+// producer, a file or a webhook for an audit pipeline keeping raw copies.
+// Send must return when ctx is done; it is bounded by the hook's Timeout.
+type Sink interface {
+	Send(ctx context.Context, event *sentrygo.Event) error
+}
+
+// SinkFunc adapts a function to a Sink, e.g. to wrap a Kafka producer.
+type SinkFunc func(ctx context.Context, event *sentrygo.Event) error
+
+// Send calls f.
+func (f SinkFunc) Send(ctx context.Context, event *sentrygo.Event) error {
+	return f(ctx, event)
+}
+
+// WithSink delivers every event to the sink as well, prepared according to
+// the profile. Like destinations, each sink receives its own copy of the
+// event, is delivered to independently, through the queue in asynchronous
+// mode, and reports its failures on Errors under the given name.
+func WithSink(name string, sink Sink, profile Profile) Option {
+	return func(hook *SentryHook) {
+		hook.destinations = append(hook.destinations, &destination{
+			name:    name,
+			sink:    sink,
+			profile: profile,
+		})
+	}
+}
+
+// WriterSink returns a sink writing every event as a line of JSON to w,
+// e.g. a file.
+func WriterSink(w io.Writer) Sink {
+	s := &writerSink{w: w}
+	return SinkFunc(s.send)
+}
+
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *writerSink) send(_ context.Context, event *sentrygo.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.write(data)
+}
+
+// write writes the data as a line.
+func (s *writerSink) write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(append(data, '\n'))
+	return err
+}
+
+// WebhookSink returns a sink posting every event as JSON to the URL. A nil
+// client uses http.DefaultClient.
+func WebhookSink(url string, client *http.Client) Sink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return SinkFunc(func(ctx context.Context, event *sentrygo.Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("sentryhook: webhook rejected event with status %s", resp.Status)
+		}
+		return nil
+	})
+}
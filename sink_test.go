@@ -0,0 +1,64 @@
+package sentryhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSinks(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	var received []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event sentrygo.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		received = append(received, event.Message)
+	}))
+	defer webhook.Close()
+
+	var file bytes.Buffer
+	failing := SinkFunc(func(ctx context.Context, event *sentrygo.Event) error {
+		return errors.New("broker unavailable")
+	})
+	hook, err := NewSentryHook(server.DSN(),
+		WithSink("file", WriterSink(&file), Profile{}),
+		WithSink("webhook", WebhookSink(webhook.URL, nil), Profile{DropExtra: true}),
+		WithSink("kafka", failing, Profile{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithField("order", 7).Error("payment failed")
+
+	if n := len(server.Events()); n != 1 {
+		t.Fatalf("expected the event in sentry despite a failing sink, got %d", n)
+	}
+	if !strings.Contains(file.String(), `"message":"payment failed"`) || !strings.HasSuffix(file.String(), "}\n") {
+		t.Fatalf("expected a JSON line in the file, got %q", file.String())
+	}
+	if len(received) != 1 || received[0] != "payment failed" {
+		t.Fatalf("expected the event at the webhook, got %v", received)
+	}
+	select {
+	case e := <-hook.Errors():
+		if e.Destination != "kafka" || e.Err.Error() != "broker unavailable" {
+			t.Fatalf("unexpected delivery error %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the sink failure on Errors")
+	}
+}
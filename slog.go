@@ -0,0 +1,118 @@
+//go:build go1.21
+// +build go1.21
+
+package sentryhook
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SlogHandler is a slog.Handler sending records through a hook, with the
+// same levels, enrichment, scrubbing, volume control and delivery as
+// entries logged with logrus. Attributes become entry fields, named
+// "group.key" within groups; an error attribute named "err" or "error" is
+// reported as the entry's error.
+type SlogHandler struct {
+	hook   *SentryHook
+	attrs  logrus.Fields
+	prefix string
+}
+
+// SlogHandler returns a slog.Handler backed by the hook, so code migrating
+// from logrus to slog shares its Sentry pipeline:
+//
+//	logger := slog.New(hook.SlogHandler())
+func (hook *SentryHook) SlogHandler() *SlogHandler {
+	return &SlogHandler{hook: hook}
+}
+
+// Enabled reports whether the hook fires for the level.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.hook.enabled(logrusLevel(level))
+}
+
+// Handle sends the record through the hook.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Context = ctx
+	entry.Time = record.Time
+	entry.Level = logrusLevel(record.Level)
+	entry.Message = record.Message
+	entry.Data = make(logrus.Fields, len(h.attrs)+record.NumAttrs())
+	for k, v := range h.attrs {
+		entry.Data[k] = v
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addAttr(entry.Data, h.prefix, attr)
+		return true
+	})
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = &frame
+	}
+	return h.hook.Fire(entry)
+}
+
+// WithAttrs returns a handler adding the attributes to every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = make(logrus.Fields, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		c.attrs[k] = v
+	}
+	for _, attr := range attrs {
+		addAttr(c.attrs, h.prefix, attr)
+	}
+	return &c
+}
+
+// WithGroup returns a handler nesting the attributes of records under the
+// group.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// addAttr adds the attribute to fields, flattening groups.
+func addAttr(fields logrus.Fields, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, a := range value.Group() {
+			addAttr(fields, prefix, a)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	if err, ok := value.Any().(error); ok && prefix == "" && (attr.Key == "err" || attr.Key == "error") {
+		fields[logrus.ErrorKey] = err
+		return
+	}
+	fields[prefix+attr.Key] = value.Any()
+}
+
+// logrusLevel converts a slog level into the logrus level of the same
+// severity.
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	}
+	return logrus.ErrorLevel
+}
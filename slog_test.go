@@ -0,0 +1,39 @@
+//go:build go1.21
+// +build go1.21
+
+package sentryhook
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithFieldTags("service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(hook.SlogHandler()).With("service", "billing").WithGroup("req")
+
+	logger.Info("ignored")
+	logger.Error("charge failed", "id", 7, slog.Group("card", "brand", "visa"))
+	slog.New(hook.SlogHandler()).Warn("retrying", "err", errors.New("timeout"))
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	event := events[0]
+	if event.Message != "charge failed" || event.Level != "error" || event.Tags["service"] != "billing" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if event.Extra["req.id"] != float64(7) || event.Extra["req.card.brand"] != "visa" {
+		t.Fatalf("expected grouped attributes as extra data, got %v", event.Extra)
+	}
+	if events[1].Level != "warning" || len(events[1].Exception) == 0 || events[1].Exception[0].Value != "timeout" {
+		t.Fatalf("expected the error attribute as exception, got %+v", events[1])
+	}
+}
@@ -0,0 +1,128 @@
+package sentryhook
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// contextifyIntegration is the name of sentry-go's integration adding the
+// source lines around in-app frames.
+const contextifyIntegration = "ContextifyFrames"
+
+// maxContextFiles and maxContextBytes bound the source files kept by
+// contextLines; the cache is reset once either is reached.
+const (
+	maxContextFiles = 64
+	maxContextBytes = 8 << 20
+)
+
+// contextLines replaces sentry-go's source context integration to include
+// a configured number of lines around in-app frames, see
+// StackTraceConfiguration.Context.
+type contextLines struct {
+	lines int
+
+	mu    sync.Mutex
+	files map[string][][]byte
+	size  int
+}
+
+// withContextLines returns the integrations of the client options, with
+// the source context integration replaced by one including lines lines.
+func withContextLines(integrations func([]sentrygo.Integration) []sentrygo.Integration, lines int) func([]sentrygo.Integration) []sentrygo.Integration {
+	return func(defaults []sentrygo.Integration) []sentrygo.Integration {
+		if integrations != nil {
+			defaults = integrations(defaults)
+		}
+		result := make([]sentrygo.Integration, 0, len(defaults))
+		for _, integration := range defaults {
+			if integration.Name() == contextifyIntegration {
+				integration = &contextLines{lines: lines, files: make(map[string][][]byte)}
+			}
+			result = append(result, integration)
+		}
+		return result
+	}
+}
+
+func (c *contextLines) Name() string {
+	return contextifyIntegration
+}
+
+func (c *contextLines) SetupOnce(client *sentrygo.Client) {
+	client.AddEventProcessor(c.process)
+}
+
+func (c *contextLines) process(event *sentrygo.Event, hint *sentrygo.EventHint) *sentrygo.Event {
+	for _, exception := range event.Exception {
+		if exception.Stacktrace != nil {
+			c.addContext(exception.Stacktrace.Frames)
+		}
+	}
+	for _, thread := range event.Threads {
+		if thread.Stacktrace != nil {
+			c.addContext(thread.Stacktrace.Frames)
+		}
+	}
+	return event
+}
+
+// addContext sets the source lines around the in-app frames whose source
+// file can be read.
+func (c *contextLines) addContext(frames []sentrygo.Frame) {
+	for i := range frames {
+		frame := &frames[i]
+		if !frame.InApp || frame.AbsPath == "" || frame.Lineno <= 0 {
+			continue
+		}
+		lines := c.source(frame.AbsPath)
+		line := frame.Lineno - 1
+		if line >= len(lines) {
+			continue
+		}
+		start, end := line-c.lines, line+c.lines+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+		frame.PreContext = nil
+		for _, l := range lines[start:line] {
+			frame.PreContext = append(frame.PreContext, string(l))
+		}
+		frame.ContextLine = string(lines[line])
+		frame.PostContext = nil
+		for _, l := range lines[line+1 : end] {
+			frame.PostContext = append(frame.PostContext, string(l))
+		}
+	}
+}
+
+// source returns the lines of the file at path, nil if it cannot be read.
+func (c *contextLines) source(path string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lines, ok := c.files[path]; ok {
+		return lines
+	}
+	var lines [][]byte
+	size := 0
+	if data, err := ioutil.ReadFile(path); err == nil {
+		lines = bytes.Split(data, []byte("\n"))
+		size = len(data)
+	}
+	if size > maxContextBytes {
+		return lines
+	}
+	if len(c.files) >= maxContextFiles || c.size+size > maxContextBytes {
+		c.files = make(map[string][][]byte)
+		c.size = 0
+	}
+	c.files[path] = lines
+	c.size += size
+	return lines
+}
@@ -0,0 +1,116 @@
+package sentryhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// startupMessage is the message of the startup event.
+const startupMessage = "sentryhook started"
+
+// WithStartupEvent sends a single info event when the hook is created,
+// carrying the release, environment, configuration hash and enabled
+// features, to confirm in Sentry that a deployment picked up the intended
+// reporting configuration. It is sent regardless of the configured levels.
+func WithStartupEvent() Option {
+	return func(hook *SentryHook) {
+		hook.startupEvent = true
+	}
+}
+
+// features lists the optional features enabled on the hook, sorted.
+func (hook *SentryHook) features() []string {
+	enabled := map[string]bool{
+		"async":             hook.asynchronous,
+		"aggregation":       hook.aggregator != nil,
+		"breadcrumbs":       hook.breadcrumbs != nil,
+		"destinations":      len(hook.destinations) > 0,
+		"deterministic":     hook.rng != nil,
+		"filters":           len(hook.filters) > 0,
+		"goroutine_id":      hook.goroutineIDTag,
+		"interning":         hook.interner != nil,
+		"max_queue_age":     hook.maxQueueAge > 0,
+		"metadata":          len(hook.metadataTags) > 0 || len(hook.metadataContexts) > 0,
+		"network_limits":    hook.constraints != nil,
+		"perf_context":      hook.perfTrigger != nil,
+		"recent_events":     hook.recent != nil,
+		"runtime_context":   hook.runtimeContext,
+		"stacktrace":        !hook.disableStacktrace,
+		"auto_in_app":       hook.autoInApp,
+		"error_breadcrumbs": hook.StacktraceConfiguration.IncludeErrorBreadcrumb,
+	}
+	var features []string
+	for name, on := range enabled {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// ConfigHash returns a short hash of the hook's configuration: levels,
+// timeouts, static tags, stacktrace settings and enabled features. Hooks
+// configured alike have the same hash.
+func (hook *SentryHook) ConfigHash() string {
+	hook.configMu.RLock()
+	levels := make([]string, 0, len(hook.levels))
+	for _, level := range hook.levels {
+		levels = append(levels, level.String())
+	}
+	tags := hook.tags
+	hook.configMu.RUnlock()
+	sort.Strings(levels)
+
+	// maps are encoded with sorted keys
+	data, _ := json.Marshal(struct {
+		Levels       []string
+		Timeout      time.Duration
+		FlushTimeout time.Duration
+		Tags         map[string]string
+		StackTrace   StackTraceConfiguration
+		Features     []string
+	}{levels, hook.Timeout, hook.flushTimeout, tags, hook.StacktraceConfiguration, hook.features()})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// sendStartupEvent sends the event configured by WithStartupEvent.
+func (hook *SentryHook) sendStartupEvent() {
+	event := sentrygo.NewEvent()
+	event.EventID = hook.deterministicEventID()
+	event.Message = startupMessage
+	event.Level = sentrygo.LevelInfo
+	event.Timestamp = hook.now()
+	event.Platform = "Golang"
+	if hook.client != nil {
+		event.Environment = hook.client.Options().Environment
+	}
+	hash := hook.ConfigHash()
+	event.Tags = hook.eventTags(logrus.NewEntry(logrus.StandardLogger()))
+	event.Tags["config_hash"] = hash
+	event.Tags["startup"] = "true"
+
+	hook.configMu.RLock()
+	levels := make([]string, 0, len(hook.levels))
+	for _, level := range hook.levels {
+		levels = append(levels, level.String())
+	}
+	hook.configMu.RUnlock()
+	event.Extra = map[string]interface{}{
+		"config_hash":   hash,
+		"features":      hook.features(),
+		"levels":        levels,
+		"timeout":       hook.Timeout.String(),
+		"flush_timeout": hook.flushTimeout.String(),
+	}
+	if event = hook.scrub(event); event != nil {
+		_ = hook.dispatch(event, nil)
+	}
+}
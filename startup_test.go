@@ -0,0 +1,47 @@
+package sentryhook
+
+import (
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestStartupEvent(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(),
+		WithStartupEvent(),
+		WithRelease("1.2.3"),
+		WithLevel(logrus.ErrorLevel),
+		WithRecentEvents(10),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected the startup event, got %d events", len(events))
+	}
+	event := events[0]
+	if event.Message != startupMessage || event.Level != sentrygo.LevelInfo || event.Release != "1.2.3" {
+		t.Fatalf("unexpected startup event %+v", event)
+	}
+	if release := hook.client.Options().Release; release != "1.2.3" {
+		t.Fatalf("expected the release set on the client, got %q", release)
+	}
+	if event.Tags["config_hash"] != hook.ConfigHash() || len(hook.ConfigHash()) != 12 {
+		t.Fatalf("unexpected config hash %q", event.Tags["config_hash"])
+	}
+	features, _ := event.Extra["features"].([]interface{})
+	if len(features) != 2 || features[0] != "recent_events" || features[1] != "stacktrace" {
+		t.Fatalf("unexpected features %v", event.Extra["features"])
+	}
+
+	same, _ := NewSentryHook("", WithRelease("1.2.3"), WithLevel(logrus.ErrorLevel), WithRecentEvents(10), WithStartupEvent())
+	other, _ := NewSentryHook("", WithLevel(logrus.WarnLevel), WithRecentEvents(10), WithStartupEvent())
+	if same.ConfigHash() != hook.ConfigHash() || other.ConfigHash() == hook.ConfigHash() {
+		t.Fatal("expected the hash to follow the configuration")
+	}
+}
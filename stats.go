@@ -0,0 +1,102 @@
+package sentryhook
+
+import (
+	"sync"
+	"time"
+)
+
+// Latency summarizes measured durations.
+type Latency struct {
+	Count int64
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Mean returns the average duration, or 0 if nothing was measured.
+func (l Latency) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Total / time.Duration(l.Count)
+}
+
+func (l *Latency) observe(d time.Duration) {
+	l.Count++
+	l.Total += d
+	if d > l.Max {
+		l.Max = d
+	}
+}
+
+// Stats are counters describing the health of a hook.
+type Stats struct {
+	// events accepted by the client
+	Sent int64
+	// events the client failed to accept or flush
+	Failed int64
+	// failed events which did not complete within the hook's Timeout
+	TimedOut int64
+	// events dropped because the queue was full
+	Dropped int64
+	// events dropped because they waited in the queue longer than the
+	// maximum queue age
+	DroppedStale int64
+	// events dropped by sampling
+	Sampled int64
+	// events dropped by load shedding
+	Shed int64
+	// events dropped by rules
+	DroppedByRule int64
+	// events dropped by the event budget
+	OverBudget int64
+	// events dropped by the limits of events and bytes in flight
+	DroppedInFlight int64
+	// events stripped to fit the budget of bytes in flight
+	Degraded int64
+	// events rejected for missing required tags
+	Rejected int64
+	// deliveries retried, see WithRetries
+	Retried int64
+	// events handed to the dead letter handler on Close
+	DeadLettered int64
+	// requests rejected by the network constraints
+	Throttled int64
+	// time events spent waiting in the queue of an asynchronous hook
+	QueueLatency Latency
+	// time spent capturing and flushing events
+	DeliveryLatency Latency
+}
+
+// stats guards the Stats of a hook.
+type stats struct {
+	mu sync.Mutex
+	Stats
+}
+
+func (s *stats) update(fn func(stats *Stats)) {
+	s.mu.Lock()
+	fn(&s.Stats)
+	s.mu.Unlock()
+}
+
+// WithTimeSource sets the clock used to measure latencies, queue ages and
+// the windows of sampling, escalation and network constraints. It defaults
+// to time.Now, whose monotonic reading keeps them correct when the wall
+// clock is stepped, e.g. by NTP or after a VM pause; custom sources should
+// preserve it. Event timestamps always use the wall-clock time of the log
+// entry.
+func WithTimeSource(now func() time.Time) Option {
+	return func(hook *SentryHook) {
+		hook.now = now
+	}
+}
+
+// Stats returns a snapshot of the hook's counters.
+func (hook *SentryHook) Stats() Stats {
+	if hook.parent != nil {
+		return hook.parent.Stats()
+	}
+	hook.stats.mu.Lock()
+	defer hook.stats.mu.Unlock()
+	return hook.stats.Stats
+}
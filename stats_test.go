@@ -0,0 +1,97 @@
+package sentryhook
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestStatsUseTimeSource(t *testing.T) {
+	clock := time.Unix(0, 0)
+	now := func() time.Time {
+		clock = clock.Add(10 * time.Millisecond)
+		return clock
+	}
+	hook, err := NewSentryHook("", WithTimeSource(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("measured")
+
+	stats := hook.Stats()
+	if stats.Sent != 1 || stats.Failed != 0 {
+		t.Fatalf("unexpected counters %+v", stats)
+	}
+	if stats.DeliveryLatency.Count != 1 || stats.DeliveryLatency.Mean() != 10*time.Millisecond {
+		t.Fatalf("unexpected delivery latency %+v", stats.DeliveryLatency)
+	}
+	if stats.QueueLatency.Count != 0 {
+		t.Fatalf("expected no queue latency in synchronous mode, got %+v", stats.QueueLatency)
+	}
+}
+
+// signallingTransport signals entered on every SendEvent and blocks until
+// release is closed.
+type signallingTransport struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (t *signallingTransport) Configure(options sentrygo.ClientOptions) {}
+
+func (t *signallingTransport) SendEvent(event *sentrygo.Event) {
+	t.entered <- struct{}{}
+	<-t.release
+}
+
+func (t *signallingTransport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func TestMaxQueueAge(t *testing.T) {
+	transport := &signallingTransport{entered: make(chan struct{}, 2), release: make(chan struct{})}
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	clock := time.Unix(0, 0)
+	now := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	hook, err := NewWithClientSentryHook(client, WithAsync(true), WithTimeSource(now), WithMaxQueueAge(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	log.Error("fresh")
+	<-transport.entered
+	log.Error("stale")
+	mu.Lock()
+	clock = clock.Add(2 * time.Minute)
+	mu.Unlock()
+	close(transport.release)
+	hook.Flush()
+
+	stats := hook.Stats()
+	if stats.Sent != 1 || stats.DroppedStale != 1 {
+		t.Fatalf("unexpected counters %+v", stats)
+	}
+	select {
+	case deliveryErr := <-hook.Errors():
+		if deliveryErr.Err != ErrStale || deliveryErr.Event.Message != "stale" {
+			t.Fatalf("unexpected error %+v", deliveryErr)
+		}
+	default:
+		t.Fatal("expected the stale event to be reported")
+	}
+}
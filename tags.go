@@ -0,0 +1,221 @@
+package sentryhook
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// TagSource identifies where the value of an event tag comes from.
+type TagSource int
+
+const (
+	// TagSourceMetadata are tags discovered from the environment, see
+	// WithKubernetesMetadata and WithCloudMetadata.
+	TagSourceMetadata TagSource = iota
+	// TagSourceHook are the static tags of the hook, see WithTags and SetTag.
+	TagSourceHook
+	// TagSourceDynamic are tags computed when the event is built, like the
+	// goroutine id, the source logger name, lazy tags and temporary tags.
+	TagSourceDynamic
+	// TagSourceEntry are entry fields promoted to tags, see WithFieldTags.
+	TagSourceEntry
+	// TagSourceScope are the tags set on the scope of the hub.
+	TagSourceScope
+)
+
+// defaultTagPrecedence lists the tag sources from the highest precedence to
+// the lowest. Scope tags win because the client applies the scope last.
+var defaultTagPrecedence = []TagSource{
+	TagSourceScope,
+	TagSourceEntry,
+	TagSourceDynamic,
+	TagSourceHook,
+	TagSourceMetadata,
+}
+
+func (s TagSource) String() string {
+	switch s {
+	case TagSourceMetadata:
+		return "metadata"
+	case TagSourceHook:
+		return "hook"
+	case TagSourceDynamic:
+		return "dynamic"
+	case TagSourceEntry:
+		return "entry"
+	case TagSourceScope:
+		return "scope"
+	}
+	return "TagSource(" + strconv.Itoa(int(s)) + ")"
+}
+
+// TagConflict describes a tag set by several sources to different values.
+type TagConflict struct {
+	Key string
+	// the source whose value is sent and its value
+	Winner TagSource
+	Value  string
+	// the source whose value was discarded and its value
+	Loser     TagSource
+	Discarded string
+}
+
+// WithTagPrecedence sets which source wins when several sources set the same
+// tag, listing sources from the highest precedence to the lowest. Sources
+// left out rank below the listed ones in their default order, which is
+// scope, entry, dynamic, hook, metadata.
+func WithTagPrecedence(order ...TagSource) Option {
+	return func(hook *SentryHook) {
+		precedence := make([]TagSource, 0, len(defaultTagPrecedence))
+		seen := make(map[TagSource]bool, len(defaultTagPrecedence))
+		for _, s := range append(order, defaultTagPrecedence...) {
+			if !seen[s] {
+				seen[s] = true
+				precedence = append(precedence, s)
+			}
+		}
+		hook.tagPrecedence = precedence
+	}
+}
+
+// WithTagConflictHandler calls fn for every tag set by several sources to
+// different values, reporting which value was kept. It is called from the
+// logging goroutine and must not log to the hook's logger.
+func WithTagConflictHandler(fn func(conflict TagConflict)) Option {
+	return func(hook *SentryHook) {
+		hook.tagConflicts = fn
+	}
+}
+
+// WithFieldTags promotes the entry fields with the given keys to tags. The
+// fields are still sent as extra data.
+func WithFieldTags(keys ...string) Option {
+	return func(hook *SentryHook) {
+		hook.fieldTags = append([]string(nil), keys...)
+	}
+}
+
+// eventTags returns the tags for a new event, merging the sources by their
+// precedence. The configured tags are copied so that per-event tags never
+// leak into the hook configuration.
+func (hook *SentryHook) eventTags(entry *logrus.Entry) map[string]string {
+	precedence := hook.tagPrecedence
+	if precedence == nil {
+		precedence = defaultTagPrecedence
+	}
+	// scope tags are applied by the client; they only need to be merged
+	// here if they may lose or conflicts are reported
+	withScope := hook.tagConflicts != nil || precedence[0] != TagSourceScope
+
+	tags := make(map[string]string)
+	origin := make(map[string]TagSource)
+	for i := len(precedence) - 1; i >= 0; i-- {
+		source := precedence[i]
+		if source == TagSourceScope && !withScope {
+			continue
+		}
+		for k, v := range hook.sourceTags(source, entry) {
+			if prev, ok := tags[k]; ok && prev != v && hook.tagConflicts != nil {
+				hook.tagConflicts(TagConflict{
+					Key:       k,
+					Winner:    source,
+					Value:     v,
+					Loser:     origin[k],
+					Discarded: prev,
+				})
+			}
+			tags[k] = v
+			origin[k] = source
+		}
+	}
+	return tags
+}
+
+// sourceTags returns the tags of a single source.
+func (hook *SentryHook) sourceTags(source TagSource, entry *logrus.Entry) map[string]string {
+	switch source {
+	case TagSourceMetadata:
+		return hook.metadataTags
+	case TagSourceHook:
+		return hook.staticTags()
+	case TagSourceDynamic:
+		tags := make(map[string]string, len(hook.lazyTags)+2)
+		for k, fn := range hook.lazyTags {
+			tags[k] = hook.resolveTag(k, fn)
+		}
+		if hook.goroutineIDTag {
+			tags[goroutineTag] = strconv.FormatUint(goroutineID(), 10)
+		}
+		if name := hook.sourceName(entry); name != "" {
+			tags[sourceLoggerTag] = name
+		}
+		if name := hook.loggerName(entry); name != "" {
+			tags[loggerTag] = name
+		}
+		for k, v := range contextTags(entry.Context) {
+			tags[k] = v
+		}
+		hook.temporaryTags.add(tags)
+		return tags
+	case TagSourceEntry:
+		tags := make(map[string]string, len(hook.fieldTags))
+		for _, k := range hook.fieldTags {
+			if v, ok := entry.Data[k]; ok {
+				tags[k] = fmt.Sprint(v)
+			}
+		}
+		return tags
+	case TagSourceScope:
+		tags := scopeTags(hook.currentHub().Scope())
+		if hub := hook.contextHub(entry); hub != nil {
+			for k, v := range scopeTags(hub.Scope()) {
+				tags[k] = v
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+// scopeTags returns the tags set on the scope. The scope has no accessor
+// for them and applying it to an event would run its event processors,
+// which may have side effects, so they are read from a clone.
+func scopeTags(scope *sentrygo.Scope) map[string]string {
+	field := reflect.ValueOf(scope.Clone()).Elem().FieldByName("tags")
+	tags := make(map[string]string)
+	if field.Kind() != reflect.Map || field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+		return tags
+	}
+	iter := field.MapRange()
+	for iter.Next() {
+		tags[iter.Key().String()] = iter.Value().String()
+	}
+	return tags
+}
+
+// currentHub returns the hub whose scope is applied to events.
+func (hook *SentryHook) currentHub() *sentrygo.Hub {
+	if hook.hub != nil {
+		return hook.hub
+	}
+	return sentrygo.CurrentHub()
+}
+
+// eventScope returns the scope to capture the event with, a clone of the
+// hub's scope, so concurrent captures and event processors do not share
+// state. Unless scope tags have the highest precedence, the event's tags
+// already hold the merged values and must not be overwritten by the scope.
+func (hook *SentryHook) eventScope(event *sentrygo.Event) *sentrygo.Scope {
+	scope := hook.currentHub().Scope().Clone()
+	if hook.tagPrecedence == nil || hook.tagPrecedence[0] == TagSourceScope {
+		return scope
+	}
+	for k := range event.Tags {
+		scope.RemoveTag(k)
+	}
+	return scope
+}
@@ -0,0 +1,112 @@
+package sentryhook
+
+import (
+	"testing"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTagPrecedence(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+
+	hub := sentrygo.NewHub(nil, sentrygo.NewScope())
+	hub.Scope().SetTag("team", "scope")
+	hub.Scope().SetTag("region", "scope")
+
+	var conflicts []TagConflict
+	hook, err := NewSentryHook(server.DSN(),
+		WithHub(hub),
+		WithTags(map[string]string{"team": "hook", "region": "hook"}),
+		WithFieldTags("team"),
+		WithTagPrecedence(TagSourceEntry, TagSourceHook),
+		WithTagConflictHandler(func(c TagConflict) {
+			conflicts = append(conflicts, c)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.WithField("team", "entry").Error("boom")
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	tags := events[0].Tags
+	if tags["team"] != "entry" || tags["region"] != "hook" {
+		t.Fatalf("unexpected tags %v", tags)
+	}
+	if hub.Scope().Clone().ApplyToEvent(sentrygo.NewEvent(), nil).Tags["team"] != "scope" {
+		t.Fatal("expected the hub scope to be left alone")
+	}
+
+	found := map[string]TagConflict{}
+	for _, c := range conflicts {
+		if c.Winner == TagSourceEntry || c.Winner == TagSourceHook {
+			found[c.Key+"/"+c.Winner.String()] = c
+		}
+	}
+	if c, ok := found["region/hook"]; !ok || c.Loser != TagSourceScope || c.Discarded != "scope" {
+		t.Fatalf("expected the scope region to lose to the hook, got %+v", conflicts)
+	}
+	if c, ok := found["team/entry"]; !ok || c.Loser != TagSourceHook || c.Discarded != "hook" {
+		t.Fatalf("expected the hook team to lose to the entry, got %+v", conflicts)
+	}
+}
+
+func TestTagPrecedenceDefault(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+
+	hub := sentrygo.NewHub(nil, sentrygo.NewScope())
+	hub.Scope().SetTag("team", "scope")
+	hook, err := NewSentryHook(server.DSN(), WithHub(hub), WithTags(map[string]string{"team": "hook"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("boom")
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Tags["team"] != "scope" {
+		t.Fatalf("expected the scope tag to win by default, got %+v", events)
+	}
+}
+
+func TestScopeTagsWithoutProcessors(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+
+	// the processor runs as often with scope tags merged by the hook as
+	// without
+	var processed [2]int
+	for i, opts := range [][]Option{nil, {WithTagConflictHandler(func(TagConflict) {})}} {
+		hub := sentrygo.NewHub(nil, sentrygo.NewScope())
+		hub.Scope().SetTag("team", "scope")
+		counter := &processed[i]
+		hub.Scope().AddEventProcessor(func(event *sentrygo.Event, hint *sentrygo.EventHint) *sentrygo.Event {
+			*counter++
+			return event
+		})
+		hook, err := NewSentryHook(server.DSN(), append(opts, WithHub(hub))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		log.Error("boom")
+	}
+
+	events := server.Events()
+	if len(events) != 2 || events[1].Tags["team"] != "scope" {
+		t.Fatalf("expected the scope tag, got %+v", events)
+	}
+	if processed[1] != processed[0] {
+		t.Fatalf("expected the scope's processor to run %d times, ran %d times", processed[0], processed[1])
+	}
+}
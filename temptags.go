@@ -0,0 +1,92 @@
+package sentryhook
+
+import (
+	"context"
+	"sync"
+)
+
+// WithTemporaryTags returns a function running fn with the tags added to
+// the events fired by the goroutine running it, e.g. to mark the events of
+// a migration:
+//
+//	hook.WithTemporaryTags(map[string]string{"operation": "migration"})(func() {
+//		migrate(db)
+//	})
+//
+// Calls may be nested; inner tags win. Goroutines started by fn are not
+// covered, pass a context made by ContextWithTags to them instead. The tags
+// are dynamic tags, see TagSourceDynamic.
+func (hook *SentryHook) WithTemporaryTags(tags map[string]string) func(fn func()) {
+	tags = copyTags(tags, 0)
+	return func(fn func()) {
+		id := goroutineID()
+		hook.temporaryTags.push(id, tags)
+		defer hook.temporaryTags.pop(id)
+		fn()
+	}
+}
+
+// temporaryTagsKey is the context key of the tags of ContextWithTags.
+type temporaryTagsKey struct{}
+
+// ContextWithTags returns a context adding the tags to the events of
+// entries logged with it, e.g. by log.WithContext(ctx), for as long as the
+// context is used. The tags are merged with those of the parent context;
+// the new ones win. They are dynamic tags, see TagSourceDynamic.
+func ContextWithTags(ctx context.Context, tags map[string]string) context.Context {
+	parent, _ := ctx.Value(temporaryTagsKey{}).(map[string]string)
+	merged := copyTags(parent, len(tags))
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, temporaryTagsKey{}, merged)
+}
+
+// contextTags returns the tags of ContextWithTags, nil if there are none.
+func contextTags(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(temporaryTagsKey{}).(map[string]string)
+	return tags
+}
+
+// goroutineTags holds the tags of WithTemporaryTags by goroutine id.
+type goroutineTags struct {
+	mu     sync.RWMutex
+	active map[uint64][]map[string]string
+}
+
+func (g *goroutineTags) push(id uint64, tags map[string]string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.active == nil {
+		g.active = make(map[uint64][]map[string]string)
+	}
+	g.active[id] = append(g.active[id], tags)
+}
+
+func (g *goroutineTags) pop(id uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stack := g.active[id]
+	if len(stack) <= 1 {
+		delete(g.active, id)
+		return
+	}
+	g.active[id] = stack[:len(stack)-1]
+}
+
+// add adds the tags of the calling goroutine to tags.
+func (g *goroutineTags) add(tags map[string]string) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.active) == 0 {
+		return
+	}
+	for _, t := range g.active[goroutineID()] {
+		for k, v := range t {
+			tags[k] = v
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package sentryhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTemporaryTags(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+
+	hook.WithTemporaryTags(map[string]string{"operation": "migration", "step": "outer"})(func() {
+		log.Error("outer")
+		hook.WithTemporaryTags(map[string]string{"step": "inner"})(func() {
+			log.Error("inner")
+		})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			log.Error("other goroutine")
+		}()
+		<-done
+	})
+	log.Error("after")
+	ctx := ContextWithTags(ContextWithTags(context.Background(), map[string]string{"operation": "backfill"}), map[string]string{"batch": "7"})
+	log.WithContext(ctx).Error("context")
+
+	events := server.Events()
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+	want := []map[string]string{
+		{"operation": "migration", "step": "outer"},
+		{"operation": "migration", "step": "inner"},
+		{},
+		{},
+		{"operation": "backfill", "batch": "7"},
+	}
+	for i, event := range events {
+		for _, k := range []string{"operation", "step", "batch"} {
+			if event.Tags[k] != want[i][k] {
+				t.Errorf("%s: expected %s=%q, got %q", event.Message, k, want[i][k], event.Tags[k])
+			}
+		}
+	}
+	if len(hook.temporaryTags.active) != 0 {
+		t.Fatal("expected the temporary tags to be removed")
+	}
+}
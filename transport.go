@@ -0,0 +1,103 @@
+package sentryhook
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// WithTransport replaces the transport of the client created by
+// NewSentryHook, e.g. with a fake one in tests. The hook's HTTP layer,
+// capability detection and network constraints, is bypassed along with
+// the HTTP transport of the SDK.
+func WithTransport(transport sentrygo.Transport) Option {
+	return func(hook *SentryHook) {
+		hook.clientOptions.Transport = transport
+	}
+}
+
+// WithHTTPClient sets the HTTP client events are sent with by the client
+// created by NewSentryHook, e.g. to route them through a corporate proxy,
+// present mTLS client certificates or pin the CA of a self-hosted Sentry or
+// GlitchTip. Capability detection and network constraints wrap the client's
+// transport; the client itself is not modified.
+func WithHTTPClient(client *http.Client) Option {
+	return func(hook *SentryHook) {
+		hook.clientOptions.HTTPClient = client
+	}
+}
+
+// WithProxy sends events through the HTTP proxy at the URL, e.g.
+// "http://proxy.internal:3128", instead of the proxy configured by the
+// HTTP_PROXY and HTTPS_PROXY environment variables. It is ignored with
+// WithHTTPClient, whose client has its own transport.
+func WithProxy(proxyURL string) Option {
+	return func(hook *SentryHook) {
+		hook.proxyURL = proxyURL
+	}
+}
+
+// WithCACert trusts the PEM encoded CA certificates in addition to the
+// system roots, e.g. the internal CA of an egress proxy or of a self-hosted
+// Sentry. It may be given several times. It is ignored with WithHTTPClient,
+// whose client has its own transport.
+func WithCACert(pem []byte) Option {
+	return func(hook *SentryHook) {
+		hook.caCerts = append(hook.caCerts, pem)
+	}
+}
+
+// baseTransport returns the HTTP transport events are sent with, or nil
+// for the default transport if no proxy or CA certificates are configured.
+func (hook *SentryHook) baseTransport() (http.RoundTripper, error) {
+	if hook.proxyURL == "" && len(hook.caCerts) == 0 {
+		return nil, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if hook.proxyURL != "" {
+		u, err := url.Parse(hook.proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("sentryhook: invalid proxy URL: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if len(hook.caCerts) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, pem := range hook.caCerts {
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.New("sentryhook: no valid CA certificate in PEM data")
+			}
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
+
+// wrapTransport adds capability detection, network constraints, the
+// propagation of dynamic sampling contexts and the recording of delivery
+// outcomes to the HTTP transport events are sent with.
+func (hook *SentryHook) wrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	rt := &capabilityTransport{base: &dscTransport{base: base, hook: hook}, hook: hook}
+	return hook.deliveryTransport(rt, &hook.outcomes)
+}
+
+// deliveryTransport applies the network constraints to rt and records the
+// outcomes of the deliveries made through it.
+func (hook *SentryHook) deliveryTransport(rt http.RoundTripper, outcomes *deliveryOutcomes) http.RoundTripper {
+	if hook.constraints != nil {
+		rt = hook.constrainedTransport(rt)
+	}
+	// outermost, to see requests refused by the constraints as well
+	return &outcomeTransport{base: rt, outcomes: outcomes}
+}
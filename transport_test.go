@@ -0,0 +1,162 @@
+package sentryhook
+
+import (
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// recordingTransport keeps the events it is asked to send.
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []*sentrygo.Event
+}
+
+func (t *recordingTransport) Configure(sentrygo.ClientOptions) {}
+
+func (t *recordingTransport) SendEvent(event *sentrygo.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *recordingTransport) Flush(time.Duration) bool {
+	return true
+}
+
+func TestWithTransport(t *testing.T) {
+	transport := &recordingTransport{}
+	hook, err := NewSentryHook("https://key@sentry.example.com/1", WithTransport(transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("boom")
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.events) != 1 || transport.events[0].Message != "boom" {
+		t.Fatalf("expected the event at the transport, got %+v", transport.events)
+	}
+}
+
+// countingRoundTripper counts the requests passing through it.
+type countingRoundTripper struct {
+	mu       sync.Mutex
+	requests int
+}
+
+func (rt *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.requests++
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	rt := &countingRoundTripper{}
+	client := &http.Client{Transport: rt}
+	hook, err := NewSentryHook(server.DSN(), WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("boom")
+
+	if len(server.Events()) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(server.Events()))
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.requests != 1 {
+		t.Fatalf("expected the event sent through the client, got %d requests", rt.requests)
+	}
+	if client.Transport != rt {
+		t.Fatal("expected the client to be left unmodified")
+	}
+}
+
+func TestWithCACert(t *testing.T) {
+	server := &MockServer{}
+	server.server = httptest.NewTLSServer(http.HandlerFunc(server.handle))
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.server.Certificate().Raw})
+
+	untrusted, err := NewSentryHook(server.DSN(), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var transportErr TransportError
+	if err := untrusted.Fire(logrus.NewEntry(logrus.New())); !errors.As(err, &transportErr) || transportErr.Err == nil {
+		t.Fatalf("expected the TLS handshake to fail without the CA, got %v", err)
+	}
+	if len(server.Events()) != 0 {
+		t.Fatalf("expected no event delivered without the CA, got %d", len(server.Events()))
+	}
+
+	hook, err := NewSentryHook(server.DSN(), WithCACert(ca))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("boom")
+	if len(server.Events()) != 1 {
+		t.Fatalf("expected the event delivered over TLS, got %d", len(server.Events()))
+	}
+
+	if _, err := NewSentryHook(server.DSN(), WithCACert([]byte("not a certificate"))); err == nil {
+		t.Fatal("expected an error for invalid PEM data")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	var mu sync.Mutex
+	var proxied int
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied++
+		mu.Unlock()
+		r.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	hook, err := NewSentryHook(server.DSN(), WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("boom")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(server.Events()) != 1 || proxied != 1 {
+		t.Fatalf("expected the event delivered through the proxy, got %d events and %d proxied requests", len(server.Events()), proxied)
+	}
+	if _, err := NewSentryHook(server.DSN(), WithProxy("://bad")); err == nil {
+		t.Fatal("expected an error for an invalid proxy URL")
+	}
+}
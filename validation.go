@@ -0,0 +1,83 @@
+package sentryhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+// ErrNotValidating is returned by WaitValidated for hooks created without
+// WithStartupValidationTimeout.
+var ErrNotValidating = errors.New("sentryhook: hook does not validate its DSN")
+
+// WithStartupValidationTimeout makes NewSentryHook return immediately and
+// validate the DSN in the background, resolving its host for at most
+// timeout, so a slow or failing DNS does not hold up the start of the
+// service. Events are buffered meanwhile, up to the queue size, and sent
+// once validation completes. If it fails, the failure is reported on Errors
+// and returned by WaitValidated, and the buffered events are sent anyway,
+// in case the host becomes reachable later.
+func WithStartupValidationTimeout(timeout time.Duration) Option {
+	return func(hook *SentryHook) {
+		hook.validationTimeout = timeout
+	}
+}
+
+// WaitValidated waits until the background validation of the DSN has
+// completed or ctx is done, and returns its outcome.
+func (hook *SentryHook) WaitValidated(ctx context.Context) error {
+	if hook.validated == nil {
+		return ErrNotValidating
+	}
+	select {
+	case <-hook.validated:
+		return hook.validationErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// validateInBackground leaves the hook pending until the DSN of the client
+// is validated, then sets the client, unless one was set meanwhile, e.g. by
+// RefreshDSN.
+func (hook *SentryHook) validateInBackground(client *sentrygo.Client) {
+	hook.pendingMax = hook.queueSize
+	if hook.pendingMax <= 0 {
+		hook.pendingMax = defaultQueueSize
+	}
+	hook.validated = make(chan struct{})
+	go func() {
+		defer close(hook.validated)
+		if err := hook.validateDSN(client.Options().Dsn); err != nil {
+			hook.validationErr = err
+			hook.reportError(nil, nil, err)
+		}
+		_, _ = hook.installClient(client)
+	}()
+}
+
+// validateDSN resolves the host of the DSN within the validation timeout.
+func (hook *SentryHook) validateDSN(dsn string) error {
+	parsed, err := sentrygo.NewDsn(dsn)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hook.validationTimeout)
+	defer cancel()
+	host := parsed.StoreAPIURL().Hostname()
+	if _, err := hook.resolver.LookupHost(ctx, host); err != nil {
+		return fmt.Errorf("sentryhook: resolving sentry host %s: %v", host, err)
+	}
+	return nil
+}
+
+// hostResolver resolves host names; *net.Resolver implements it.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+var _ hostResolver = (*net.Resolver)(nil)
@@ -0,0 +1,104 @@
+package sentryhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// blockingResolver resolves hosts once release is closed.
+type blockingResolver struct {
+	release chan struct{}
+	err     error
+}
+
+func (r *blockingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	select {
+	case <-r.release:
+		return []string{"127.0.0.1"}, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestStartupValidation(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		server := NewMockServer()
+		resolver := &blockingResolver{release: make(chan struct{})}
+		if fail {
+			resolver.err = errors.New("no such host")
+		}
+		hook, err := NewSentryHook(server.DSN(), WithStartupValidationTimeout(time.Minute), func(hook *SentryHook) {
+			hook.resolver = resolver
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		log := logrus.New()
+		log.Hooks.Add(hook)
+		log.Error("logged during validation")
+		if n := len(server.Events()); n != 0 {
+			t.Fatalf("expected events to be buffered during validation, got %d", n)
+		}
+
+		close(resolver.release)
+		err = hook.WaitValidated(context.Background())
+		if fail != (err != nil) {
+			t.Fatalf("fail %v: unexpected validation outcome %v", fail, err)
+		}
+		events := server.Events()
+		if len(events) != 1 || events[0].Tags[bufferedPreInitTag] != "true" {
+			t.Fatalf("fail %v: expected the buffered event to be sent, got %+v", fail, events)
+		}
+		server.Close()
+	}
+}
+
+func TestWaitValidatedWithoutValidation(t *testing.T) {
+	hook, err := NewSentryHook("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.WaitValidated(context.Background()); err != ErrNotValidating {
+		t.Fatalf("expected ErrNotValidating, got %v", err)
+	}
+}
+
+func TestStartupValidationAfterRefresh(t *testing.T) {
+	first, second := NewMockServer(), NewMockServer()
+	defer first.Close()
+	defer second.Close()
+	dsn := first.DSN()
+	resolver := &blockingResolver{release: make(chan struct{})}
+	hook, err := NewSentryHook("", WithStartupValidationTimeout(time.Minute), WithPanicOnMisuse(),
+		WithDSNProvider(func() (string, error) { return dsn, nil }),
+		func(hook *SentryHook) {
+			hook.resolver = resolver
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Hooks.Add(hook)
+	log.Error("logged during validation")
+
+	dsn = second.DSN()
+	if err := hook.RefreshDSN(); err != nil {
+		t.Fatal(err)
+	}
+	close(resolver.release)
+	if err := hook.WaitValidated(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	log.Error("logged after validation")
+
+	if n := len(first.Events()); n != 0 {
+		t.Fatalf("expected the validated client to be discarded, got %d events", n)
+	}
+	if n := len(second.Events()); n != 2 {
+		t.Fatalf("expected both events sent with the refreshed client, got %d", n)
+	}
+}
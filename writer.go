@@ -0,0 +1,131 @@
+package sentryhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Writer returns a writer turning every written line into an entry of the
+// level fed through the hook, for code writing to an io.Writer, like the
+// standard library logger after log.SetOutput, instead of using logrus.
+// Lines holding a JSON object are decoded: "msg" or "message" becomes the
+// message, "level" and "time" (RFC 3339) replace the level and time if they
+// parse, "error" or "err" becomes the entry error and all other keys become
+// fields. It is safe for concurrent use; an incomplete last line is kept
+// until its newline is written.
+func (hook *SentryHook) Writer(level logrus.Level) io.Writer {
+	return &lineWriter{hook: hook, level: level}
+}
+
+// StdLogger returns a standard library logger whose output is fed through
+// the hook as entries of the level, e.g. for http.Server.ErrorLog. Lines
+// are handled as by Writer; in plain lines the first error-looking part,
+// like "TLS handshake error from 10.0.0.1:4242: EOF" in
+// "http: TLS handshake error from 10.0.0.1:4242: EOF", becomes the entry
+// error and thus the exception value.
+func (hook *SentryHook) StdLogger(level logrus.Level) *log.Logger {
+	return log.New(&lineWriter{hook: hook, level: level, extractErrors: true}, "", 0)
+}
+
+// errorWords mark the parts of a line which look like an error.
+var errorWords = []string{
+	"error", "err", "fail", "refused", "reset", "timeout", "timed out",
+	"eof", "denied", "invalid", "broken pipe", "panic", "unexpected",
+}
+
+// errorToken returns the part of a line from the first ": " separated
+// segment which looks like an error, or "" if none does.
+func errorToken(line string) string {
+	segments := strings.Split(line, ": ")
+	for i, segment := range segments {
+		lower := strings.ToLower(segment)
+		for _, word := range errorWords {
+			if strings.Contains(lower, word) {
+				return strings.Join(segments[i:], ": ")
+			}
+		}
+	}
+	return ""
+}
+
+type lineWriter struct {
+	hook  *SentryHook
+	level logrus.Level
+	// whether errors are extracted from plain lines
+	extractErrors bool
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimRight(w.buf[:i], "\r")
+		w.buf = w.buf[i+1:]
+		if len(bytes.TrimSpace(line)) > 0 {
+			_ = w.hook.Fire(w.entry(line))
+		}
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// entry converts a line into an entry.
+func (w *lineWriter) entry(line []byte) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Time = w.hook.now()
+	entry.Level = w.level
+	entry.Message = string(line)
+
+	var fields map[string]interface{}
+	if line[0] != '{' || json.Unmarshal(line, &fields) != nil {
+		if token := errorToken(entry.Message); token != "" && w.extractErrors {
+			entry.Data[logrus.ErrorKey] = errors.New(token)
+		}
+		return entry
+	}
+	entry.Message = ""
+	entry.Data = make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		s, isString := v.(string)
+		switch {
+		case (k == "msg" || k == "message") && isString && entry.Message == "":
+			entry.Message = s
+		case k == "level" && isString:
+			if level, err := logrus.ParseLevel(s); err == nil {
+				entry.Level = level
+			} else {
+				entry.Data[k] = v
+			}
+		case k == "time" && isString:
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				entry.Time = t
+			} else {
+				entry.Data[k] = v
+			}
+		case k == "error" || k == "err":
+			entry.Data[logrus.ErrorKey] = errors.New(fmt.Sprint(v))
+		default:
+			entry.Data[k] = v
+		}
+	}
+	return entry
+}
@@ -0,0 +1,68 @@
+package sentryhook
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWriter(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN(), WithLevel(logrus.WarnLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := log.New(hook.Writer(logrus.ErrorLevel), "", 0)
+	legacy.Print("legacy failure")
+	w := hook.Writer(logrus.WarnLevel)
+	fmt.Fprint(w, `{"msg":"charge failed","level":"error","time":"2020-01-01T12:00:00Z",`)
+	fmt.Fprint(w, `"error":"card declined","order":7}`+"\r\n\n")
+	fmt.Fprintln(w, `{"msg":"verbose","level":"info"}`)
+	fmt.Fprint(w, "incomplete")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Message != "legacy failure" || events[0].Level != "error" {
+		t.Fatalf("unexpected plain line event %+v", events[0])
+	}
+	event := events[1]
+	if event.Message != "charge failed" || event.Level != "error" || event.Timestamp.Year() != 2020 {
+		t.Fatalf("unexpected JSON line event %+v", event)
+	}
+	if event.Extra["order"] != float64(7) || len(event.Exception) == 0 || event.Exception[0].Value != "card declined" {
+		t.Fatalf("expected the JSON fields and error, got %+v", event)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	server := NewMockServer()
+	defer server.Close()
+	hook, err := NewSentryHook(server.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := hook.StdLogger(logrus.ErrorLevel)
+	logger.Print("http: TLS handshake error from 10.0.0.1:4242: EOF")
+	logger.Print("http: superfluous response.WriteHeader call")
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Message != "http: TLS handshake error from 10.0.0.1:4242: EOF" ||
+		len(events[0].Exception) == 0 || events[0].Exception[0].Value != "TLS handshake error from 10.0.0.1:4242: EOF" {
+		t.Fatalf("expected the error part as exception value, got %+v", events[0])
+	}
+	for _, exception := range events[1].Exception {
+		if exception.Value != "" {
+			t.Fatalf("expected no error extracted, got %+v", events[1].Exception)
+		}
+	}
+}